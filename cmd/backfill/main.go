@@ -1,5 +1,8 @@
 // Backfill loads existing project knowledge into DevMemory.
 // Usage: go run ./cmd/backfill --project-id=plss-fhir --root=/path/to/project
+//
+// With --claude-transcripts=DIR it instead imports the Claude Code .jsonl
+// session transcripts in DIR as sessions.
 package main
 
 import (
@@ -9,22 +12,29 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/extract"
 	"github.com/Platform-LSS/devmemory/internal/store"
+	"github.com/Platform-LSS/devmemory/internal/transcript"
 )
 
+// embedIncludeKey mirrors EMBEDDING_INCLUDE_KEY for imported memories.
+var embedIncludeKey = true
+
 func main() {
 	projectID := flag.String("project-id", "plss-fhir", "Project ID")
 	projectName := flag.String("project-name", "PLSS FHIR Server", "Project display name")
 	rootPath := flag.String("root", "", "Project root path")
 	dbURL := flag.String("db", "", "Database URL (or DATABASE_URL env)")
 	embURL := flag.String("embed-url", "", "Embedding URL (or EMBEDDING_URL env)")
+	claudeDir := flag.String("claude-transcripts", "", "Import Claude Code .jsonl transcripts from this directory as sessions")
 	flag.Parse()
 
-	if *rootPath == "" {
-		fmt.Fprintln(os.Stderr, "Error: --root is required")
+	if *rootPath == "" && *claudeDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --root or --claude-transcripts is required")
 		os.Exit(1)
 	}
 
@@ -45,7 +55,7 @@ func main() {
 
 	ctx := context.Background()
 
-	pgStore, err := store.NewPostgresStore(ctx, *dbURL)
+	pgStore, err := store.NewPostgresStore(ctx, *dbURL, os.Getenv("DB_SCHEMA"))
 	if err != nil {
 		slog.Error("connect", "error", err)
 		os.Exit(1)
@@ -53,18 +63,42 @@ func main() {
 	defer pgStore.Close()
 
 	emb := embedding.New(*embURL, 384)
+	if strip, err := strconv.ParseBool(os.Getenv("EMBEDDING_STRIP_MARKDOWN")); err == nil {
+		emb.SetStripMarkdown(strip)
+	}
+	emb.SetPrefixes(os.Getenv("EMBEDDING_QUERY_PREFIX"), os.Getenv("EMBEDDING_DOC_PREFIX"))
+	emb.SetFieldNames(os.Getenv("EMBEDDING_REQUEST_FIELD"), os.Getenv("EMBEDDING_RESPONSE_FIELD"))
+	emb.SetModelVersion(os.Getenv("EMBEDDING_MODEL_VERSION"))
+	pgStore.SetModelVersion(emb.ModelVersion())
+	if include, err := strconv.ParseBool(os.Getenv("EMBEDDING_INCLUDE_KEY")); err == nil {
+		embedIncludeKey = include
+	}
 	slog.Info("embedding", "status", emb.Status())
 
-	// Register project
-	if err := pgStore.CreateProject(ctx, &store.Project{
-		ID:       *projectID,
-		Name:     *projectName,
-		RootPath: *rootPath,
-	}); err != nil {
-		slog.Error("register project", "error", err)
+	// Register project. A transcript-only import leaves an existing
+	// project's name, root, and metadata alone.
+	existing, err := pgStore.GetProject(ctx, *projectID)
+	if err != nil {
+		slog.Error("get project", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("project registered", "id", *projectID)
+	if existing == nil || *rootPath != "" {
+		if err := pgStore.CreateProject(ctx, &store.Project{
+			ID:       *projectID,
+			Name:     *projectName,
+			RootPath: *rootPath,
+		}); err != nil {
+			slog.Error("register project", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("project registered", "id", *projectID)
+	}
+
+	if *claudeDir != "" {
+		n := loadClaudeTranscripts(ctx, pgStore, emb, *projectID, *claudeDir)
+		slog.Info("claude transcript import complete", "sessions", n, "project", *projectID)
+		return
+	}
 
 	var total int
 
@@ -133,7 +167,7 @@ func loadDirAsMemories(ctx context.Context, s store.Store, emb *embedding.Servic
 		if len(embText) > 2000 {
 			embText = embText[:2000]
 		}
-		vec := emb.Embed(ctx, embText)
+		vec := emb.Embed(ctx, (&store.Memory{Topic: topic, Key: key, Value: embText}).EmbedText(embedIncludeKey))
 
 		if err := s.SetMemory(ctx, &store.Memory{
 			ProjectID: projectID,
@@ -161,7 +195,7 @@ func loadFileAsMemory(ctx context.Context, s store.Store, emb *embedding.Service
 	if len(embText) > 2000 {
 		embText = embText[:2000]
 	}
-	vec := emb.Embed(ctx, embText)
+	vec := emb.Embed(ctx, (&store.Memory{Topic: topic, Key: key, Value: embText}).EmbedText(embedIncludeKey))
 
 	if err := s.SetMemory(ctx, &store.Memory{
 		ProjectID: projectID,
@@ -229,6 +263,101 @@ func loadTranscriptsAsSessions(ctx context.Context, s store.Store, emb *embeddin
 	return count
 }
 
+// loadClaudeTranscripts upserts each .jsonl transcript in dir as a session.
+// A transcript already imported (matched on metadata.claude_session_id)
+// keeps its session number; new ones are numbered after the highest
+// existing session.
+func loadClaudeTranscripts(ctx context.Context, s store.Store, emb *embedding.Service, projectID, dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Warn("skip dir", "dir", dir, "error", err)
+		return 0
+	}
+
+	existing, err := s.ListSessions(ctx, projectID)
+	if err != nil {
+		slog.Error("list sessions", "error", err)
+		return 0
+	}
+	byClaudeID := map[string]int{}
+	nextNum := 1
+	for _, sess := range existing {
+		if id, ok := sess.Metadata["claude_session_id"].(string); ok {
+			byClaudeID[id] = sess.SessionNum
+		}
+		if sess.SessionNum >= nextNum {
+			nextNum = sess.SessionNum + 1
+		}
+	}
+
+	count := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Warn("skip transcript", "path", path, "error", err)
+			continue
+		}
+		t, err := transcript.ParseClaude(f)
+		f.Close()
+		if err != nil {
+			slog.Warn("skip transcript", "path", path, "error", err)
+			continue
+		}
+		if t.Turns == 0 {
+			slog.Info("skip empty transcript", "path", path)
+			continue
+		}
+		if t.SessionID == "" {
+			t.SessionID = strings.TrimSuffix(e.Name(), ".jsonl")
+		}
+
+		num, ok := byClaudeID[t.SessionID]
+		if !ok {
+			num = nextNum
+			nextNum++
+			byClaudeID[t.SessionID] = num
+		}
+
+		embText := t.Summary
+		if embText == "" {
+			embText = t.Title
+		}
+		vec := emb.Embed(ctx, embText)
+
+		meta := map[string]any{
+			"source":            "claude-code",
+			"claude_session_id": t.SessionID,
+		}
+		if !t.StartedAt.IsZero() {
+			meta["started_at"] = t.StartedAt
+		}
+		if t.Cwd != "" {
+			meta["cwd"] = t.Cwd
+		}
+		if t.GitBranch != "" {
+			meta["git_branch"] = t.GitBranch
+		}
+		if err := s.CreateSession(ctx, &store.Session{
+			ProjectID:  projectID,
+			SessionNum: num,
+			Title:      t.Title,
+			Summary:    t.Summary,
+			Content:    t.Markdown,
+			Metadata:   meta,
+		}, vec); err != nil {
+			slog.Error("create session", "title", t.Title, "error", err)
+			continue
+		}
+		slog.Info("loaded claude transcript", "num", num, "title", t.Title, "turns", t.Turns)
+		count++
+	}
+	return count
+}
+
 func indexGoFiles(ctx context.Context, s store.Store, emb *embedding.Service, projectID, rootPath string) int {
 	count := 0
 	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
@@ -251,16 +380,17 @@ func indexGoFiles(ctx context.Context, s store.Store, emb *embedding.Service, pr
 		}
 
 		relPath, _ := filepath.Rel(rootPath, path)
-		summary := extractGoSummary(string(content))
-
-		vec := emb.Embed(ctx, summary)
-
-		if err := s.IndexFile(ctx, &store.FileEntry{
+		summary, symbols, fileType := extract.File(relPath, content)
+		entry := &store.FileEntry{
 			ProjectID: projectID,
 			FilePath:  relPath,
-			FileType:  "go",
+			FileType:  fileType,
 			Summary:   summary,
-		}, vec); err != nil {
+			Symbols:   symbols,
+		}
+		vec := emb.Embed(ctx, entry.EmbedText(true))
+
+		if err := s.IndexFile(ctx, entry, vec); err != nil {
 			slog.Warn("index file", "path", relPath, "error", err)
 			return nil
 		}
@@ -294,30 +424,3 @@ func extractSummary(content string) string {
 	}
 	return result
 }
-
-func extractGoSummary(content string) string {
-	lines := strings.Split(content, "\n")
-	var parts []string
-
-	// Collect package doc comment + function/type names
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "// ") {
-			parts = append(parts, strings.TrimPrefix(trimmed, "// "))
-		}
-		if strings.HasPrefix(trimmed, "func ") || strings.HasPrefix(trimmed, "type ") {
-			// Extract just the signature
-			if idx := strings.Index(trimmed, "{"); idx > 0 {
-				parts = append(parts, strings.TrimSpace(trimmed[:idx]))
-			} else {
-				parts = append(parts, trimmed)
-			}
-		}
-	}
-
-	result := strings.Join(parts, ". ")
-	if len(result) > 1000 {
-		result = result[:1000]
-	}
-	return result
-}
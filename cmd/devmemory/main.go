@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -11,12 +13,15 @@ import (
 
 	"net/http"
 
+	"github.com/Platform-LSS/devmemory/internal/access"
 	"github.com/Platform-LSS/devmemory/internal/config"
 	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/export"
+	"github.com/Platform-LSS/devmemory/internal/logfile"
 	mcpserver "github.com/Platform-LSS/devmemory/internal/mcp"
 	"github.com/Platform-LSS/devmemory/internal/store"
 	"github.com/Platform-LSS/devmemory/internal/web"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/Platform-LSS/devmemory/internal/webhook"
 	"github.com/mark3labs/mcp-go/server"
 )
 
@@ -24,6 +29,9 @@ func main() {
 	migrate := flag.Bool("migrate", false, "Run database migrations on startup")
 	exitAfterMigrate := flag.Bool("exit-after-migrate", false, "Exit after running migrations")
 	migrationsDir := flag.String("migrations-dir", "", "Path to migrations directory (default: auto-detect)")
+	exportMarkdown := flag.String("export-markdown", "", "Print the given project's memories as Markdown to stdout and exit")
+	diffProject := flag.String("project-diff", "", "Print how the given project differs from --backup as JSON and exit")
+	diffBackup := flag.String("backup", "", "Backup archive for --project-diff (.jsonl or .jsonl.gz)")
 	flag.Parse()
 
 	cfg := config.Load()
@@ -32,6 +40,10 @@ func main() {
 	if *migrationsDir != "" {
 		cfg.MigrationsDir = *migrationsDir
 	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
 
 	// Set up structured logging
 	var handler slog.Handler
@@ -46,10 +58,22 @@ func main() {
 	default:
 		opts.Level = slog.LevelInfo
 	}
+	// With the stdio transport stderr may be read by the MCP client, so
+	// LOG_FILE keeps logs out of it.
+	var logOut io.Writer = os.Stderr
+	if cfg.LogFile != "" {
+		lf, err := logfile.Open(cfg.LogFile, int64(cfg.LogFileMaxSizeMB)<<20, cfg.LogFileMaxBackups)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer lf.Close()
+		logOut = lf
+	}
 	if cfg.LogFormat == "json" {
-		handler = slog.NewJSONHandler(os.Stderr, opts)
+		handler = slog.NewJSONHandler(logOut, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stderr, opts)
+		handler = slog.NewTextHandler(logOut, opts)
 	}
 	slog.SetDefault(slog.New(handler))
 
@@ -72,12 +96,12 @@ func main() {
 			slog.Error("migrations directory not found", "searched", cfg.MigrationsDir)
 			os.Exit(1)
 		}
-		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		pool, err := store.NewPool(ctx, cfg.DatabaseURL, cfg.DBSchema)
 		if err != nil {
 			slog.Error("connect for migration", "error", err)
 			os.Exit(1)
 		}
-		if err := store.RunMigrations(ctx, pool, dir); err != nil {
+		if err := store.RunMigrations(ctx, pool, dir, cfg.DBSchema); err != nil {
 			slog.Error("migrations failed", "error", err)
 			pool.Close()
 			os.Exit(1)
@@ -90,32 +114,192 @@ func main() {
 	}
 
 	// Connect to database
-	pgStore, err := store.NewPostgresStore(ctx, cfg.DatabaseURL)
+	pgStore, err := store.NewPostgresStore(ctx, cfg.DatabaseURL, cfg.DBSchema)
 	if err != nil {
 		slog.Error("database connection failed", "error", err)
 		os.Exit(1)
 	}
 	defer pgStore.Close()
+	if w := cfg.SessionRankWeights; w != nil {
+		pgStore.SetSessionWeights(w[0], w[1], w[2])
+	}
+	pgStore.SetStatsCacheTTL(cfg.StatsCacheTTL)
+	pgStore.SetQueryLogging(cfg.DBLogQueries)
+	pgStore.SetModelVersion(cfg.EmbeddingModelVersion)
+	pgStore.SetMemorySummaries(cfg.MemorySummaryThreshold)
+	pgStore.SetSearchAllConcurrency(cfg.SearchAllConcurrency)
+	if cfg.ContentStoreURL != "" {
+		cs, err := store.OpenContentStore(cfg.ContentStoreURL)
+		if err != nil {
+			slog.Error("content store", "error", err)
+			os.Exit(1)
+		}
+		pgStore.SetContentStore(cs, cfg.ContentStoreThreshold)
+		slog.Info("content store enabled", "threshold", cfg.ContentStoreThreshold)
+	}
+	if cfg.SearchSynonymsFile != "" {
+		syn, err := store.LoadSynonyms(cfg.SearchSynonymsFile)
+		if err != nil {
+			slog.Error("search synonyms", "error", err)
+			os.Exit(1)
+		}
+		pgStore.SetSynonyms(syn)
+		slog.Info("keyword search synonyms loaded", "terms", len(syn))
+	}
+	if cfg.DBLogQueries && cfg.LogLevel != "debug" {
+		slog.Warn("DB_LOG_QUERIES has no effect unless LOG_LEVEL=debug")
+	}
 
-	// Create embedding service
-	emb := embedding.New(cfg.EmbeddingURL, cfg.EmbeddingDim)
+	if *exportMarkdown != "" {
+		if err := exportProjectMarkdown(ctx, pgStore, *exportMarkdown); err != nil {
+			slog.Error("export markdown", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *diffProject != "" {
+		if err := printProjectDiff(ctx, pgStore, *diffProject, *diffBackup); err != nil {
+			slog.Error("project diff", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.RetentionSweepInterval > 0 {
+		go store.RunRetentionSweeper(ctx, pgStore, cfg.RetentionSweepInterval)
+	}
+	if cfg.CacheWarmInterval > 0 {
+		go store.RunCacheWarmer(ctx, pgStore, cfg.CacheWarmInterval)
+	}
+
+	// Create embedding service. Without pgvector there is nowhere to store
+	// or compare vectors, so skip the embedding calls entirely.
+	if !pgStore.VectorEnabled() && cfg.EmbeddingURL != "" {
+		slog.Warn("ignoring EMBEDDING_URL: pgvector extension is not installed")
+		cfg.EmbeddingURL = ""
+	}
+	emb := newEmbedding(cfg, cfg.EmbeddingURL, cfg.EmbeddingDim)
 	slog.Info("embedding service", "status", emb.Status())
 
+	// Per-entity models. Their columns are sized to the configured
+	// dimension, which clears any vectors of the old size.
+	entityEmb := map[string]*embedding.Service{}
+	if len(cfg.EntityEmbeddings) > 0 {
+		columns := map[string]int{}
+		if pgStore.VectorEnabled() {
+			if columns, err = pgStore.EmbeddingColumnDims(ctx); err != nil {
+				slog.Error("embedding columns", "error", err)
+				os.Exit(1)
+			}
+		}
+		for entity, e := range cfg.EntityEmbeddings {
+			if !pgStore.VectorEnabled() {
+				e.URL = ""
+			}
+			if e.URL != "" && columns[entity] != e.Dim {
+				if !cfg.MigrateOnStart {
+					slog.Error("embedding column has the wrong dimension; restart with --migrate to resize it", "entity", entity, "column", columns[entity], "configured", e.Dim)
+					os.Exit(1)
+				}
+				cleared, err := pgStore.ResizeEmbeddingColumn(ctx, entity, e.Dim)
+				if err != nil {
+					slog.Error("resize embedding column", "entity", entity, "error", err)
+					os.Exit(1)
+				}
+				slog.Warn("embedding column resized; reindex to re-embed", "entity", entity, "dim", e.Dim, "cleared", cleared)
+			}
+			entityEmb[entity] = newEmbedding(cfg, e.URL, e.Dim)
+			slog.Info("embedding service", "entity", entity, "status", entityEmb[entity].Status())
+		}
+	}
+
 	// Create MCP server
-	srv := mcpserver.New(pgStore, emb)
+	mcpOpts := mcpserver.DefaultOptions()
+	mcpOpts.FileEmbedPath = cfg.FileEmbedPath
+	mcpOpts.MemoryEmbedKey = cfg.EmbeddingIncludeKey
+	mcpOpts.EmbedUsageQueries = cfg.UsageEmbedQueries
+	mcpOpts.AutoCreateProject = cfg.AutoCreateProject
+	mcpOpts.SearchTimeout = cfg.SearchTimeout
+	mcpOpts.TopicInferenceThreshold = cfg.TopicInferenceThreshold
+	mcpOpts.CatchAllTopic = cfg.CatchAllTopic
+	if cfg.GenericTopics != nil {
+		mcpOpts.GenericTopics = cfg.GenericTopics
+	}
+	mcpOpts.MaxEmbeddingElements = cfg.MaxEmbeddingElements
+	mcpOpts.ReembedUnchanged = cfg.MemoryReembedUnchanged
+	mcpOpts.VerboseTiming = cfg.MCPVerboseTiming
+	mcpOpts.DuplicateSessionTitles = cfg.SessionDuplicateTitles
+	mcpOpts.PruneWindow = cfg.PruneWindow
+	mcpOpts.PruneMinScore = cfg.PruneMinScore
+	mcpOpts.ArchiveDir = cfg.ArchiveDir
+	mcpOpts.BackupDir = cfg.BackupDir
+	if t := cfg.FileIndexTypes; t != nil {
+		if len(t) == 1 && t[0] == "*" {
+			t = nil
+		}
+		mcpOpts.FileTypes = t
+	}
+	if cfg.FileIndexPathOnlyTypes != nil {
+		mcpOpts.PathOnlyFileTypes = cfg.FileIndexPathOnlyTypes
+	}
+	srv := mcpserver.New(pgStore, emb, mcpOpts)
+	for entity, e := range entityEmb {
+		srv.SetEntityEmbedding(entity, e)
+	}
+	if cfg.PruneScanInterval > 0 {
+		go srv.RunPruneScanner(ctx, cfg.PruneScanInterval)
+	}
+	if cfg.WriteQueueEnabled {
+		q, err := store.OpenWriteQueue(cfg.WriteQueuePath)
+		if err != nil {
+			slog.Error("write queue", "error", err)
+			os.Exit(1)
+		}
+		srv.SetWriteQueue(q)
+		go store.RunWriteQueue(ctx, q, pgStore, store.DefaultWriteQueueInterval)
+		slog.Info("write queue enabled", "path", cfg.WriteQueuePath)
+	}
+
+	if cfg.ResultsWebhookURL != "" {
+		srv.SetResultsWebhook(webhook.New(cfg.ResultsWebhookURL))
+		slog.Info("results webhook enabled")
+	}
+
+	var policy access.Policy
+	if cfg.AccessTokensFile != "" {
+		if policy, err = access.Load(cfg.AccessTokensFile); err != nil {
+			slog.Error("access tokens", "error", err)
+			os.Exit(1)
+		}
+		if cfg.Transport == "sse" || cfg.Transport == "web" {
+			slog.Info("access tokens loaded", "tokens", len(policy))
+		} else {
+			slog.Warn("ACCESS_TOKENS_FILE only applies to the sse and web transports", "transport", cfg.Transport)
+		}
+	}
 
 	// Start transport
 	switch cfg.Transport {
 	case "web":
-		webSrv, err := web.New(pgStore, emb)
+		webSrv, err := web.New(pgStore, emb, web.Options{
+			FileEmbedPath:  cfg.FileEmbedPath,
+			MemoryEmbedKey: cfg.EmbeddingIncludeKey,
+			SSEHeartbeat:   cfg.SSEHeartbeat,
+			SSEMaxClients:  cfg.SSEMaxClients,
+			BasePath:       cfg.WebBasePath,
+			Access:         policy,
+		})
 		if err != nil {
 			slog.Error("web server init failed", "error", err)
 			os.Exit(1)
 		}
+		for entity, e := range entityEmb {
+			webSrv.SetEntityEmbedding(entity, e)
+		}
 		// Wire event bus to MCP server for real-time updates
 		srv.SetEvents(webSrv.Events())
 
-		slog.Info("starting web dashboard", "port", cfg.Port, "url", fmt.Sprintf("http://localhost:%s", cfg.Port))
+		slog.Info("starting web dashboard", "port", cfg.Port, "url", fmt.Sprintf("http://localhost:%s%s/", cfg.Port, cfg.WebBasePath))
 		httpSrv := &http.Server{Addr: ":" + cfg.Port, Handler: webSrv.Routes()}
 		go func() {
 			<-ctx.Done()
@@ -130,7 +314,11 @@ func main() {
 		sseServer := server.NewSSEServer(srv.MCPServer(),
 			server.WithBaseURL(fmt.Sprintf("http://localhost:%s", cfg.Port)),
 		)
-		if err := sseServer.Start(":" + cfg.Port); err != nil {
+		var handler http.Handler = sseServer
+		if policy != nil {
+			handler = policy.Middleware(sseServer)
+		}
+		if err := http.ListenAndServe(":"+cfg.Port, handler); err != nil {
 			slog.Error("SSE server error", "error", err)
 			os.Exit(1)
 		}
@@ -145,6 +333,62 @@ func main() {
 	}
 }
 
+// newEmbedding creates an embedding service for url and dim with the
+// shared settings.
+func newEmbedding(cfg *config.Config, url string, dim int) *embedding.Service {
+	emb := embedding.New(url, dim)
+	emb.SetDimMismatch(embedding.DimMismatch(cfg.EmbeddingDimMismatch))
+	emb.SetStripMarkdown(cfg.EmbeddingStripMarkdown)
+	emb.SetMinChars(cfg.EmbeddingMinChars)
+	emb.SetPrefixes(cfg.EmbeddingQueryPrefix, cfg.EmbeddingDocPrefix)
+	emb.SetModelVersion(cfg.EmbeddingModelVersion)
+	emb.SetFieldNames(cfg.EmbeddingRequestField, cfg.EmbeddingResponseField)
+	return emb
+}
+
+// exportProjectMarkdown writes a project's memories to stdout as Markdown.
+func exportProjectMarkdown(ctx context.Context, s store.Store, projectID string) error {
+	p, err := s.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("project '%s' not found", projectID)
+	}
+	memories, err := s.ListMemories(ctx, projectID, "")
+	if err != nil {
+		return err
+	}
+	return export.MemoriesMarkdown(os.Stdout, p, memories)
+}
+
+func printProjectDiff(ctx context.Context, s store.Store, projectID, backupPath string) error {
+	if backupPath == "" {
+		return fmt.Errorf("--project-diff needs --backup")
+	}
+	if p, err := s.GetProject(ctx, projectID); err != nil {
+		return err
+	} else if p == nil {
+		return fmt.Errorf("project '%s' not found", projectID)
+	}
+	f, err := store.OpenArchive(backupPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	snap, err := store.ReadProjectSnapshot(f, projectID)
+	if err != nil {
+		return err
+	}
+	d, err := mcpserver.DiffProject(ctx, s, projectID, snap)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
 // findMigrationsDir checks common locations for the migrations directory.
 func findMigrationsDir(configured string) string {
 	candidates := []string{
@@ -5,6 +5,7 @@ Exposes POST /embed endpoint returning 384-dim vectors.
 """
 
 import os
+import base64
 import json
 import logging
 import numpy as np
@@ -19,6 +20,10 @@ MODEL_DIR = os.environ.get("MODEL_DIR", "/model")
 PORT = int(os.environ.get("PORT", "8091"))
 MAX_LENGTH = int(os.environ.get("MAX_LENGTH", "128"))
 
+# Compact response format: base64 of little-endian float32 bytes. Sent only
+# when the client lists it in Accept; otherwise the response is JSON.
+BINARY_MEDIA_TYPE = "application/x-float32-base64"
+
 
 def load_model():
     """Load ONNX model and tokenizer."""
@@ -91,14 +96,19 @@ class EmbedHandler(BaseHTTPRequestHandler):
             token_embeddings = outputs[0]  # (1, seq_len, 384)
             pooled = mean_pooling(token_embeddings, attention_mask)
             normalized = normalize(pooled)
-            embedding = normalized[0].tolist()
+            vector = normalized[0]
 
             # Respond
-            response = json.dumps({"embedding": embedding})
+            if BINARY_MEDIA_TYPE in self.headers.get("Accept", ""):
+                content_type = BINARY_MEDIA_TYPE
+                response = base64.b64encode(vector.astype("<f4").tobytes())
+            else:
+                content_type = "application/json"
+                response = json.dumps({"embedding": vector.tolist()}).encode()
             self.send_response(200)
-            self.send_header("Content-Type", "application/json")
+            self.send_header("Content-Type", content_type)
             self.end_headers()
-            self.wfile.write(response.encode())
+            self.wfile.write(response)
 
         except Exception as e:
             logger.error(f"Embedding error: {e}")
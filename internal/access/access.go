@@ -0,0 +1,143 @@
+// Package access restricts which projects a request may reach, based on
+// the bearer token it carries.
+package access
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Scope is the set of projects one token may reach. A nil *Scope is
+// unrestricted, which is what requests without a policy get.
+type Scope struct {
+	all      bool
+	projects map[string]bool
+}
+
+// Allows reports whether the scope includes projectID.
+func (sc *Scope) Allows(projectID string) bool {
+	return sc == nil || sc.all || sc.projects[projectID]
+}
+
+// Unrestricted reports whether the scope reaches every project, which
+// admin operations such as reindex and orphan cleanup require.
+func (sc *Scope) Unrestricted() bool {
+	return sc == nil || sc.all
+}
+
+type scopeKey struct{}
+
+// WithScope returns ctx carrying sc.
+func WithScope(ctx context.Context, sc *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, sc)
+}
+
+// FromContext returns the scope of ctx, or nil (unrestricted) if none.
+func FromContext(ctx context.Context) *Scope {
+	sc, _ := ctx.Value(scopeKey{}).(*Scope)
+	return sc
+}
+
+// Policy maps tokens to the projects they may reach.
+type Policy map[string]*Scope
+
+// Load reads a token file; see Parse for the format.
+func Load(path string) (Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads one token per line followed by a colon and the projects it
+// may reach, comma-separated, or * for all of them:
+//
+//	s3cr3t-admin: *
+//	team-a-token: plss-fhir, plss-portal
+//
+// Blank lines and lines starting with # are ignored.
+func Parse(r io.Reader) (Policy, error) {
+	p := Policy{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		token, list, ok := strings.Cut(line, ":")
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			return nil, fmt.Errorf("access tokens line %d: want \"token: project, ...\"", n)
+		}
+		if _, dup := p[token]; dup {
+			return nil, fmt.Errorf("access tokens line %d: token listed twice", n)
+		}
+		scope := &Scope{projects: map[string]bool{}}
+		for _, id := range strings.Split(list, ",") {
+			switch id = strings.TrimSpace(id); id {
+			case "":
+			case "*":
+				scope.all = true
+			default:
+				scope.projects[id] = true
+			}
+		}
+		if !scope.all && len(scope.projects) == 0 {
+			return nil, fmt.Errorf("access tokens line %d: no projects", n)
+		}
+		p[token] = scope
+	}
+	return p, sc.Err()
+}
+
+// Lookup returns the scope of token; ok is false for an unknown token.
+// Tokens are compared in constant time.
+func (p Policy) Lookup(token string) (sc *Scope, ok bool) {
+	for t, scope := range p {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			sc, ok = scope, true
+		}
+	}
+	return sc, ok
+}
+
+// CookieName is the cookie a browser presents its token in.
+const CookieName = "devmemory_token"
+
+// Token returns the token of r from "Authorization: Bearer <token>", or
+// for clients that cannot set that header, X-DevMemory-Token or the
+// CookieName cookie.
+func Token(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	if t := r.Header.Get("X-DevMemory-Token"); t != "" {
+		return t
+	}
+	if c, err := r.Cookie(CookieName); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// Middleware rejects requests without a known token with 401 and passes
+// the rest on with the token's scope in their context.
+func (p Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, ok := p.Lookup(Token(r))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="devmemory"`)
+			http.Error(w, "missing or unknown access token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithScope(r.Context(), sc)))
+	})
+}
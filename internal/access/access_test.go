@@ -0,0 +1,162 @@
+package access
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    map[string][]string // token -> projects; "*" for all
+		wantErr string
+	}{
+		{
+			name: "tokens",
+			file: "# comment\n\nadmin: *\n team-a : fhir, portal ,\n",
+			want: map[string][]string{"admin": {"*"}, "team-a": {"fhir", "portal"}},
+		},
+		{name: "empty file", file: "", want: map[string][]string{}},
+		{name: "no colon", file: "admin *\n", wantErr: "line 1"},
+		{name: "no token", file: "ok: a\n: a\n", wantErr: "line 2"},
+		{name: "no projects", file: "admin: , \n", wantErr: "line 1: no projects"},
+		{name: "duplicate", file: "a: x\nb: y\na: z\n", wantErr: "line 3: token listed twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(strings.NewReader(tt.file))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(p) != len(tt.want) {
+				t.Fatalf("Parse returned %d tokens, want %d", len(p), len(tt.want))
+			}
+			for token, projects := range tt.want {
+				sc, ok := p[token]
+				if !ok {
+					t.Fatalf("token %q missing", token)
+				}
+				for _, id := range projects {
+					if id == "*" {
+						if !sc.Unrestricted() {
+							t.Errorf("token %q is restricted, want all projects", token)
+						}
+					} else if !sc.Allows(id) {
+						t.Errorf("token %q does not allow %q", token, id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestScope(t *testing.T) {
+	p, err := Parse(strings.NewReader("admin: *\nteam: a, b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		scope        *Scope
+		project      string
+		allows       bool
+		unrestricted bool
+	}{
+		{"nil allows anything", nil, "x", true, true},
+		{"all", p["admin"], "x", true, true},
+		{"listed", p["team"], "a", true, false},
+		{"not listed", p["team"], "c", false, false},
+		{"empty id", p["team"], "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.Allows(tt.project); got != tt.allows {
+				t.Errorf("Allows(%q) = %v, want %v", tt.project, got, tt.allows)
+			}
+			if got := tt.scope.Unrestricted(); got != tt.unrestricted {
+				t.Errorf("Unrestricted() = %v, want %v", got, tt.unrestricted)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	p, err := Parse(strings.NewReader("admin: *\nteam: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		token  string
+		ok     bool
+		allows string
+	}{
+		{"admin", true, "anything"},
+		{"team", true, "a"},
+		{"tea", false, ""},
+		{"team ", false, ""},
+		{"", false, ""},
+	}
+	for _, tt := range tests {
+		sc, ok := p.Lookup(tt.token)
+		if ok != tt.ok {
+			t.Errorf("Lookup(%q) ok = %v, want %v", tt.token, ok, tt.ok)
+			continue
+		}
+		if ok && !sc.Allows(tt.allows) {
+			t.Errorf("Lookup(%q) scope does not allow %q", tt.token, tt.allows)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	p, err := Parse(strings.NewReader("team: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *Scope
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+	tests := []struct {
+		name   string
+		set    func(r *http.Request)
+		status int
+	}{
+		{"no token", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"unknown token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer team") }, http.StatusOK},
+		{"header", func(r *http.Request) { r.Header.Set("X-DevMemory-Token", "team") }, http.StatusOK},
+		{"cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: CookieName, Value: "team"}) }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.set(r)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && (got == nil || !got.Allows("a") || got.Allows("b")) {
+				t.Errorf("handler got scope %+v, want the team scope", got)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if sc := FromContext(context.Background()); !sc.Unrestricted() {
+		t.Error("a context without a scope should be unrestricted")
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// validTransports lists the transports cmd/devmemory can start.
+var validTransports = map[string]bool{"stdio": true, "sse": true, "web": true}
+
+// Validate checks the configuration and returns every problem found,
+// joined into one error, so a misconfigured deploy fails fast and all
+// issues can be fixed in one pass.
+func (c *Config) Validate() error {
+	var problems []error
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.EmbeddingDim <= 0 {
+		add("EMBEDDING_DIM must be a positive integer (got %q)", c.rawEmbeddingDim)
+	}
+	for entity, e := range c.EntityEmbeddings {
+		if e.Dim <= 0 {
+			add("%s_EMBEDDING_DIM must be a positive integer (got %q)", entityEmbeddingPrefixes[entity], e.rawDim)
+		}
+	}
+	if slices.Contains(strings.Split(c.EmbeddingResponseField, "."), "") {
+		add("EMBEDDING_RESPONSE_FIELD must be field names or array indexes joined by dots, e.g. data.0.embedding (got %q)", c.EmbeddingResponseField)
+	}
+	switch c.SessionDuplicateTitles {
+	case "warn", "reject", "allow":
+	default:
+		add("SESSION_DUPLICATE_TITLES must be one of warn, reject, allow (got %q)", c.SessionDuplicateTitles)
+	}
+	switch c.EmbeddingDimMismatch {
+	case "error", "drop", "adjust":
+	default:
+		add("EMBEDDING_DIM_MISMATCH must be one of error, drop, adjust (got %q)", c.EmbeddingDimMismatch)
+	}
+	if !validTransports[c.Transport] {
+		add("TRANSPORT must be one of stdio, sse, web (got %q)", c.Transport)
+	}
+	if p, err := strconv.Atoi(c.Port); err != nil || p < 1 || p > 65535 {
+		add("PORT must be a number between 1 and 65535 (got %q)", c.Port)
+	}
+	if _, err := pgx.ParseConfig(c.DatabaseURL); err != nil {
+		// pgx masks the password in its parse errors; repeating the
+		// value here would undo that.
+		add("DATABASE_URL is not a valid connection string: %v", err)
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL must be one of debug, info, warn, error (got %q)", c.LogLevel)
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		add("LOG_FORMAT must be text or json (got %q)", c.LogFormat)
+	}
+	if c.LogFileMaxSizeMB < 0 {
+		add("LOG_FILE_MAX_SIZE_MB must be a non-negative number of megabytes (got %q)", c.rawLogFileMaxSize)
+	}
+	if c.LogFileMaxBackups < 0 {
+		add("LOG_FILE_MAX_BACKUPS must be a non-negative integer (got %q)", c.rawLogFileMaxBackups)
+	}
+	if c.rawSessionRankWeights != "" && c.SessionRankWeights == nil {
+		add("SESSION_RANK_WEIGHTS must be three comma-separated numbers between 0 and 1 (got %q)", c.rawSessionRankWeights)
+	}
+	if c.RetentionSweepInterval < 0 {
+		add("RETENTION_SWEEP_INTERVAL must be a non-negative duration such as 1h or 30m (got %q)", c.rawRetentionSweep)
+	}
+	if c.EmbeddingMinChars < 0 {
+		add("EMBEDDING_MIN_CHARS must be a non-negative integer (got %q)", c.rawEmbeddingMinChars)
+	}
+	if c.SSEMaxClients < 0 {
+		add("SSE_MAX_CLIENTS must be a non-negative integer (got %q)", c.rawSSEMaxClients)
+	}
+	if c.SSEHeartbeat < 0 {
+		add("SSE_HEARTBEAT_INTERVAL must be a non-negative duration such as 15s (got %q)", c.rawSSEHeartbeat)
+	}
+	if c.StatsCacheTTL < 0 {
+		add("STATS_CACHE_TTL must be a non-negative duration such as 5s (got %q)", c.rawStatsCacheTTL)
+	}
+	if c.WebBasePath != "" && (!strings.HasPrefix(c.WebBasePath, "/") || strings.ContainsAny(c.WebBasePath, "?#{} ")) {
+		add("WEB_BASE_PATH must be a path starting with / such as /devmemory (got %q)", c.WebBasePath)
+	}
+	if c.ContentStoreURL != "" {
+		if u, err := url.Parse(c.ContentStoreURL); err != nil || (u.Scheme != "" && u.Scheme != "file" && u.Scheme != "s3") {
+			add("CONTENT_STORE_URL must be file:///dir or s3://bucket/prefix (got %q)", c.ContentStoreURL)
+		}
+	}
+	if c.SearchAllConcurrency < 1 {
+		add("SEARCHALL_CONCURRENCY must be a positive integer (got %q)", c.rawSearchAllConc)
+	}
+	if c.MemorySummaryThreshold < 0 {
+		add("MEMORY_SUMMARY_THRESHOLD must be a non-negative number of characters (got %q)", c.rawSummaryThreshold)
+	}
+	if c.SearchTimeout < 0 {
+		add("SEARCH_TIMEOUT must be a non-negative duration such as 2s (got %q)", c.rawSearchTimeout)
+	}
+	if c.MaxEmbeddingElements < 0 {
+		add("MCP_MAX_EMBEDDING_ELEMENTS must be a non-negative integer (got %q)", c.rawMaxEmbeddingElems)
+	}
+	if c.CacheWarmInterval < 0 {
+		add("CACHE_WARM_INTERVAL must be a non-negative duration such as 1m (got %q)", c.rawCacheWarm)
+	}
+	if c.PruneScanInterval < 0 {
+		add("PRUNE_SCAN_INTERVAL must be a non-negative duration such as 24h (got %q)", c.rawPruneScan)
+	}
+	if c.PruneWindow <= 0 {
+		add("PRUNE_WINDOW must be a positive duration such as 720h (got %q)", c.rawPruneWindow)
+	}
+	if c.PruneMinScore < 0 || c.PruneMinScore > 1 {
+		add("PRUNE_MIN_SCORE must be a similarity between 0 and 1 (got %q)", c.rawPruneMinScore)
+	}
+	if c.TopicInferenceThreshold < 0 || c.TopicInferenceThreshold > 1 {
+		add("TOPIC_INFERENCE_THRESHOLD must be a similarity between 0 and 1 (got %q)", c.rawTopicInference)
+	}
+	if c.ResultsWebhookURL != "" {
+		if u, err := url.Parse(c.ResultsWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("RESULTS_WEBHOOK_URL must be an http or https URL (got %q)", c.ResultsWebhookURL)
+		}
+	}
+	if c.ContentStoreThreshold < 0 {
+		add("CONTENT_STORE_THRESHOLD must be a non-negative number of bytes (got %q)", c.rawContentThreshold)
+	}
+
+	return errors.Join(problems...)
+}
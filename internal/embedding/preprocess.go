@@ -0,0 +1,38 @@
+package embedding
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	frontmatterRe = regexp.MustCompile(`\A---\r?\n(?s:.*?)\r?\n---\r?\n`)
+	fenceRe       = regexp.MustCompile("(?ms)^[ \t]*(```|~~~).*?^[ \t]*(```|~~~)[ \t]*$")
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTagRe     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	imageRe       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRe        = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	refDefRe      = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:[ \t]*\S+.*$`)
+	bareURLRe     = regexp.MustCompile(`<?https?://[^\s>)]+>?`)
+	blankLinesRe  = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+)
+
+// StripMarkdown reduces Markdown to its prose for embedding: frontmatter,
+// fenced code blocks, HTML, and link URLs are removed, keeping link text.
+// If nothing but markup is left, the original text is returned so
+// code-only entries still get an embedding.
+func StripMarkdown(text string) string {
+	out := frontmatterRe.ReplaceAllString(text, "")
+	out = fenceRe.ReplaceAllString(out, "")
+	out = htmlCommentRe.ReplaceAllString(out, "")
+	out = htmlTagRe.ReplaceAllString(out, "")
+	out = imageRe.ReplaceAllString(out, "$1")
+	out = linkRe.ReplaceAllString(out, "$1")
+	out = refDefRe.ReplaceAllString(out, "")
+	out = bareURLRe.ReplaceAllString(out, "")
+	out = strings.TrimSpace(blankLinesRe.ReplaceAllString(out, "\n\n"))
+	if out == "" {
+		return text
+	}
+	return out
+}
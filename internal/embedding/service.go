@@ -3,27 +3,73 @@ package embedding
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/Platform-LSS/devmemory/internal/timing"
 )
 
+// DimMismatch decides what happens when the API returns a vector of the
+// wrong dimension.
+type DimMismatch string
+
+const (
+	// DimMismatchError fails writes that go through EmbedChecked.
+	DimMismatchError DimMismatch = "error"
+	// DimMismatchDrop discards the vector and stores the row without one.
+	DimMismatchDrop DimMismatch = "drop"
+	// DimMismatchAdjust truncates or zero-pads the vector to the expected dim.
+	DimMismatchAdjust DimMismatch = "adjust"
+)
+
+// ErrDimMismatch is returned by EmbedChecked under DimMismatchError.
+var ErrDimMismatch = errors.New("embedding dimension mismatch")
+
 // Service generates vector embeddings from text.
 // If URL is empty, embedding is disabled and all methods return nil.
 type Service struct {
-	url    string
-	dim    int
-	client *http.Client
+	url      string
+	dim      int
+	mismatch DimMismatch
+	strip    bool // run StripMarkdown before embedding
+	minChars int  // EmbedValue skips shorter text
+	client   *http.Client
+
+	// queryPrefix and docPrefix are prepended to search text and stored
+	// text respectively, for asymmetric models such as e5 ("query: ",
+	// "passage: "). Both empty is the symmetric default.
+	queryPrefix string
+	docPrefix   string
+
+	// modelVersion names the model behind url; see SetModelVersion.
+	modelVersion string
+
+	// requestField and responsePath name the JSON text field sent and
+	// the vector received; see SetFieldNames.
+	requestField string
+	responsePath []string
 }
 
 // New creates an embedding service. If url is empty, the service is disabled.
 func New(url string, dim int) *Service {
 	return &Service{
-		url: url,
-		dim: dim,
+		url:          url,
+		dim:          dim,
+		mismatch:     DimMismatchError,
+		requestField: DefaultRequestField,
+		responsePath: []string{DefaultResponseField},
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -35,66 +81,245 @@ func (s *Service) Enabled() bool {
 	return s.url != ""
 }
 
+// SetDimMismatch sets the policy for wrong-dimension vectors.
+func (s *Service) SetDimMismatch(m DimMismatch) {
+	s.mismatch = m
+}
+
+// SetStripMarkdown enables StripMarkdown on all text before it is sent to
+// the embedding API. Stored values are unaffected.
+func (s *Service) SetStripMarkdown(on bool) {
+	s.strip = on
+}
+
+// SetPrefixes sets the text prepended to search queries (EmbedQuery) and
+// to stored documents (every other Embed method).
+func (s *Service) SetPrefixes(query, doc string) {
+	s.queryPrefix = query
+	s.docPrefix = doc
+}
+
+// SetModelVersion records the model version that stored vectors are
+// tagged with, for Status and project_status.
+func (s *Service) SetModelVersion(v string) {
+	s.modelVersion = v
+}
+
+// ModelVersion returns the configured model version, "" when unset.
+func (s *Service) ModelVersion() string {
+	return s.modelVersion
+}
+
+// Default JSON field names of the embedding protocol.
+const (
+	DefaultRequestField  = "text"
+	DefaultResponseField = "embedding"
+)
+
+// SetFieldNames sets the request field the text is sent in and the path
+// of the vector in a JSON response: field names and array indexes joined
+// by dots, e.g. "data.0.embedding" for OpenAI-style servers. Empty
+// arguments keep the defaults.
+func (s *Service) SetFieldNames(request, response string) {
+	if request != "" {
+		s.requestField = request
+	}
+	if response != "" {
+		s.responsePath = strings.Split(response, ".")
+	}
+}
+
+// SetMinChars sets the length, in characters, below which EmbedValue
+// skips embedding. Zero embeds everything.
+func (s *Service) SetMinChars(n int) {
+	s.minChars = n
+}
+
+// TooShort reports whether text is below the EmbedValue minimum.
+func (s *Service) TooShort(text string) bool {
+	return s.minChars > 0 && utf8.RuneCountInString(strings.TrimSpace(text)) < s.minChars
+}
+
+// EmbedValue is EmbedChecked for short free-form values such as memories:
+// trivial values ("yes", "done") make poor vectors, so text under the
+// minimum length is not embedded and skipped is true.
+func (s *Service) EmbedValue(ctx context.Context, text string) (vec []float32, skipped bool, err error) {
+	if s.Enabled() && s.TooShort(text) {
+		slog.Info("value too short to embed", "chars", utf8.RuneCountInString(strings.TrimSpace(text)), "min", s.minChars)
+		return nil, true, nil
+	}
+	vec, err = s.EmbedChecked(ctx, text)
+	return vec, false, err
+}
+
 // Dim returns the configured embedding dimension.
 func (s *Service) Dim() int {
 	return s.dim
 }
 
-// embeddingRequest is the request body for the embedding API.
-type embeddingRequest struct {
-	Text string `json:"text"`
+// decodeVector reads the vector at path from a JSON response body.
+func decodeVector(r io.Reader, path []string) ([]float32, error) {
+	var node json.RawMessage
+	if err := json.NewDecoder(r).Decode(&node); err != nil {
+		return nil, err
+	}
+	for i, step := range path {
+		at := strings.Join(path[:i+1], ".")
+		if n, err := strconv.Atoi(step); err == nil {
+			var items []json.RawMessage
+			if err := json.Unmarshal(node, &items); err != nil {
+				return nil, fmt.Errorf("%s: not an array", at)
+			}
+			if n < 0 || n >= len(items) {
+				return nil, fmt.Errorf("%s: index out of range (%d items)", at, len(items))
+			}
+			node = items[n]
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(node, &fields); err != nil {
+			return nil, fmt.Errorf("%s: not an object", at)
+		}
+		next, ok := fields[step]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing", at)
+		}
+		node = next
+	}
+	var vec []float32
+	if err := json.Unmarshal(node, &vec); err != nil {
+		return nil, fmt.Errorf("%s: not a vector: %w", strings.Join(path, "."), err)
+	}
+	return vec, nil
 }
 
-// embeddingResponse is the response body from the embedding API.
-type embeddingResponse struct {
-	Embedding []float32 `json:"embedding"`
+// binaryMediaType is the compact response format: the body is the
+// base64-encoded little-endian float32 bytes of the vector. It is offered
+// in Accept alongside JSON; servers that don't support it answer in JSON.
+const binaryMediaType = "application/x-float32-base64"
+
+// acceptHeader prefers the binary format and falls back to JSON.
+const acceptHeader = binaryMediaType + ", application/json;q=0.9"
+
+// decodeBinary decodes a binaryMediaType body.
+func decodeBinary(r io.Reader) ([]float32, error) {
+	raw, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("binary embedding length %d is not a multiple of 4", len(raw))
+	}
+	vec := make([]float32, len(raw)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return vec, nil
 }
 
 // Embed generates a vector embedding for the given text.
 // Returns nil if the service is disabled or an error occurs (non-fatal).
 func (s *Service) Embed(ctx context.Context, text string) []float32 {
-	if !s.Enabled() || text == "" {
+	vec, err := s.EmbedChecked(ctx, text)
+	if err != nil {
+		slog.Error("embedding rejected", "error", err)
 		return nil
 	}
+	return vec
+}
 
-	body, err := json.Marshal(embeddingRequest{Text: text})
+// EmbedQuery is Embed for search text: it applies the query prefix instead
+// of the document prefix. Use it for anything compared against stored
+// vectors rather than stored itself.
+func (s *Service) EmbedQuery(ctx context.Context, text string) []float32 {
+	vec, err := s.embed(ctx, s.queryPrefix, text)
 	if err != nil {
-		slog.Warn("embedding marshal error", "error", err)
+		slog.Error("embedding rejected", "error", err)
 		return nil
 	}
+	return vec
+}
+
+// EmbedChecked is Embed for write paths: transport failures are still
+// non-fatal (nil, nil), but a dimension mismatch under DimMismatchError is
+// returned so the caller can fail the write instead of silently storing
+// a row with no embedding.
+func (s *Service) EmbedChecked(ctx context.Context, text string) ([]float32, error) {
+	return s.embed(ctx, s.docPrefix, text)
+}
+
+// embed calls the embedding API for prefix plus the (optionally stripped) text.
+func (s *Service) embed(ctx context.Context, prefix, text string) ([]float32, error) {
+	if !s.Enabled() || text == "" {
+		return nil, nil
+	}
+	if s.strip {
+		text = StripMarkdown(text)
+	}
+	text = prefix + text
+	defer timing.Start(ctx, timing.Embedding)()
+
+	body, err := json.Marshal(map[string]string{s.requestField: text})
+	if err != nil {
+		slog.Warn("embedding marshal error", "error", err)
+		return nil, nil
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
 	if err != nil {
 		slog.Warn("embedding request error", "error", err)
-		return nil
+		return nil, nil
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", acceptHeader)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
 		slog.Warn("embedding call failed", "error", err)
-		return nil
+		return nil, nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		slog.Warn("embedding API error", "status", resp.StatusCode, "body", string(respBody))
-		return nil
+		return nil, nil
 	}
 
-	var result embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		slog.Warn("embedding decode error", "error", err)
-		return nil
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == binaryMediaType {
+		vec, err := decodeBinary(resp.Body)
+		if err != nil {
+			slog.Warn("embedding decode error", "format", "binary", "error", err)
+			return nil, nil
+		}
+		return s.checkDim(vec)
 	}
 
-	if len(result.Embedding) != s.dim {
-		slog.Warn("embedding dimension mismatch", "expected", s.dim, "got", len(result.Embedding))
-		return nil
+	vec, err := decodeVector(resp.Body, s.responsePath)
+	if err != nil {
+		slog.Warn("embedding decode error", "error", err)
+		return nil, nil
 	}
+	return s.checkDim(vec)
+}
 
-	return result.Embedding
+// checkDim applies the mismatch policy to a vector from the API.
+func (s *Service) checkDim(vec []float32) ([]float32, error) {
+	if len(vec) == s.dim {
+		return vec, nil
+	}
+	switch s.mismatch {
+	case DimMismatchDrop:
+		slog.Warn("embedding dimension mismatch, dropping vector", "expected", s.dim, "got", len(vec))
+		return nil, nil
+	case DimMismatchAdjust:
+		slog.Warn("embedding dimension mismatch, adjusting vector", "expected", s.dim, "got", len(vec))
+		adjusted := make([]float32, s.dim)
+		copy(adjusted, vec)
+		return adjusted, nil
+	default:
+		return nil, fmt.Errorf("%w: expected %d, got %d (check EMBEDDING_DIM and the embedding model)", ErrDimMismatch, s.dim, len(vec))
+	}
 }
 
 // EmbedBatch generates embeddings for multiple texts.
@@ -111,5 +336,8 @@ func (s *Service) Status() string {
 	if !s.Enabled() {
 		return "disabled (no EMBEDDING_URL configured, using keyword search only)"
 	}
+	if s.modelVersion != "" {
+		return fmt.Sprintf("enabled (url=%s, dim=%d, model=%s)", s.url, s.dim, s.modelVersion)
+	}
 	return fmt.Sprintf("enabled (url=%s, dim=%d)", s.url, s.dim)
 }
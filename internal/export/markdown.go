@@ -0,0 +1,45 @@
+// Package export renders stored knowledge as human-readable documents.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+)
+
+// MemoriesMarkdown writes a project's memories as one Markdown document:
+// a heading per topic and, per memory, a key heading, a small front matter
+// block (author, status, timestamps), and the value. memories must be
+// ordered by topic, as ListMemories returns them.
+func MemoriesMarkdown(w io.Writer, p *store.Project, memories []store.Memory) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s — Memories\n\n", p.Name)
+	fmt.Fprintf(&b, "Project `%s`, %d memories, exported %s.\n", p.ID, len(memories), time.Now().UTC().Format(time.RFC3339))
+
+	topic := ""
+	for i, m := range memories {
+		if i == 0 || m.Topic != topic {
+			topic = m.Topic
+			fmt.Fprintf(&b, "\n## %s\n", topic)
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", m.Key)
+		b.WriteString("---\n")
+		if m.CreatedBy != "" {
+			fmt.Fprintf(&b, "author: %s\n", m.CreatedBy)
+		}
+		if m.Status != "" {
+			fmt.Fprintf(&b, "status: %s\n", m.Status)
+		}
+		fmt.Fprintf(&b, "created: %s\n", m.CreatedAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&b, "updated: %s\n", m.UpdatedAt.UTC().Format(time.RFC3339))
+		b.WriteString("---\n\n")
+		b.WriteString(strings.TrimSpace(m.Value))
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
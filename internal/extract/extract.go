@@ -0,0 +1,159 @@
+// Package extract derives a file summary and symbol list from source text
+// for the file index. Go files are parsed with go/ast; other files get a
+// plain-text summary and no symbols.
+package extract
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+)
+
+// maxSummary caps summary length; the embedding model truncates long input anyway.
+const maxSummary = 1000
+
+// File returns the summary, symbols, and file type for the file at path.
+func File(path string, src []byte) (summary string, symbols []store.Symbol, fileType string) {
+	fileType = strings.TrimPrefix(filepath.Ext(path), ".")
+	if fileType == "go" {
+		if summary, symbols, ok := goFile(path, src); ok {
+			return summary, symbols, fileType
+		}
+	}
+	return textSummary(string(src)), nil, fileType
+}
+
+// goFile parses Go source. ok is false if the file does not parse.
+func goFile(path string, src []byte) (string, []store.Symbol, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return "", nil, false
+	}
+
+	var parts []string
+	if f.Doc != nil {
+		parts = append(parts, doc.Synopsis(f.Doc.Text()))
+	}
+	parts = append(parts, "package "+f.Name.Name)
+
+	var symbols []store.Symbol
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := store.Symbol{
+				Name: d.Name.Name,
+				Kind: "func",
+				Doc:  synopsis(d.Doc),
+				Line: fset.Position(d.Pos()).Line,
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = "method"
+				sym.Name = receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+			symbols = append(symbols, sym)
+			parts = append(parts, funcSignature(fset, d))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					docGroup := sp.Doc
+					if docGroup == nil {
+						docGroup = d.Doc
+					}
+					symbols = append(symbols, store.Symbol{
+						Name: sp.Name.Name,
+						Kind: "type",
+						Doc:  synopsis(docGroup),
+						Line: fset.Position(sp.Pos()).Line,
+					})
+					parts = append(parts, "type "+sp.Name.Name)
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range sp.Names {
+						if !name.IsExported() {
+							continue
+						}
+						symbols = append(symbols, store.Symbol{
+							Name: name.Name,
+							Kind: kind,
+							Doc:  synopsis(sp.Doc),
+							Line: fset.Position(name.Pos()).Line,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return capLen(strings.Join(parts, ". ")), symbols, true
+}
+
+func synopsis(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return doc.Synopsis(cg.Text())
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// funcSignature prints a function declaration without its body.
+func funcSignature(fset *token.FileSet, d *ast.FuncDecl) string {
+	sig := *d
+	sig.Body = nil
+	sig.Doc = nil
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, &sig); err != nil {
+		return "func " + d.Name.Name
+	}
+	return buf.String()
+}
+
+// textSummary returns the first paragraph of non-heading text.
+func textSummary(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") && len(lines) == 0 {
+			continue
+		}
+		lines = append(lines, trimmed)
+	}
+	return capLen(strings.Join(lines, " "))
+}
+
+func capLen(s string) string {
+	if len(s) > maxSummary {
+		return s[:maxSummary]
+	}
+	return s
+}
@@ -0,0 +1,293 @@
+// Package jsonschema validates JSON documents against the commonly used
+// subset of JSON Schema: type, enum, const, properties, required,
+// additionalProperties, items, the min/max length, size and value
+// bounds, and pattern. Other keywords are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a validation failure at one location of the document.
+type Error struct {
+	Path    string // JSON path of the failing value, e.g. $.pool.max_conns
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Check reports whether schema is usable: a JSON object whose keyword
+// values have the right shape and whose patterns compile.
+func Check(schema map[string]any) error {
+	return check(schema, "$")
+}
+
+func check(schema map[string]any, path string) error {
+	if t, ok := schema["type"]; ok {
+		for _, name := range typeNames(t) {
+			if !knownTypes[name] {
+				return fmt.Errorf("%s: unknown type %q", path, name)
+			}
+		}
+		if len(typeNames(t)) == 0 {
+			return fmt.Errorf("%s: type must be a string or a list of strings", path)
+		}
+	}
+	if p, ok := schema["pattern"]; ok {
+		s, isString := p.(string)
+		if !isString {
+			return fmt.Errorf("%s: pattern must be a string", path)
+		}
+		if _, err := regexp.Compile(s); err != nil {
+			return fmt.Errorf("%s: pattern: %v", path, err)
+		}
+	}
+	if props, ok := schema["properties"]; ok {
+		m, isObject := props.(map[string]any)
+		if !isObject {
+			return fmt.Errorf("%s: properties must be an object", path)
+		}
+		for name, sub := range m {
+			subSchema, isObject := sub.(map[string]any)
+			if !isObject {
+				return fmt.Errorf("%s.%s: schema must be an object", path, name)
+			}
+			if err := check(subSchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	for key, subPath := range map[string]string{"items": path + "[]", "additionalProperties": path + ".*"} {
+		switch sub := schema[key].(type) {
+		case nil, bool:
+		case map[string]any:
+			if err := check(sub, subPath); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: %s must be a schema", path, key)
+		}
+	}
+	if req, ok := schema["required"]; ok {
+		list, isList := req.([]any)
+		if !isList {
+			return fmt.Errorf("%s: required must be a list of property names", path)
+		}
+		for _, r := range list {
+			if _, isString := r.(string); !isString {
+				return fmt.Errorf("%s: required must be a list of property names", path)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate parses doc as JSON and checks it against schema, returning an
+// *Error for the first failing value.
+func Validate(schema map[string]any, doc string) error {
+	var v any
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return &Error{Path: "$", Message: "not valid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return &Error{Path: "$", Message: "not valid JSON: unexpected data after the value"}
+	}
+	return validate(schema, v, "$")
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+func typeNames(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var names []string
+		for _, n := range t {
+			if s, ok := n.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// typeOf names the JSON type of a decoded value.
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func validate(schema map[string]any, v any, path string) error {
+	fail := func(format string, args ...any) error {
+		return &Error{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if t, ok := schema["type"]; ok {
+		names := typeNames(t)
+		got := typeOf(v)
+		match := false
+		for _, n := range names {
+			if n == got || (n == "number" && got == "integer") {
+				match = true
+			}
+		}
+		if !match {
+			return fail("expected %s, got %s", strings.Join(names, " or "), got)
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %s", jsonList(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !equal(c, v) {
+		return fail("must be %s", jsonText(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if req, ok := schema["required"].([]any); ok {
+			for _, r := range req {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					return fail("missing required property %q", name)
+				}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := props[name].(map[string]any); ok {
+				if err := validate(sub, v[name], path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fail("unexpected property %q", name)
+				}
+			case map[string]any:
+				if err := validate(extra, v[name], path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if n, ok := bound(schema, "minItems"); ok && float64(len(v)) < n {
+			return fail("must have at least %s items", num(n))
+		}
+		if n, ok := bound(schema, "maxItems"); ok && float64(len(v)) > n {
+			return fail("must have at most %s items", num(n))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if min, ok := bound(schema, "minLength"); ok && n < min {
+			return fail("must be at least %s characters", num(min))
+		}
+		if max, ok := bound(schema, "maxLength"); ok && n > max {
+			return fail("must be at most %s characters", num(max))
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(v) {
+				return fail("must match pattern %q", p)
+			}
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if min, ok := bound(schema, "minimum"); ok && f < min {
+			return fail("must be at least %s", num(min))
+		}
+		if max, ok := bound(schema, "maximum"); ok && f > max {
+			return fail("must be at most %s", num(max))
+		}
+	}
+	return nil
+}
+
+// bound reads a numeric keyword. Schemas come from encoding/json, so
+// numbers are float64.
+func bound(schema map[string]any, key string) (float64, bool) {
+	f, ok := schema[key].(float64)
+	return f, ok
+}
+
+func num(f float64) string {
+	if f == math.Trunc(f) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// equal compares a schema value (float64 numbers) with a document value
+// (json.Number) by their JSON encodings.
+func equal(schemaValue, docValue any) bool {
+	if n, ok := docValue.(json.Number); ok {
+		f, err := n.Float64()
+		sf, isNumber := schemaValue.(float64)
+		return err == nil && isNumber && f == sf
+	}
+	return jsonText(schemaValue) == jsonText(docValue)
+}
+
+func jsonText(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func jsonList(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = jsonText(v)
+	}
+	return strings.Join(parts, ", ")
+}
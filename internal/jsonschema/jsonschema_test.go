@@ -0,0 +1,125 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// schema decodes a schema the way callers do, with encoding/json.
+func schema(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("bad test schema: %v", err)
+	}
+	return m
+}
+
+func TestValidate(t *testing.T) {
+	const config = `{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+			"level": {"enum": ["low", "high"]},
+			"version": {"const": 2},
+			"ratio": {"type": "number", "minimum": 0, "maximum": 1.5},
+			"count": {"type": "integer"},
+			"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+			"extra": {"type": ["string", "null"]},
+			"env": {"type": "object", "additionalProperties": {"type": "string"}}
+		}
+	}`
+	tests := []struct {
+		name     string
+		doc      string
+		wantPath string // "" when the document is valid
+		wantMsg  string
+	}{
+		{name: "minimal", doc: `{"name": "abc"}`},
+		{name: "everything", doc: `{"name": "ab", "level": "high", "version": 2, "ratio": 1.5, "count": 3,
+			"tags": ["x"], "extra": null, "env": {"A": "1"}}`},
+		{name: "integer is a number", doc: `{"name": "ab", "ratio": 1}`},
+		{name: "const as float", doc: `{"name": "ab", "version": 2.0}`},
+		{name: "multibyte length", doc: `{"name": "ab", "env": {"A": "é"}}`},
+
+		{name: "not JSON", doc: `{"name":`, wantPath: "$", wantMsg: "not valid JSON"},
+		{name: "trailing data", doc: `{"name": "ab"} {}`, wantPath: "$", wantMsg: "after the value"},
+		{name: "wrong root type", doc: `[]`, wantPath: "$", wantMsg: "expected object, got array"},
+		{name: "missing required", doc: `{}`, wantPath: "$", wantMsg: `missing required property "name"`},
+		{name: "unexpected property", doc: `{"name": "ab", "zzz": 1}`, wantPath: "$", wantMsg: `unexpected property "zzz"`},
+		{name: "too short", doc: `{"name": "a"}`, wantPath: "$.name", wantMsg: "at least 2 characters"},
+		{name: "too long", doc: `{"name": "abcdef"}`, wantPath: "$.name", wantMsg: "at most 5 characters"},
+		{name: "pattern", doc: `{"name": "AB"}`, wantPath: "$.name", wantMsg: "must match pattern"},
+		{name: "enum", doc: `{"name": "ab", "level": "mid"}`, wantPath: "$.level", wantMsg: `must be one of "low", "high"`},
+		{name: "const", doc: `{"name": "ab", "version": 3}`, wantPath: "$.version", wantMsg: "must be 2"},
+		{name: "below minimum", doc: `{"name": "ab", "ratio": -0.1}`, wantPath: "$.ratio", wantMsg: "at least 0"},
+		{name: "above maximum", doc: `{"name": "ab", "ratio": 2}`, wantPath: "$.ratio", wantMsg: "at most 1.5"},
+		{name: "not an integer", doc: `{"name": "ab", "count": 1.5}`, wantPath: "$.count", wantMsg: "expected integer, got number"},
+		{name: "too few items", doc: `{"name": "ab", "tags": []}`, wantPath: "$.tags", wantMsg: "at least 1 items"},
+		{name: "too many items", doc: `{"name": "ab", "tags": ["a", "b", "c"]}`, wantPath: "$.tags", wantMsg: "at most 2 items"},
+		{name: "bad item", doc: `{"name": "ab", "tags": ["a", 1]}`, wantPath: "$.tags[1]", wantMsg: "expected string, got integer"},
+		{name: "type list", doc: `{"name": "ab", "extra": 1}`, wantPath: "$.extra", wantMsg: "expected string or null"},
+		{name: "additional schema", doc: `{"name": "ab", "env": {"A": 1}}`, wantPath: "$.env.A", wantMsg: "expected string"},
+	}
+	s := schema(t, config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(s, tt.doc)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			var verr *Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate error = %v, want an *Error", err)
+			}
+			if verr.Path != tt.wantPath || !strings.Contains(verr.Message, tt.wantMsg) {
+				t.Errorf("Validate error = %q, want %s: ...%s...", verr, tt.wantPath, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "empty", schema: `{}`},
+		{name: "nested", schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": ["string", "null"], "pattern": "^x"}},
+			"additionalProperties": {"type": "integer"}, "items": true}`},
+		{name: "unknown type", schema: `{"type": "text"}`, wantErr: `$: unknown type "text"`},
+		{name: "type not a string", schema: `{"type": 3}`, wantErr: "type must be a string"},
+		{name: "bad pattern", schema: `{"pattern": "("}`, wantErr: "$: pattern:"},
+		{name: "pattern not a string", schema: `{"pattern": 1}`, wantErr: "pattern must be a string"},
+		{name: "properties not an object", schema: `{"properties": []}`, wantErr: "properties must be an object"},
+		{name: "property not a schema", schema: `{"properties": {"a": 1}}`, wantErr: "$.a: schema must be an object"},
+		{name: "nested property", schema: `{"properties": {"a": {"type": "nope"}}}`, wantErr: `$.a: unknown type "nope"`},
+		{name: "items not a schema", schema: `{"items": "x"}`, wantErr: "items must be a schema"},
+		{name: "bad items", schema: `{"items": {"type": "nope"}}`, wantErr: `$[]: unknown type`},
+		{name: "bad additionalProperties", schema: `{"additionalProperties": {"pattern": "("}}`, wantErr: "$.*: pattern:"},
+		{name: "required not a list", schema: `{"required": "a"}`, wantErr: "required must be a list"},
+		{name: "required not strings", schema: `{"required": [1]}`, wantErr: "required must be a list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(schema(t, tt.schema))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
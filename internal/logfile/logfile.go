@@ -0,0 +1,90 @@
+// Package logfile is an io.Writer that appends to a file and rotates it
+// by size, for sending logs somewhere other than stderr.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Writer appends to a file. When a write would take the file past its
+// size limit, the file is renamed to path.1 (path.1 to path.2, and so on)
+// and a new one is started; the oldest backup beyond the limit is removed.
+type Writer struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open opens path for appending, creating it if needed. maxSize is in
+// bytes; zero never rotates. backups is how many rotated files to keep.
+func Open(path string, maxSize int64, backups int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, backups: backups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if the file would outgrow its limit.
+// A single write larger than the limit still goes into one file.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.backups > 0 {
+		os.Remove(w.backupName(w.backups))
+		for i := w.backups - 1; i >= 1; i-- {
+			os.Rename(w.backupName(i), w.backupName(i+1))
+		}
+		if err := os.Rename(w.path, w.backupName(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *Writer) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", w.path, n)
+}
+
+// Close closes the current file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
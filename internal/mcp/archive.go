@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// archiveTopic is where archive_sessions writes distilled session memories.
+const archiveTopic = "session-archive"
+
+// archivedSession reports what archive_sessions did with one session.
+type archivedSession struct {
+	SessionNum int    `json:"session_num"`
+	Title      string `json:"title"`
+	Bytes      int    `json:"bytes"`
+	ExportPath string `json:"export_path,omitempty"`
+	MemoryKey  string `json:"memory_key,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (s *Server) handleArchiveSessions(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	olderThan := intArg(req, "older_than_days", 0)
+	exportDir := stringArg(req, "export_dir")
+	extract := boolArg(req, "extract_memories", false)
+	dryRun := boolArg(req, "dry_run", false)
+
+	if projectID == "" || olderThan <= 0 {
+		return mcpsdk.NewToolResultError("project_id and a positive older_than_days are required"), nil
+	}
+
+	sessions, err := s.store.ListSessions(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list sessions: %v", err)), nil
+	}
+	if exportDir != "" {
+		if exportDir, err = serverPath(s.opts.ArchiveDir, "ARCHIVE_DIR", exportDir); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("export_dir: %v", err)), nil
+		}
+	}
+	if exportDir != "" && !dryRun {
+		if err := os.MkdirAll(exportDir, 0o755); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("create export dir: %v", err)), nil
+		}
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -olderThan)
+	var results []archivedSession
+	var reclaimed int
+	for _, meta := range sessions {
+		if !meta.CreatedAt.Before(cutoff) || meta.Metadata[store.MetaArchivedAt] != nil {
+			continue
+		}
+		sess, err := s.store.GetSession(ctx, projectID, meta.SessionNum)
+		if err != nil || sess == nil || sess.Content == "" {
+			continue
+		}
+		r := archivedSession{SessionNum: sess.SessionNum, Title: sess.Title, Bytes: len(sess.Content)}
+		if dryRun {
+			results = append(results, r)
+			reclaimed += r.Bytes
+			continue
+		}
+
+		if exportDir != "" {
+			r.ExportPath = filepath.Join(exportDir, fmt.Sprintf("%s-session-%d.md", fileSafe(projectID), sess.SessionNum))
+			if err := os.WriteFile(r.ExportPath, []byte(sess.Content), 0o644); err != nil {
+				r.Error = fmt.Sprintf("export: %v", err)
+				results = append(results, r)
+				continue
+			}
+		}
+		if extract {
+			key, err := s.distillSession(ctx, sess)
+			if err != nil {
+				r.Error = fmt.Sprintf("extract memories: %v", err)
+				results = append(results, r)
+				continue
+			}
+			r.MemoryKey = key
+		}
+		if err := s.store.ArchiveSession(ctx, projectID, sess.SessionNum, r.ExportPath); err != nil {
+			r.Error = fmt.Sprintf("archive: %v", err)
+		} else {
+			reclaimed += r.Bytes
+		}
+		results = append(results, r)
+	}
+
+	response := map[string]any{
+		"project_id":      projectID,
+		"cutoff":          cutoff.Format(time.RFC3339),
+		"dry_run":         dryRun,
+		"archived":        len(results),
+		"bytes_reclaimed": reclaimed,
+		"sessions":        results,
+	}
+	s.recordUsage(ctx, "archive_sessions", projectID, strconv.Itoa(olderThan), len(results))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// distillSession saves a session's summary and section headings as a draft
+// memory so the gist survives after its content is archived.
+func (s *Server) distillSession(ctx context.Context, sess *store.Session) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %d: %s\n\n", sess.SessionNum, sess.Title)
+	if sess.Summary != "" {
+		b.WriteString(sess.Summary + "\n\n")
+	}
+	if outline := parseOutline(sess.Content, 2); len(outline) > 0 {
+		b.WriteString("Sections:\n")
+		var walk func(nodes []*outlineNode, depth int)
+		walk = func(nodes []*outlineNode, depth int) {
+			for _, n := range nodes {
+				fmt.Fprintf(&b, "%s- %s\n", strings.Repeat("  ", depth), n.Title)
+				walk(n.Children, depth+1)
+			}
+		}
+		walk(outline, 0)
+	}
+
+	mem := &store.Memory{
+		ProjectID: sess.ProjectID,
+		Topic:     archiveTopic,
+		Key:       fmt.Sprintf("session-%d", sess.SessionNum),
+		Value:     strings.TrimSpace(b.String()),
+		Status:    store.MemoryDraft,
+	}
+	emb, err := s.embedFor(ctx, store.EntityMemory, sess.ProjectID, mem.EmbedText(s.opts.MemoryEmbedKey))
+	if err != nil {
+		return "", err
+	}
+	return mem.Key, s.store.SetMemory(ctx, mem, emb)
+}
+
+func (s *Server) handleSessionRestore(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	content := stringArg(req, "content")
+
+	if projectID == "" || sessionNum == 0 {
+		return mcpsdk.NewToolResultError("project_id and session_num are required"), nil
+	}
+
+	sess, err := s.store.GetSession(ctx, projectID, sessionNum)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get session: %v", err)), nil
+	}
+	if sess == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+	if content == "" {
+		path, _ := sess.Metadata[store.MetaArchivePath].(string)
+		if path == "" {
+			return mcpsdk.NewToolResultError("session was not exported when archived; pass content to restore it"), nil
+		}
+		if !insideDir(s.opts.ArchiveDir, path) {
+			return mcpsdk.NewToolResultError("session export is outside ARCHIVE_DIR; pass content to restore it"), nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("read export: %v", err)), nil
+		}
+		content = string(data)
+	}
+
+	if err := s.store.RestoreSession(ctx, projectID, sessionNum, content); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("restore session: %v", err)), nil
+	}
+	s.recordUsage(ctx, "session_restore", projectID, strconv.Itoa(sessionNum), 1)
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Session %d restored (%d bytes)", sessionNum, len(content))), nil
+}
@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// changesSafetyWindow is how far behind the present the memory_changes
+// cursor stays. updated_at is the writing transaction's start time, so a
+// write that commits after a poll can carry an older timestamp than rows
+// already returned; holding the cursor back returns it on a later poll.
+// Changes in the window, and rows at the cursor itself, may be returned
+// more than once.
+const changesSafetyWindow = time.Minute
+
+func (s *Server) handleMemoryChanges(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sinceArg := stringArg(req, "since")
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	var since time.Time
+	if sinceArg != "" {
+		t, err := time.Parse(time.RFC3339Nano, sinceArg)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("invalid since %q: use the cursor from the previous call or an RFC3339 timestamp", sinceArg)), nil
+		}
+		since = t
+	}
+
+	changed, err := s.store.ListMemoriesChangedSince(ctx, projectID, since)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list changed memories: %v", err)), nil
+	}
+	deleted, err := s.store.ListMemoryDeletionsSince(ctx, projectID, since)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list deleted memories: %v", err)), nil
+	}
+
+	// The cursor is the newest change seen, held back by the safety window
+	// but never moved backwards; with nothing new it stays put.
+	cursor := since
+	for _, m := range changed {
+		if m.UpdatedAt.After(cursor) {
+			cursor = m.UpdatedAt
+		}
+	}
+	for _, d := range deleted {
+		if d.DeletedAt.After(cursor) {
+			cursor = d.DeletedAt
+		}
+	}
+	if horizon := time.Now().Add(-changesSafetyWindow); cursor.After(horizon) {
+		cursor = horizon
+	}
+	if cursor.Before(since) {
+		cursor = since
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"since":      sinceArg,
+		"cursor":     cursor.UTC().Format(time.RFC3339Nano),
+		"changed":    changed,
+		"deleted":    deleted,
+	}
+	s.recordUsage(ctx, "memory_changes", projectID, "", len(changed)+len(deleted))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxClusterMemories caps max_memories: k-means here is O(n·k·dim) per
+// iteration and runs inside a tool call.
+const maxClusterMemories = 2000
+
+// memoryCluster is one group of semantically similar memories.
+type memoryCluster struct {
+	Size           int            `json:"size"`
+	Representative string         `json:"representative"` // member closest to the centroid
+	Cohesion       float64        `json:"cohesion"`       // mean member similarity to the centroid
+	Topics         map[string]int `json:"topics"`
+	Members        []string       `json:"members"`
+}
+
+func (s *Server) handleMemoryClusters(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	k := intArg(req, "k", 5)
+	limit := intArg(req, "max_memories", 500)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if k < 1 {
+		return mcpsdk.NewToolResultError("k must be at least 1"), nil
+	}
+	if limit <= 0 || limit > maxClusterMemories {
+		limit = maxClusterMemories
+	}
+
+	vectors, total, err := s.store.SampleMemoryEmbeddings(ctx, projectID, limit)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("memory embeddings: %v", err)), nil
+	}
+	// The sample comes back in random order; sort so that the same
+	// memories always give the same clusters.
+	sort.Slice(vectors, func(i, j int) bool {
+		if vectors[i].Topic != vectors[j].Topic {
+			return vectors[i].Topic < vectors[j].Topic
+		}
+		return vectors[i].Key < vectors[j].Key
+	})
+
+	clusters := clusterMemories(vectors, k)
+	response := map[string]any{
+		"project_id": projectID,
+		"k":          len(clusters),
+		"memories":   len(vectors),
+		"embedded":   total,
+		"sampled":    total > len(vectors),
+		"clusters":   clusters,
+	}
+	s.recordUsage(ctx, "memory_clusters", projectID, "", len(clusters))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// clusterMemories groups memories into at most k clusters by cosine
+// similarity, largest cluster first.
+func clusterMemories(vectors []store.MemoryVector, k int) []memoryCluster {
+	points := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		points[i] = normalize(v.Embedding)
+	}
+	assign, centroids := sphericalKMeans(points, k)
+
+	clusters := make([]memoryCluster, len(centroids))
+	best := make([]float64, len(centroids))
+	for c := range clusters {
+		clusters[c].Topics = map[string]int{}
+		best[c] = math.Inf(-1)
+	}
+	for i, c := range assign {
+		sim := dot(points[i], centroids[c])
+		name := vectors[i].Topic + "/" + vectors[i].Key
+		cl := &clusters[c]
+		cl.Size++
+		cl.Cohesion += sim
+		cl.Topics[vectors[i].Topic]++
+		cl.Members = append(cl.Members, name)
+		if sim > best[c] {
+			best[c], cl.Representative = sim, name
+		}
+	}
+
+	var out []memoryCluster
+	for _, cl := range clusters {
+		if cl.Size == 0 {
+			continue
+		}
+		cl.Cohesion = math.Round(cl.Cohesion/float64(cl.Size)*1000) / 1000
+		out = append(out, cl)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Size > out[j].Size })
+	return out
+}
+
+// sphericalKMeans runs k-means on unit vectors with cosine similarity,
+// seeded k-means++ style from a fixed seed so results are repeatable.
+// It returns each point's cluster and the cluster centroids.
+func sphericalKMeans(points [][]float64, k int) ([]int, [][]float64) {
+	if len(points) == 0 {
+		return nil, nil
+	}
+	k = min(k, len(points))
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	centroids := [][]float64{points[rng.IntN(len(points))]}
+	for len(centroids) < k {
+		// Pick the next seed with probability proportional to its squared
+		// distance from the nearest seed so far.
+		weights := make([]float64, len(points))
+		sum := 0.0
+		for i, p := range points {
+			d := 1.0
+			for _, c := range centroids {
+				d = min(d, 1-dot(p, c))
+			}
+			weights[i] = d * d
+			sum += weights[i]
+		}
+		if sum == 0 {
+			break // every point duplicates a seed: fewer distinct clusters
+		}
+		r := rng.Float64() * sum
+		next := len(points) - 1
+		for i, w := range weights {
+			if r -= w; r <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, points[next])
+	}
+
+	assign := make([]int, len(points))
+	for iter := 0; iter < 50; iter++ {
+		changed := iter == 0
+		for i, p := range points {
+			bestC, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if sim := dot(p, centroid); sim > bestSim {
+					bestC, bestSim = c, sim
+				}
+			}
+			if assign[i] != bestC {
+				assign[i], changed = bestC, true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([][]float64, len(centroids))
+		for i, c := range assign {
+			if sums[c] == nil {
+				sums[c] = make([]float64, len(points[i]))
+			}
+			for d, x := range points[i] {
+				sums[c][d] += x
+			}
+		}
+		for c, sum := range sums {
+			if sum != nil { // an emptied cluster keeps its centroid
+				centroids[c] = unit(sum)
+			}
+		}
+	}
+	return assign, centroids
+}
+
+func normalize(v []float32) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return unit(out)
+}
+
+// unit scales v to length 1 in place; a zero vector is left as is.
+func unit(v []float64) []float64 {
+	n := math.Sqrt(dot(v, v))
+	if n == 0 {
+		return v
+	}
+	for i := range v {
+		v[i] /= n
+	}
+	return v
+}
+
+func dot(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// comparedMemory is one side of a memory_compare result.
+type comparedMemory struct {
+	Topic string `json:"topic"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Embedded is "stored", or "on the fly" for a memory without a vector.
+	Embedded string `json:"embedded"`
+}
+
+func (s *Server) handleMemoryCompare(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topicA, keyA := stringArg(req, "topic_a"), stringArg(req, "key_a")
+	topicB, keyB := stringArg(req, "topic_b"), stringArg(req, "key_b")
+
+	if projectID == "" || topicA == "" || keyA == "" || topicB == "" || keyB == "" {
+		return mcpsdk.NewToolResultError("project_id, topic_a, key_a, topic_b, and key_b are required"), nil
+	}
+
+	a, vecA, err := s.compareSide(ctx, projectID, topicA, keyA)
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	b, vecB, err := s.compareSide(ctx, projectID, topicB, keyB)
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	if len(vecA) != len(vecB) {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embedding dimensions differ (%d vs %d); reindex the project", len(vecA), len(vecB))), nil
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"similarity": round3(embedding.Cosine(vecA, vecB)),
+		"a":          a,
+		"b":          b,
+	}
+	s.recordUsage(ctx, "memory_compare", projectID, topicA+"/"+keyA+" "+topicB+"/"+keyB, 2)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// compareSide loads a memory and its vector, embedding the memory's text
+// when it has no stored vector. The on-the-fly vector is not saved.
+func (s *Server) compareSide(ctx context.Context, projectID, topic, key string) (comparedMemory, store.Vector, error) {
+	m, err := s.store.GetMemory(ctx, projectID, topic, key)
+	if err != nil {
+		return comparedMemory{}, nil, fmt.Errorf("get memory: %v", err)
+	}
+	if m == nil {
+		return comparedMemory{}, nil, fmt.Errorf("memory %s/%s not found", topic, key)
+	}
+	side := comparedMemory{Topic: topic, Key: key, Value: m.Value, Embedded: "stored"}
+
+	vec, err := s.store.GetMemoryEmbedding(ctx, projectID, topic, key)
+	if err != nil {
+		return side, nil, fmt.Errorf("get embedding: %v", err)
+	}
+	if vec == nil {
+		side.Embedded = "on the fly"
+		if vec, err = s.embedFor(ctx, store.EntityMemory, projectID, m.EmbedText(s.opts.MemoryEmbedKey)); err != nil {
+			return side, nil, fmt.Errorf("embed %s/%s: %v", topic, key, err)
+		}
+		if vec == nil {
+			return side, nil, fmt.Errorf("%s/%s has no stored vector and embeddings are not enabled for this project", topic, key)
+		}
+	}
+	return side, vec, nil
+}
@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// conflictTag marks memories flagged by memory_conflicts.
+const conflictTag = "conflict"
+
+// memoryConflict is a pair of memories about the same thing that say
+// different things.
+type memoryConflict struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+	Overlap    float64 `json:"overlap"` // word overlap of the two values, 0-1
+	Diff       string  `json:"diff"`
+}
+
+func (s *Server) handleMemoryConflicts(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	minSim := floatArg(req, "similarity", 0.85)
+	maxOverlap := floatArg(req, "max_overlap", 0.5)
+	limit := intArg(req, "limit", 20)
+	flag := boolArg(req, "flag", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	vectors, total, err := s.store.SampleMemoryEmbeddings(ctx, projectID, maxClusterMemories)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("memory embeddings: %v", err)), nil
+	}
+	memories, err := s.store.ListMemories(ctx, projectID, "")
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+	}
+	byName := make(map[string]*store.Memory, len(memories))
+	for i := range memories {
+		byName[memories[i].Topic+"/"+memories[i].Key] = &memories[i]
+	}
+
+	conflicts, pairs := findConflicts(vectors, byName, minSim, maxOverlap)
+	if limit > 0 && len(conflicts) > limit {
+		conflicts, pairs = conflicts[:limit], pairs[:limit]
+	}
+
+	response := map[string]any{
+		"project_id":  projectID,
+		"similarity":  minSim,
+		"max_overlap": maxOverlap,
+		"compared":    len(vectors),
+		"sampled":     total > len(vectors),
+		"count":       len(conflicts),
+		"conflicts":   conflicts,
+	}
+	if flag && len(pairs) > 0 {
+		var ids []int64
+		for _, p := range pairs {
+			ids = append(ids, p[0].ID, p[1].ID)
+		}
+		tagged, err := s.store.TagMemories(ctx, projectID, ids, conflictTag)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("tag memories: %v", err)), nil
+		}
+		response["flagged"] = tagged
+		response["tag"] = conflictTag
+	}
+	s.recordUsage(ctx, "memory_conflicts", projectID, "", len(conflicts))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// findConflicts compares every pair of memories and returns those at
+// least minSim similar whose values share at most maxOverlap of their
+// words, most similar first, with the memories of each pair. Versions of
+// one key (key, key@2, ...) are expected to differ and are skipped.
+func findConflicts(vectors []store.MemoryVector, byName map[string]*store.Memory, minSim, maxOverlap float64) ([]memoryConflict, [][2]*store.Memory) {
+	type candidate struct {
+		a, b *store.Memory
+		sim  float64
+	}
+	points := make([][]float64, len(vectors))
+	mems := make([]*store.Memory, len(vectors))
+	for i, v := range vectors {
+		points[i] = normalize(v.Embedding)
+		mems[i] = byName[v.Topic+"/"+v.Key]
+	}
+
+	var found []candidate
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			a, b := mems[i], mems[j]
+			if a == nil || b == nil || sameBaseKey(a, b) {
+				continue
+			}
+			if sim := dot(points[i], points[j]); sim >= minSim {
+				found = append(found, candidate{a, b, sim})
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].sim > found[j].sim })
+
+	var conflicts []memoryConflict
+	var pairs [][2]*store.Memory
+	for _, c := range found {
+		overlap := wordOverlap(c.a.Value, c.b.Value)
+		if overlap > maxOverlap {
+			continue // same claim, at most a duplicate
+		}
+		conflicts = append(conflicts, memoryConflict{
+			A:          c.a.Topic + "/" + c.a.Key,
+			B:          c.b.Topic + "/" + c.b.Key,
+			Similarity: round3(c.sim),
+			Overlap:    round3(overlap),
+			Diff:       diffSummary(c.a.Value, c.b.Value),
+		})
+		pairs = append(pairs, [2]*store.Memory{c.a, c.b})
+	}
+	return conflicts, pairs
+}
+
+// sameBaseKey reports whether a and b are versions of one memory.
+func sameBaseKey(a, b *store.Memory) bool {
+	base := func(key string) string {
+		if hasVersionSuffix(key) {
+			return key[:strings.LastIndex(key, "@")]
+		}
+		return key
+	}
+	return a.Topic == b.Topic && base(a.Key) == base(b.Key)
+}
+
+// wordOverlap is the Jaccard similarity of the lower-cased word sets of
+// a and b.
+func wordOverlap(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := map[string]bool{}
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// searchProjects returns the projects a memory_search covers: projectID,
+// then those named by include_projects, a JSON array of ids or * for
+// every project the caller may access.
+func (s *Server) searchProjects(ctx context.Context, req mcpsdk.CallToolRequest, projectID string) ([]string, error) {
+	raw, ok := req.Params.Arguments["include_projects"]
+	if !ok || raw == nil || raw == "" {
+		return []string{projectID}, nil
+	}
+
+	var ids []string
+	if text, isString := raw.(string); isString && strings.TrimSpace(text) == "*" {
+		// ListProjects is already filtered to the caller's access scope.
+		projects, err := s.store.ListProjects(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list projects: %v", err)
+		}
+		for _, p := range projects {
+			ids = append(ids, p.ID)
+		}
+	} else {
+		data, isString := raw.(string)
+		if !isString {
+			b, _ := json.Marshal(raw)
+			data = string(b)
+		}
+		if err := json.Unmarshal([]byte(data), &ids); err != nil {
+			return nil, fmt.Errorf(`include_projects must be a JSON array of project ids or *: %v`, err)
+		}
+		scope := access.FromContext(ctx)
+		for _, id := range ids {
+			if !scope.Allows(id) {
+				return nil, fmt.Errorf("access denied: this token may not access project %q", id)
+			}
+		}
+	}
+
+	out := []string{projectID}
+	seen := map[string]bool{projectID: true}
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// searchMemoriesIn runs SearchMemories in each project and merges the
+// results by score, keeping the best limit. Every project is searched
+// with the same query vector, so vector scores are comparable; keyword
+// ranks are only roughly so.
+func (s *Server) searchMemoriesIn(ctx context.Context, projectIDs []string, query string, emb store.Vector, limit int, includeDrafts bool) ([]store.Memory, error) {
+	if len(projectIDs) == 1 {
+		return s.store.SearchMemories(ctx, projectIDs[0], query, emb, limit, includeDrafts)
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	var merged []store.Memory
+	for _, id := range projectIDs {
+		results, err := s.store.SearchMemories(ctx, id, query, emb, limit, includeDrafts)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", id, err)
+		}
+		merged = append(merged, results...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
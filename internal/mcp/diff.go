@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffMaxLines   = 8   // changed lines shown in a diff summary
+	diffMaxLineLen = 120 // characters kept per shown line
+	diffMaxCells   = 1 << 20
+)
+
+// diffSummary describes how after differs from before as a line count
+// header followed by up to diffMaxLines "-"/"+" lines. Returns "" when the
+// values are identical.
+func diffSummary(before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	// Trim the common prefix and suffix; memory edits are usually local.
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	var ops []string
+	if len(a)*len(b) <= diffMaxCells {
+		ops = lineDiff(a, b)
+	} else {
+		// Too large for LCS: report the whole middle as replaced.
+		for _, l := range a {
+			ops = append(ops, "-"+l)
+		}
+		for _, l := range b {
+			ops = append(ops, "+"+l)
+		}
+	}
+
+	added, removed := 0, 0
+	for _, op := range ops {
+		if op[0] == '+' {
+			added++
+		} else {
+			removed++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "+%d/-%d lines", added, removed)
+	for i, op := range ops {
+		if i == diffMaxLines {
+			fmt.Fprintf(&sb, "\n... %d more changed lines", len(ops)-i)
+			break
+		}
+		sb.WriteString("\n")
+		sb.WriteString(truncate(op, diffMaxLineLen))
+	}
+	return sb.String()
+}
+
+// lineDiff returns the removed ("-") and added ("+") lines between a and b
+// using a longest-common-subsequence table.
+func lineDiff(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, "-"+a[i])
+			i++
+		default:
+			ops = append(ops, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, "+"+b[j])
+	}
+	return ops
+}
+
+// truncate shortens s to at most n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	"github.com/Platform-LSS/devmemory/internal/summary"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// The project digest is stored as an ordinary memory under this key.
+const (
+	digestTopic = "project"
+	digestKey   = "digest"
+)
+
+// digestTopics is how many of the largest topics a digest covers when
+// the project has no priming topics.
+const digestTopics = 5
+
+// digestEntryChars is the summary budget of each memory or session in a
+// digest.
+const digestEntryChars = 240
+
+// handleProjectDigest writes a Markdown overview of the project to the
+// project/digest memory: the newest published memories of each priming
+// topic (or the largest topics) and the latest sessions, each cut to an
+// extractive summary. Running it again replaces the previous digest.
+func (s *Server) handleProjectDigest(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	perTopic := intArg(req, "per_topic", 3)
+	sessionCount := intArg(req, "sessions", 5)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if perTopic <= 0 {
+		perTopic = 3
+	}
+	if sessionCount < 0 {
+		sessionCount = 0
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	memories, err := s.store.ListMemories(ctx, projectID, "")
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+	}
+	sessions, err := s.store.ListSessions(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list sessions: %v", err)), nil
+	}
+
+	byTopic := map[string][]store.Memory{}
+	for _, m := range memories {
+		if m.Status == store.MemoryPublished && !(m.Topic == digestTopic && m.Key == digestKey) {
+			byTopic[m.Topic] = append(byTopic[m.Topic], m)
+		}
+	}
+	topics := p.PrimingTopics()
+	if len(topics) == 0 {
+		for t := range byTopic {
+			topics = append(topics, t)
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			if len(byTopic[topics[i]]) != len(byTopic[topics[j]]) {
+				return len(byTopic[topics[i]]) > len(byTopic[topics[j]])
+			}
+			return topics[i] < topics[j]
+		})
+		topics = topics[:min(digestTopics, len(topics))]
+	}
+	if len(sessions) > sessionCount {
+		sessions = sessions[len(sessions)-sessionCount:]
+	}
+
+	now := time.Now().UTC()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s digest\n\nGenerated %s by project_digest.\n", p.Name, now.Format(time.RFC3339))
+	sampled := 0
+	for _, topic := range topics {
+		mems := byTopic[topic]
+		if len(mems) == 0 {
+			continue
+		}
+		sort.SliceStable(mems, func(i, j int) bool { return mems[i].UpdatedAt.After(mems[j].UpdatedAt) })
+		fmt.Fprintf(&b, "\n## %s\n\n", topic)
+		for _, m := range mems[:min(perTopic, len(mems))] {
+			fmt.Fprintf(&b, "- **%s**: %s\n", m.Key, digestLine(m.Value))
+			sampled++
+		}
+	}
+	if len(sessions) > 0 {
+		b.WriteString("\n## Recent sessions\n\n")
+		for i := len(sessions) - 1; i >= 0; i-- {
+			sess := sessions[i]
+			fmt.Fprintf(&b, "- **Session %d: %s**", sess.SessionNum, sess.Title)
+			if line := digestLine(sess.Summary); line != "" {
+				b.WriteString(": " + line)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if sampled == 0 && len(sessions) == 0 {
+		return mcpsdk.NewToolResultError("project has no published memories or sessions to digest"), nil
+	}
+
+	mem := &store.Memory{
+		ProjectID: projectID,
+		Topic:     digestTopic,
+		Key:       digestKey,
+		Value:     b.String(),
+		CreatedBy: "project_digest",
+		Status:    store.MemoryPublished,
+	}
+	if err := s.checkTopicSchema(ctx, projectID, digestTopic, mem.Value); err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	emb, err := s.embedFor(ctx, store.EntityMemory, projectID, mem.EmbedText(s.opts.MemoryEmbedKey))
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embed digest: %v", err)), nil
+	}
+	if err := s.store.SetMemory(ctx, mem, emb); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("store digest: %v", err)), nil
+	}
+
+	response := map[string]any{
+		"project_id":   projectID,
+		"topic":        digestTopic,
+		"key":          digestKey,
+		"generated_at": now,
+		"memories":     sampled,
+		"sessions":     len(sessions),
+		"digest":       mem.Value,
+	}
+	s.recordUsage(ctx, "project_digest", projectID, "", sampled+len(sessions))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// digestLine condenses text to one line of a digest. Text without prose,
+// such as a bare code block, falls back to its first line of code.
+func digestLine(text string) string {
+	if line := strings.Join(strings.Fields(summary.Extractive(text, digestEntryChars)), " "); line != "" {
+		return line
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "```") {
+			return truncate(line, digestEntryChars)
+		}
+	}
+	return ""
+}
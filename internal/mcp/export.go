@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/export"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+func (s *Server) handleMemoryExportMarkdown(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topic := stringArg(req, "topic")
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	memories, err := s.store.ListMemories(ctx, projectID, topic)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+	}
+
+	var doc strings.Builder
+	if err := export.MemoriesMarkdown(&doc, p, memories); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("render: %v", err)), nil
+	}
+	s.recordUsage(ctx, "memory_export_markdown", projectID, topic, len(memories))
+	return mcpsdk.NewToolResultText(doc.String()), nil
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleFileIndexAudit compares the file index with the project's
+// root_path. No content hash is stored per file, so a file counts as
+// modified when it was written after it was last indexed.
+func (s *Server) handleFileIndexAudit(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	prefix := stringArg(req, "prefix")
+	prune := boolArg(req, "prune", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	if p.RootPath == "" {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' has no root_path; re-register it with one", projectID)), nil
+	}
+	root, err := filepath.Abs(p.RootPath)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("resolve root_path: %v", err)), nil
+	}
+	if _, err := os.Stat(root); err != nil {
+		// Every file would look vanished; refuse rather than prune them all.
+		return mcpsdk.NewToolResultError(fmt.Sprintf("root_path is not readable by the server: %v", err)), nil
+	}
+
+	files, err := s.store.ListFiles(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list files: %v", err)), nil
+	}
+
+	modified, missing := []string{}, []string{}
+	var checked int
+	errs := map[string]string{}
+	for _, f := range files {
+		if prefix != "" && !strings.HasPrefix(f.FilePath, prefix) {
+			continue
+		}
+		checked++
+
+		abs := filepath.Join(root, f.FilePath)
+		if rel, err := filepath.Rel(root, abs); err != nil || strings.HasPrefix(rel, "..") {
+			errs[f.FilePath] = "path escapes project root"
+			continue
+		}
+		info, err := os.Stat(abs)
+		if os.IsNotExist(err) {
+			missing = append(missing, f.FilePath)
+			continue
+		}
+		if err != nil {
+			errs[f.FilePath] = err.Error()
+			continue
+		}
+		if info.ModTime().After(f.LastIndexed) {
+			modified = append(modified, f.FilePath)
+		}
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"checked":    checked,
+		"modified":   modified,
+		"missing":    missing,
+	}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+	if prune && len(missing) > 0 {
+		pruned, err := s.store.DeleteFiles(ctx, projectID, missing)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("prune files: %v", err)), nil
+		}
+		response["pruned"] = pruned
+	}
+	if len(modified) > 0 {
+		response["next_step"] = "Run file_refresh on the modified paths to re-index them."
+	}
+	s.recordUsage(ctx, "file_index_audit", projectID, prefix, len(modified)+len(missing))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
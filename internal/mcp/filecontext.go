@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleFileContext finds the memories and sessions most related to an
+// indexed file, using the file's stored embedding as the query vector.
+func (s *Server) handleFileContext(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	filePath := stringArg(req, "file_path")
+	limit := intArg(req, "limit", 5)
+
+	if projectID == "" || filePath == "" {
+		return mcpsdk.NewToolResultError("project_id and file_path are required"), nil
+	}
+
+	f, err := s.store.GetFile(ctx, projectID, filePath)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get file: %v", err)), nil
+	}
+	if f == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("file '%s' is not indexed; index it with file_index first", filePath)), nil
+	}
+
+	emb, err := s.store.GetFileEmbedding(ctx, projectID, filePath)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get file embedding: %v", err)), nil
+	}
+	if emb == nil {
+		// Indexed before embeddings were available: embed it now.
+		if emb, err = s.embedFor(ctx, store.EntityFile, projectID, f.EmbedText(s.opts.FileEmbedPath)); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("embed file: %v", err)), nil
+		}
+	}
+
+	// Without a vector, fall back to keyword search on the file's name:
+	// a whole summary as a query would require every word to match.
+	query := strings.TrimSuffix(path.Base(f.FilePath), path.Ext(f.FilePath))
+	memories, err := s.store.SearchMemories(ctx, projectID, query, emb, limit, false)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+	}
+	sessions, err := s.store.SearchSessions(ctx, projectID, query, emb, limit)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search sessions: %v", err)), nil
+	}
+
+	searchType := "full-text (file name)"
+	if emb != nil {
+		searchType = "semantic (file embedding)"
+	}
+	response := map[string]any{
+		"file_path":   f.FilePath,
+		"summary":     f.Summary,
+		"search_type": searchType,
+		"memories":    memories,
+		"sessions":    sessions,
+	}
+	s.recordUsage(ctx, "file_context", projectID, filePath, len(memories)+len(sessions))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
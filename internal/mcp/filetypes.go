@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultFileTypes are the text-bearing file types file_index accepts
+// unless FILE_INDEX_TYPES overrides them.
+var DefaultFileTypes = []string{
+	"go", "py", "js", "jsx", "ts", "tsx", "java", "kt", "rb", "rs", "c", "h", "cpp", "hpp", "cs",
+	"php", "swift", "scala", "sh", "bash", "sql", "proto", "graphql", "tf",
+	"md", "markdown", "txt", "rst", "html", "css", "scss",
+	"json", "yaml", "yml", "toml", "xml", "ini", "env", "dockerfile", "makefile",
+}
+
+// DefaultPathOnlyFileTypes are recorded by path but never embedded: a
+// summary of an image or PDF says little about its contents.
+var DefaultPathOnlyFileTypes = []string{"png", "jpg", "jpeg", "gif", "svg", "webp", "ico", "pdf"}
+
+// fileKind normalizes a file type for the allowlist: the given type, else
+// the path's extension, else its base name (Dockerfile, Makefile).
+func fileKind(fileType, filePath string) string {
+	kind := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(fileType)), ".")
+	if kind == "" {
+		kind = strings.TrimPrefix(strings.ToLower(path.Ext(filePath)), ".")
+	}
+	if kind == "" {
+		kind = strings.ToLower(path.Base(filePath))
+	}
+	return kind
+}
+
+// filePolicy reports whether a file may be indexed and whether it should
+// be embedded. An empty FileTypes allowlist accepts every type.
+func (s *Server) filePolicy(fileType, filePath string) (kind string, index, embed bool) {
+	kind = fileKind(fileType, filePath)
+	if slices.Contains(s.opts.PathOnlyFileTypes, kind) {
+		return kind, true, false
+	}
+	if len(s.opts.FileTypes) == 0 || slices.Contains(s.opts.FileTypes, kind) {
+		return kind, true, true
+	}
+	return kind, false, false
+}
+
+func (s *Server) handleFileTypes(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	types, err := s.store.ListFileTypes(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list file types: %v", err)), nil
+	}
+	total := 0
+	for _, t := range types {
+		total += t.Count
+	}
+	response := map[string]any{
+		"project_id": projectID,
+		"files":      total,
+		"types":      types,
+	}
+	s.recordUsage(ctx, "file_types", projectID, "", len(types))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
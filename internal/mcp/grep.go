@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// grepMatch is one hit in a session's content. Offsets are in characters
+// (runes), matching session_outline, so a hit can be placed in a section.
+type grepMatch struct {
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Excerpt string `json:"excerpt"`
+}
+
+func (s *Server) handleSessionGrep(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	query := stringArg(req, "query")
+	window := intArg(req, "context", 80)
+	limit := intArg(req, "limit", 20)
+
+	if projectID == "" || sessionNum == 0 || query == "" {
+		return mcpsdk.NewToolResultError("project_id, session_num, and query are required"), nil
+	}
+
+	sess, err := s.store.GetSession(ctx, projectID, sessionNum)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get session: %v", err)), nil
+	}
+	if sess == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+
+	matches, total := grepContent(sess.Content, query, window, limit)
+	response := map[string]any{
+		"session_num": sess.SessionNum,
+		"title":       sess.Title,
+		"query":       query,
+		"total":       total,
+		"count":       len(matches),
+		"matches":     matches,
+	}
+	s.recordUsage(ctx, "session_grep", projectID, strconv.Itoa(sessionNum)+": "+query, total)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// grepContent finds case-insensitive, non-overlapping occurrences of query
+// and returns up to limit of them with window characters of context on
+// each side, plus the total number of occurrences.
+func grepContent(content, query string, window, limit int) ([]grepMatch, int) {
+	text := []rune(content)
+	q := []rune(query)
+	fold := func(r rune) rune { return unicode.ToLower(r) }
+
+	var matches []grepMatch
+	total, line, lineAt := 0, 1, 0
+	for i := 0; i+len(q) <= len(text); i++ {
+		j := 0
+		for j < len(q) && fold(text[i+j]) == fold(q[j]) {
+			j++
+		}
+		if j < len(q) {
+			continue
+		}
+		total++
+		if limit <= 0 || len(matches) < limit {
+			for ; lineAt < i; lineAt++ {
+				if text[lineAt] == '\n' {
+					line++
+				}
+			}
+			start, end := max(0, i-window), min(len(text), i+len(q)+window)
+			excerpt := strings.TrimSpace(string(text[start:end]))
+			if start > 0 {
+				excerpt = "..." + excerpt
+			}
+			if end < len(text) {
+				excerpt += "..."
+			}
+			matches = append(matches, grepMatch{Offset: i, Line: line, Excerpt: excerpt})
+		}
+		i += len(q) - 1
+	}
+	return matches, total
+}
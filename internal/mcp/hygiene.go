@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// topicMerge is a suggested merge of several topics into one target.
+type topicMerge struct {
+	Target     string   `json:"target"`
+	Sources    []string `json:"sources"`
+	Similarity float64  `json:"min_similarity"`
+	Moved      int      `json:"moved,omitempty"`
+	Conflicts  int      `json:"conflicts,omitempty"`
+}
+
+func (s *Server) handleMemorySuggestMerges(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	threshold := floatArg(req, "threshold", 0.85)
+	apply := boolArg(req, "apply", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	centroids, err := s.store.TopicCentroids(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("topic centroids: %v", err)), nil
+	}
+
+	merges := clusterTopics(centroids, threshold)
+	if apply {
+		for i := range merges {
+			for _, src := range merges[i].Sources {
+				moved, conflicts, err := s.store.RenameTopic(ctx, projectID, src, merges[i].Target)
+				if err != nil {
+					return mcpsdk.NewToolResultError(fmt.Sprintf("merge %s into %s: %v", src, merges[i].Target, err)), nil
+				}
+				merges[i].Moved += moved
+				merges[i].Conflicts += conflicts
+			}
+		}
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"threshold":  threshold,
+		"applied":    apply,
+		"topics":     len(centroids),
+		"merges":     merges,
+	}
+	s.recordUsage(ctx, "memory_suggest_merges", projectID, "", len(merges))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// memoryIsland is a memory nobody has touched within the idle window.
+type memoryIsland struct {
+	Topic     string    `json:"topic"`
+	Key       string    `json:"key"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	IdleDays  int       `json:"idle_days"`
+}
+
+// handleMemoryIslands lists memories that are disconnected from current
+// work. Memories have no relation links in this schema, so "disconnected"
+// means not updated and not read via memory_get within the window.
+func (s *Server) handleMemoryIslands(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	days := intArg(req, "days", 90)
+	limit := intArg(req, "limit", 50)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if days <= 0 {
+		return mcpsdk.NewToolResultError("days must be a positive integer"), nil
+	}
+
+	now := time.Now()
+	memories, err := s.store.ListIdleMemories(ctx, projectID, now.AddDate(0, 0, -days))
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list idle memories: %v", err)), nil
+	}
+
+	islands := make([]memoryIsland, 0, len(memories))
+	for _, m := range memories {
+		if limit > 0 && len(islands) >= limit {
+			break
+		}
+		islands = append(islands, memoryIsland{
+			Topic:     m.Topic,
+			Key:       m.Key,
+			Status:    m.Status,
+			UpdatedAt: m.UpdatedAt,
+			IdleDays:  int(now.Sub(m.UpdatedAt).Hours() / 24),
+		})
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"days":       days,
+		"total":      len(memories),
+		"count":      len(islands),
+		"islands":    islands,
+		"suggestion": "Review each island: reference it from a related memory, refresh it with memory_set, or remove it with memory_delete.",
+	}
+	s.recordUsage(ctx, "memory_islands", projectID, "", len(memories))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// clusterTopics groups topics whose centroids are at least threshold
+// similar (single linkage). Each group merges into its largest topic.
+func clusterTopics(centroids []store.TopicCentroid, threshold float64) []topicMerge {
+	parent := make([]int, len(centroids))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	minSim := map[int]float64{}
+	for i := range centroids {
+		for j := i + 1; j < len(centroids); j++ {
+			sim := embedding.Cosine(centroids[i].Centroid, centroids[j].Centroid)
+			if sim < threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			if ri != rj {
+				parent[rj] = ri
+			}
+			if cur, ok := minSim[ri]; !ok || sim < cur {
+				minSim[ri] = sim
+			}
+		}
+	}
+
+	groups := map[int][]int{}
+	for i := range centroids {
+		r := find(i)
+		groups[r] = append(groups[r], i)
+	}
+
+	var merges []topicMerge
+	for root, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(a, b int) bool {
+			ca, cb := centroids[members[a]], centroids[members[b]]
+			if ca.Count != cb.Count {
+				return ca.Count > cb.Count
+			}
+			return ca.Topic < cb.Topic
+		})
+		m := topicMerge{Target: centroids[members[0]].Topic, Similarity: minSim[root]}
+		for _, idx := range members[1:] {
+			m.Sources = append(m.Sources, centroids[idx].Topic)
+		}
+		merges = append(merges, m)
+	}
+	sort.Slice(merges, func(i, j int) bool { return merges[i].Target < merges[j].Target })
+	return merges
+}
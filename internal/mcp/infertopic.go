@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/store"
+)
+
+// DefaultCatchAllTopic is where memory_set files a value stored without a
+// meaningful topic.
+const DefaultCatchAllTopic = "uncategorized"
+
+// DefaultGenericTopics are topic names memory_set treats as no topic.
+var DefaultGenericTopics = []string{"misc", "temp"}
+
+// catchAllTopic is Options.CatchAllTopic, or DefaultCatchAllTopic.
+func (s *Server) catchAllTopic() string {
+	if s.opts.CatchAllTopic != "" {
+		return s.opts.CatchAllTopic
+	}
+	return DefaultCatchAllTopic
+}
+
+// genericTopic reports whether topic is empty or one of
+// Options.GenericTopics, compared without case.
+func (s *Server) genericTopic(topic string) bool {
+	if topic == "" {
+		return true
+	}
+	for _, g := range s.opts.GenericTopics {
+		if strings.EqualFold(topic, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// inferTopic picks a topic for a memory_set without one: the existing
+// topic whose centroid is most similar to the value's embedding, if that
+// similarity reaches Options.TopicInferenceThreshold, otherwise the
+// catch-all topic. It also returns the similarity (0 when the value could
+// not be embedded).
+func (s *Server) inferTopic(ctx context.Context, projectID, value string) (string, float64, error) {
+	if !s.projectEmbeds(ctx, projectID) {
+		return s.catchAllTopic(), 0, nil
+	}
+	vec, _, err := s.embedder(store.EntityMemory).EmbedValue(ctx, value)
+	if err != nil || vec == nil {
+		return s.catchAllTopic(), 0, err
+	}
+	centroids, err := s.store.TopicCentroids(ctx, projectID)
+	if err != nil {
+		return "", 0, err
+	}
+	topic, best := s.nearestTopic(vec, centroids)
+	if best < s.opts.TopicInferenceThreshold {
+		topic = s.catchAllTopic()
+	}
+	return topic, round3(best), nil
+}
+
+// nearestTopic returns the topic whose centroid is most similar to vec and
+// that similarity, never the catch-all topic itself; "" if none is
+// similar at all.
+func (s *Server) nearestTopic(vec store.Vector, centroids []store.TopicCentroid) (string, float64) {
+	topic, best := "", 0.0
+	for _, c := range centroids {
+		if c.Topic == s.catchAllTopic() {
+			continue
+		}
+		if sim := embedding.Cosine(vec, c.Centroid); sim > best {
+			topic, best = c.Topic, sim
+		}
+	}
+	return topic, best
+}
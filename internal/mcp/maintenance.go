@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// embeddingAudit summarizes one entity's embeddings.
+type embeddingAudit struct {
+	Total      int         `json:"total"`
+	Missing    int         `json:"missing"`
+	Mismatched int         `json:"mismatched"`
+	Dims       map[int]int `json:"dims"`
+}
+
+func (s *Server) handleEmbeddingAudit(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	repair := boolArg(req, "repair", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	counts, err := s.store.EmbeddingDims(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("audit embeddings: %v", err)), nil
+	}
+
+	dims := map[string]int{}
+	for _, entity := range []string{store.EntityMemory, store.EntitySession, store.EntityFile} {
+		dims[entity] = s.embedder(entity).Dim()
+	}
+	entities := map[string]*embeddingAudit{}
+	mismatched := 0
+	for _, c := range counts {
+		a := entities[c.Entity]
+		if a == nil {
+			a = &embeddingAudit{Dims: map[int]int{}}
+			entities[c.Entity] = a
+		}
+		a.Total += c.Count
+		switch {
+		case c.Dim == 0:
+			a.Missing += c.Count
+		case c.Dim != dims[c.Entity]:
+			a.Mismatched += c.Count
+			mismatched += c.Count
+			a.Dims[c.Dim] = c.Count
+		default:
+			a.Dims[c.Dim] = c.Count
+		}
+	}
+
+	response := map[string]any{
+		"project_id":      projectID,
+		"configured_dims": dims,
+		"mismatched":      mismatched,
+		"entities":        entities,
+	}
+	if repair && mismatched > 0 {
+		cleared, err := s.store.ClearMismatchedEmbeddings(ctx, projectID, dims)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("clear embeddings: %v", err)), nil
+		}
+		response["cleared"] = cleared
+		response["next_step"] = "Run a reindex from the dashboard to re-embed the cleared rows."
+	}
+	s.recordUsage(ctx, "embedding_audit", projectID, "", mismatched)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleWarmCaches(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	// Record usage first: it is a write, and writes invalidate the caches.
+	s.recordUsage(ctx, "warm_caches", "", "", 0)
+	start := time.Now()
+	if err := s.store.WarmCaches(ctx); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("warm caches: %v", err)), nil
+	}
+	response := map[string]any{
+		"warmed":      []string{"dashboard_stats", "project_centroids"},
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleEmbeddingCoverage(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	coverage, err := s.store.GetEmbeddingCoverage(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embedding coverage: %v", err)), nil
+	}
+
+	response := map[string]any{
+		"coverage":           coverage,
+		"embeddings_enabled": p.EmbeddingsEnabled(),
+	}
+	if p.EmbeddingsEnabled() && coverage.Overall.Embedded < coverage.Overall.Total {
+		response["next_step"] = "Run a reindex from the dashboard to embed the missing rows."
+	}
+	s.recordUsage(ctx, "embedding_coverage", projectID, "", coverage.Overall.Total)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleProjectFootprint(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	footprint, err := s.store.GetProjectFootprint(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project footprint: %v", err)), nil
+	}
+
+	response := map[string]any{"footprint": footprint}
+	if footprint.ExternalSessions > 0 {
+		response["note"] = fmt.Sprintf("%d sessions keep their content in the content store; it is not counted in session_bytes.", footprint.ExternalSessions)
+	}
+	s.recordUsage(ctx, "project_footprint", projectID, "", 1)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleMaintenanceOrphans(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	cleanup := boolArg(req, "cleanup", false)
+
+	report, err := s.store.FindOrphans(ctx)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("find orphans: %v", err)), nil
+	}
+
+	response := map[string]any{
+		"total":   report.Total(),
+		"orphans": report,
+	}
+	if cleanup && report.Total() > 0 {
+		deleted, err := s.store.DeleteOrphans(ctx)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("delete orphans: %v", err)), nil
+		}
+		response["deleted"] = deleted
+	}
+	s.recordUsage(ctx, "maintenance_orphans", "", "", report.Total())
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultPreviewChars is the memory_manifest preview length.
+const defaultPreviewChars = 80
+
+// manifestTopic is one topic of a memory_manifest, newest memory first.
+type manifestTopic struct {
+	Topic     string          `json:"topic"`
+	Priming   bool            `json:"priming,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Memories  []manifestEntry `json:"memories"`
+}
+
+// manifestEntry is a memory without its full value.
+type manifestEntry struct {
+	Key       string    `json:"key"`
+	Preview   string    `json:"preview"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Draft     bool      `json:"draft,omitempty"`
+}
+
+func (s *Server) handleMemoryManifest(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	order := stringArg(req, "order")
+	previewChars := intArg(req, "preview_chars", defaultPreviewChars)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if order != "" && order != "priming" && order != "recency" {
+		return mcpsdk.NewToolResultError("order must be priming or recency"), nil
+	}
+	if previewChars <= 0 {
+		previewChars = defaultPreviewChars
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	priming := p.PrimingTopics()
+	if order == "" {
+		order = "recency"
+		if len(priming) > 0 {
+			order = "priming"
+		}
+	}
+	if order == "recency" {
+		priming = nil
+	}
+
+	memories, err := s.store.ListMemories(ctx, projectID, "")
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+	}
+	topics := buildManifest(memories, priming, previewChars)
+
+	response := map[string]any{
+		"project_id": projectID,
+		"order":      order,
+		"memories":   len(memories),
+		"topics":     topics,
+	}
+	s.recordUsage(ctx, "memory_manifest", projectID, "", len(memories))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// buildManifest groups memories by topic. Topics in priming come first in
+// that order; the rest follow, most recently updated first.
+func buildManifest(memories []store.Memory, priming []string, previewChars int) []manifestTopic {
+	byTopic := map[string]*manifestTopic{}
+	var topics []*manifestTopic
+	for _, m := range memories {
+		t := byTopic[m.Topic]
+		if t == nil {
+			t = &manifestTopic{Topic: m.Topic}
+			byTopic[m.Topic] = t
+			topics = append(topics, t)
+		}
+		t.Memories = append(t.Memories, manifestEntry{
+			Key:       m.Key,
+			Preview:   preview(m.Value, previewChars),
+			UpdatedAt: m.UpdatedAt,
+			Draft:     m.Status == store.MemoryDraft,
+		})
+		if m.UpdatedAt.After(t.UpdatedAt) {
+			t.UpdatedAt = m.UpdatedAt
+		}
+	}
+
+	rank := map[string]int{}
+	for i, name := range priming {
+		if _, seen := rank[name]; !seen {
+			rank[name] = i
+		}
+	}
+	sort.SliceStable(topics, func(i, j int) bool {
+		ri, pi := rank[topics[i].Topic]
+		rj, pj := rank[topics[j].Topic]
+		if pi != pj {
+			return pi
+		}
+		if pi {
+			return ri < rj
+		}
+		return topics[i].UpdatedAt.After(topics[j].UpdatedAt)
+	})
+
+	out := make([]manifestTopic, len(topics))
+	for i, t := range topics {
+		_, t.Priming = rank[t.Topic]
+		sort.SliceStable(t.Memories, func(a, b int) bool { return t.Memories[a].UpdatedAt.After(t.Memories[b].UpdatedAt) })
+		out[i] = *t
+	}
+	return out
+}
+
+// preview is the first non-blank line of value, whitespace collapsed and
+// cut to n characters.
+func preview(value string, n int) string {
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			return truncate(line, n)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleMemoryMergeKeys folds one memory into another of the same topic:
+// the target gets the merged value, a new embedding, and the tags of both,
+// and the source is deleted.
+func (s *Server) handleMemoryMergeKeys(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topic := stringArg(req, "topic")
+	sourceKey := stringArg(req, "source_key")
+	targetKey := stringArg(req, "target_key")
+	strategy := stringArg(req, "strategy")
+
+	if projectID == "" || topic == "" || sourceKey == "" || targetKey == "" {
+		return mcpsdk.NewToolResultError("project_id, topic, source_key, and target_key are required"), nil
+	}
+	if sourceKey == targetKey {
+		return mcpsdk.NewToolResultError("source_key and target_key must differ"), nil
+	}
+	if strategy == "" {
+		strategy = "concat"
+	}
+	if strategy != "concat" && strategy != "replace" {
+		return mcpsdk.NewToolResultError("strategy must be concat or replace"), nil
+	}
+
+	source, err := s.store.GetMemory(ctx, projectID, topic, sourceKey)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get memory: %v", err)), nil
+	}
+	if source == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("memory %s/%s not found", topic, sourceKey)), nil
+	}
+	target, err := s.store.GetMemory(ctx, projectID, topic, targetKey)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get memory: %v", err)), nil
+	}
+	if target == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("memory %s/%s not found", topic, targetKey)), nil
+	}
+
+	merged := *target
+	merged.Value = source.Value
+	if strategy == "concat" {
+		merged.Value = target.Value + "\n\n" + source.Value
+	}
+	if err := s.checkTopicSchema(ctx, projectID, topic, merged.Value); err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	emb, err := s.embedFor(ctx, store.EntityMemory, projectID, merged.EmbedText(s.opts.MemoryEmbedKey))
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embed merged value: %v", err)), nil
+	}
+	ok, err := s.store.MergeMemoryKeys(ctx, projectID, topic, sourceKey, targetKey, merged.Value, emb)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("merge memories: %v", err)), nil
+	}
+	if !ok {
+		return mcpsdk.NewToolResultError("one of the memories was deleted during the merge; nothing changed"), nil
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"topic":      topic,
+		"source_key": sourceKey,
+		"target_key": targetKey,
+		"strategy":   strategy,
+		"value":      merged.Value,
+		"embedded":   emb != nil,
+	}
+	s.recordUsage(ctx, "memory_merge_keys", projectID, topic+"/"+sourceKey+" "+topic+"/"+targetKey, 1)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
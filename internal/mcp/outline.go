@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Platform-LSS/devmemory/internal/summary"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// outlineNode is a Markdown heading and the section it starts. Offsets are
+// in characters (runes) into the session content; End is exclusive and
+// covers nested subsections.
+type outlineNode struct {
+	Level    int            `json:"level"`
+	Title    string         `json:"title"`
+	Offset   int            `json:"offset"`
+	End      int            `json:"end"`
+	Children []*outlineNode `json:"children,omitempty"`
+}
+
+func (s *Server) handleSessionOutline(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	maxLevel := intArg(req, "max_level", 6)
+
+	if projectID == "" || sessionNum == 0 {
+		return mcpsdk.NewToolResultError("project_id and session_num are required"), nil
+	}
+
+	sess, err := s.store.GetSession(ctx, projectID, sessionNum)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get session: %v", err)), nil
+	}
+	if sess == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+
+	outline := parseOutline(sess.Content, maxLevel)
+	response := map[string]any{
+		"session_num": sess.SessionNum,
+		"title":       sess.Title,
+		"length":      utf8.RuneCountInString(sess.Content),
+		"outline":     outline,
+	}
+	s.recordUsage(ctx, "session_outline", projectID, strconv.Itoa(sessionNum), 1)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// parseOutline extracts ATX headings ("## Title") outside fenced code
+// blocks and nests them by level.
+func parseOutline(content string, maxLevel int) []*outlineNode {
+	total := utf8.RuneCountInString(content)
+	var roots []*outlineNode
+	var stack []*outlineNode
+	var fence string
+
+	offset := 0 // rune offset of the current line
+	for _, line := range strings.SplitAfter(content, "\n") {
+		lineStart := offset
+		offset += utf8.RuneCountInString(line)
+
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		level, title := summary.Heading(line)
+		if level == 0 || level > maxLevel {
+			continue
+		}
+
+		node := &outlineNode{Level: level, Title: title, Offset: lineStart, End: total}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+			stack[len(stack)-1].End = lineStart
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+	return roots
+}
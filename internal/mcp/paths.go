@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// serverPath resolves rel, a path given by an MCP client, under base, a
+// directory the operator configured under the env var named by setting.
+// Clients may name only relative paths that stay inside base.
+func serverPath(base, setting, rel string) (string, error) {
+	if base == "" {
+		return "", fmt.Errorf("server paths are disabled; set %s to allow them", setting)
+	}
+	if rel == "" {
+		rel = "."
+	}
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("path %q must be relative to %s and stay inside it", rel, setting)
+	}
+	return filepath.Join(base, rel), nil
+}
+
+// insideDir reports whether path lies within dir.
+func insideDir(dir, path string) bool {
+	if dir == "" {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// fileSafe replaces every character of s that is not a letter, digit, dot,
+// dash, or underscore, so it can be used as part of a file name.
+func fileSafe(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+	if strings.Trim(s, ".") == "" {
+		return "_" + s
+	}
+	return s
+}
@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// ProjectDiff is how a live project differs from a backup of it. Memories
+// are named topic/key, sessions by number, and files by path.
+type ProjectDiff struct {
+	ProjectID string     `json:"project_id"`
+	BackupAt  string     `json:"backup_created_at"`
+	Memories  EntityDiff `json:"memories"`
+	Sessions  EntityDiff `json:"sessions"`
+	Files     EntityDiff `json:"files"`
+}
+
+// EntityDiff lists the rows added since the backup, removed since it, and
+// present in both but changed.
+type EntityDiff struct {
+	Added    []string     `json:"added"`
+	Removed  []string     `json:"removed"`
+	Modified []DiffChange `json:"modified"`
+}
+
+// DiffChange is one modified row: the fields that differ and, for its
+// text, a diffSummary from the backup to the live value.
+type DiffChange struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+	Diff   string   `json:"diff,omitempty"`
+}
+
+// Changes counts the rows that differ.
+func (d *ProjectDiff) Changes() int {
+	n := 0
+	for _, e := range []EntityDiff{d.Memories, d.Sessions, d.Files} {
+		n += len(e.Added) + len(e.Removed) + len(e.Modified)
+	}
+	return n
+}
+
+// DiffProject compares the live rows of projectID with snap, read from a
+// backup of it. Session content is compared too, so each session present
+// in both costs one read.
+func DiffProject(ctx context.Context, st store.Store, projectID string, snap *store.ProjectSnapshot) (*ProjectDiff, error) {
+	d := &ProjectDiff{ProjectID: projectID, BackupAt: snap.Manifest.CreatedAt.Format(time.RFC3339)}
+
+	memories, err := st.ListMemories(ctx, projectID, "")
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	old := map[string]store.Memory{}
+	for _, m := range snap.Memories {
+		old[m.Topic+"/"+m.Key] = m
+	}
+	for _, m := range memories {
+		name := m.Topic + "/" + m.Key
+		before, ok := old[name]
+		if !ok {
+			d.Memories.Added = append(d.Memories.Added, name)
+			continue
+		}
+		delete(old, name)
+		var fields []string
+		if before.Value != m.Value {
+			fields = append(fields, "value")
+		}
+		if before.Status != m.Status {
+			fields = append(fields, "status")
+		}
+		if !slices.Equal(before.Tags, m.Tags) {
+			fields = append(fields, "tags")
+		}
+		if fields != nil {
+			d.Memories.Modified = append(d.Memories.Modified, DiffChange{Name: name, Fields: fields, Diff: diffSummary(before.Value, m.Value)})
+		}
+	}
+	for name := range old {
+		d.Memories.Removed = append(d.Memories.Removed, name)
+	}
+
+	sessions, err := st.ListSessions(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	oldSessions := map[int]store.Session{}
+	for _, sess := range snap.Sessions {
+		oldSessions[sess.SessionNum] = sess
+	}
+	for _, listed := range sessions {
+		name := strconv.Itoa(listed.SessionNum)
+		before, ok := oldSessions[listed.SessionNum]
+		if !ok {
+			d.Sessions.Added = append(d.Sessions.Added, name)
+			continue
+		}
+		delete(oldSessions, listed.SessionNum)
+		sess, err := st.GetSession(ctx, projectID, listed.SessionNum)
+		if err != nil {
+			return nil, fmt.Errorf("get session %d: %w", listed.SessionNum, err)
+		}
+		if sess == nil {
+			continue // deleted since it was listed
+		}
+		var fields []string
+		if before.Title != sess.Title {
+			fields = append(fields, "title")
+		}
+		if before.Summary != sess.Summary {
+			fields = append(fields, "summary")
+		}
+		if before.Content != sess.Content {
+			fields = append(fields, "content")
+		}
+		if fields != nil {
+			d.Sessions.Modified = append(d.Sessions.Modified, DiffChange{Name: name, Fields: fields, Diff: diffSummary(before.Content, sess.Content)})
+		}
+	}
+	for num := range oldSessions {
+		d.Sessions.Removed = append(d.Sessions.Removed, strconv.Itoa(num))
+	}
+
+	files, err := st.ListFiles(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	oldFiles := map[string]store.FileEntry{}
+	for _, f := range snap.Files {
+		oldFiles[f.FilePath] = f
+	}
+	for _, f := range files {
+		before, ok := oldFiles[f.FilePath]
+		if !ok {
+			d.Files.Added = append(d.Files.Added, f.FilePath)
+			continue
+		}
+		delete(oldFiles, f.FilePath)
+		var fields []string
+		if before.FileType != f.FileType {
+			fields = append(fields, "file_type")
+		}
+		if before.Summary != f.Summary {
+			fields = append(fields, "summary")
+		}
+		a, _ := json.Marshal(before.Symbols)
+		b, _ := json.Marshal(f.Symbols)
+		if string(a) != string(b) {
+			fields = append(fields, "symbols")
+		}
+		if fields != nil {
+			d.Files.Modified = append(d.Files.Modified, DiffChange{Name: f.FilePath, Fields: fields, Diff: diffSummary(before.Summary, f.Summary)})
+		}
+	}
+	for path := range oldFiles {
+		d.Files.Removed = append(d.Files.Removed, path)
+	}
+
+	slices.Sort(d.Memories.Removed)
+	slices.SortFunc(d.Sessions.Removed, func(a, b string) int {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+	slices.Sort(d.Files.Removed)
+	return d, nil
+}
+
+func (s *Server) handleProjectDiff(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	path := stringArg(req, "backup_path")
+
+	if projectID == "" || path == "" {
+		return mcpsdk.NewToolResultError("project_id and backup_path are required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+
+	full, err := serverPath(s.opts.BackupDir, "BACKUP_DIR", path)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("backup_path: %v", err)), nil
+	}
+	f, err := store.OpenArchive(full)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("open backup: %v", err)), nil
+	}
+	snap, err := store.ReadProjectSnapshot(f, projectID)
+	f.Close()
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("read backup: %v", err)), nil
+	}
+
+	d, err := DiffProject(ctx, s.store, projectID, snap)
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	s.recordUsage(ctx, "project_diff", projectID, path, d.Changes())
+	data, _ := json.MarshalIndent(d, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultPruneWindow is how far back prune scans look by default.
+const DefaultPruneWindow = 30 * 24 * time.Hour
+
+// pruneMaxQueries caps the distinct past queries one prune scan replays;
+// the most often asked are kept.
+const pruneMaxQueries = 200
+
+// pruneReplayLimit is how many results each replayed search returns, the
+// memory_search default, so "returned" means what a caller would have seen.
+const pruneReplayLimit = 10
+
+// errPruneNoEmbeddings is returned by scanPruneCandidates when the
+// project's searches cannot be replayed semantically.
+var errPruneNoEmbeddings = errors.New("prune scans need semantic search; embeddings are not available for this project")
+
+// pruneEntry is a flagged memory in a prune_suggestions response.
+type pruneEntry struct {
+	Topic     string    `json:"topic"`
+	Key       string    `json:"key"`
+	Preview   string    `json:"preview"`
+	BestScore float64   `json:"best_score"`
+	UpdatedAt time.Time `json:"updated_at"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+func (s *Server) handlePruneSuggestions(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	refresh := boolArg(req, "refresh", false)
+	days := intArg(req, "window_days", 0)
+	limit := intArg(req, "limit", 50)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if days < 0 {
+		return mcpsdk.NewToolResultError("window_days must be a positive integer"), nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	if refresh {
+		window := time.Duration(days) * 24 * time.Hour
+		if window == 0 {
+			window = s.pruneWindow()
+		}
+		if _, err := s.scanPruneCandidates(ctx, projectID, window, s.pruneMinScore(ctx, req, projectID)); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("prune scan: %v", err)), nil
+		}
+	}
+	scan, candidates, err := s.store.GetPruneScan(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get prune report: %v", err)), nil
+	}
+	if scan == nil {
+		return mcpsdk.NewToolResultError("project has not been scanned yet; pass refresh=true or set PRUNE_SCAN_INTERVAL"), nil
+	}
+
+	total := len(candidates)
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	entries := make([]pruneEntry, len(candidates))
+	for i, c := range candidates {
+		entries[i] = pruneEntry{
+			Topic:     c.Topic,
+			Key:       c.Key,
+			Preview:   preview(c.Value, defaultPreviewChars),
+			BestScore: round3(c.BestScore),
+			UpdatedAt: c.UpdatedAt,
+			FlaggedAt: c.FlaggedAt,
+		}
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"scan":       scan,
+		"total":      total,
+		"candidates": entries,
+	}
+	if scan.Queries == 0 {
+		response["hint"] = "No memory searches were recorded in the window, so nothing was flagged."
+	} else {
+		response["hint"] = "Nothing has been deleted. Review each candidate: improve it with memory_set so searches find it, or remove it with memory_delete."
+	}
+	s.recordUsage(ctx, "prune_suggestions", projectID, "", total)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// pruneWindow is Options.PruneWindow, or DefaultPruneWindow.
+func (s *Server) pruneWindow() time.Duration {
+	if s.opts.PruneWindow > 0 {
+		return s.opts.PruneWindow
+	}
+	return DefaultPruneWindow
+}
+
+// pruneMinScore is the min_score argument or the project's tuned
+// threshold, else Options.PruneMinScore.
+func (s *Server) pruneMinScore(ctx context.Context, req mcpsdk.CallToolRequest, projectID string) float64 {
+	if v := s.memoryMinScore(ctx, req, projectID); v > 0 {
+		return v
+	}
+	return s.opts.PruneMinScore
+}
+
+// scanPruneCandidates replays the project's distinct memory searches from
+// the window and flags each published memory, unchanged for the whole
+// window, that none of them returned at minScore or above. The report
+// replaces the project's previous one.
+func (s *Server) scanPruneCandidates(ctx context.Context, projectID string, window time.Duration, minScore float64) (int, error) {
+	if !s.projectEmbeds(ctx, projectID) || !s.embedder(store.EntityMemory).Enabled() {
+		return 0, errPruneNoEmbeddings
+	}
+	since := time.Now().Add(-window)
+	queries, err := s.store.ListSearchQueries(ctx, projectID, store.MemorySearchTools, since, pruneMaxQueries)
+	if err != nil {
+		return 0, fmt.Errorf("list past queries: %w", err)
+	}
+
+	best := map[int64]float64{}
+	replayed := 0
+	for _, q := range queries {
+		emb := s.embedQueryFor(ctx, store.EntityMemory, projectID, q.QueryText)
+		if emb == nil {
+			continue
+		}
+		results, err := s.store.SearchMemories(ctx, projectID, q.QueryText, emb, pruneReplayLimit, false)
+		if err != nil {
+			return 0, fmt.Errorf("replay %q: %w", q.QueryText, err)
+		}
+		replayed++
+		for _, m := range results {
+			best[m.ID] = max(best[m.ID], m.Score)
+		}
+	}
+
+	// Without any replayed search there is no evidence against anything.
+	var candidates []store.PruneCandidate
+	if replayed > 0 {
+		memories, err := s.store.ListMemories(ctx, projectID, "")
+		if err != nil {
+			return 0, fmt.Errorf("list memories: %w", err)
+		}
+		for _, m := range memories {
+			if m.Status == store.MemoryDraft || !m.UpdatedAt.Before(since) || best[m.ID] >= minScore {
+				continue
+			}
+			candidates = append(candidates, store.PruneCandidate{Memory: m, BestScore: best[m.ID]})
+		}
+	}
+
+	scan := &store.PruneScan{ProjectID: projectID, WindowStart: since, Queries: replayed, MinScore: minScore}
+	if err := s.store.SavePruneScan(ctx, scan, candidates); err != nil {
+		return 0, fmt.Errorf("save prune report: %w", err)
+	}
+	return len(candidates), nil
+}
+
+// RunPruneScanner rescans every embedded project for prune candidates
+// each interval, starting one interval after startup, until ctx is done.
+func (s *Server) RunPruneScanner(ctx context.Context, interval time.Duration) {
+	if !s.embedder(store.EntityMemory).Enabled() {
+		slog.Warn("prune scanner disabled: memory embeddings are not configured")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		projects, err := s.store.ListProjects(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("prune scan failed", "error", err)
+			continue
+		}
+		for _, p := range projects {
+			if !p.EmbeddingsEnabled() {
+				continue
+			}
+			n, err := s.scanPruneCandidates(ctx, p.ID, s.pruneWindow(), s.pruneMinScore(ctx, mcpsdk.CallToolRequest{}, p.ID))
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("prune scan failed", "project", p.ID, "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("prune scan", "project", p.ID, "candidates", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleMemoryReassign moves memories to another project, chosen by topic,
+// by topic/key, or by a semantic query. Without confirm it only lists the
+// selection.
+func (s *Server) handleMemoryReassign(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	dest := stringArg(req, "to_project_id")
+	query := stringArg(req, "query")
+	limit := intArg(req, "limit", 50)
+	onConflict := stringArg(req, "on_conflict")
+	confirm := boolArg(req, "confirm", false)
+
+	if projectID == "" || dest == "" {
+		return mcpsdk.NewToolResultError("project_id and to_project_id are required"), nil
+	}
+	if dest == projectID {
+		return mcpsdk.NewToolResultError("to_project_id must differ from project_id"), nil
+	}
+	if !access.FromContext(ctx).Allows(dest) {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("access denied: this token may not access project %q", dest)), nil
+	}
+	if onConflict != "" && onConflict != "skip" && onConflict != "overwrite" {
+		return mcpsdk.NewToolResultError("on_conflict must be skip or overwrite"), nil
+	}
+	topics, err := stringListArg(req, "topics")
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	keys, err := stringListArg(req, "keys")
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	if len(topics) == 0 && len(keys) == 0 && query == "" {
+		return mcpsdk.NewToolResultError("give topics, keys, or query to choose the memories to move"), nil
+	}
+
+	if err := s.ensureProject(ctx, dest); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("register project: %v", err)), nil
+	}
+	if p, err := s.store.GetProject(ctx, dest); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	} else if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", dest)), nil
+	}
+
+	selected := map[int64]store.Memory{}
+	if len(topics) > 0 || len(keys) > 0 {
+		memories, err := s.store.ListMemories(ctx, projectID, "")
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+		}
+		byTopic := map[string]bool{}
+		for _, t := range topics {
+			byTopic[t] = true
+		}
+		byKey := map[string]bool{}
+		for _, k := range keys {
+			byKey[k] = true
+		}
+		for _, m := range memories {
+			if byTopic[m.Topic] || byKey[m.Topic+"/"+m.Key] {
+				selected[m.ID] = m
+			}
+		}
+	}
+	if query != "" {
+		minScore := s.memoryMinScore(ctx, req, projectID)
+		if minScore <= 0 {
+			return mcpsdk.NewToolResultError("min_score is required with query (or tune a project default with tune_threshold)"), nil
+		}
+		emb := s.embedQueryFor(ctx, store.EntityMemory, projectID, query)
+		if emb == nil {
+			return mcpsdk.NewToolResultError("query needs semantic search; embeddings are not available for this project"), nil
+		}
+		results, err := s.store.SearchMemories(ctx, projectID, query, emb, limit, true)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+		}
+		for _, m := range results {
+			if m.Score < minScore {
+				break // results are ordered by similarity
+			}
+			selected[m.ID] = m
+		}
+	}
+
+	ids := make([]int64, 0, len(selected))
+	names := make([]string, 0, len(selected))
+	for id, m := range selected {
+		ids = append(ids, id)
+		names = append(names, m.Topic+"/"+m.Key)
+	}
+	response := map[string]any{
+		"project_id":    projectID,
+		"to_project_id": dest,
+		"confirmed":     confirm,
+		"count":         len(ids),
+	}
+	if !confirm || len(ids) == 0 {
+		sort.Strings(names)
+		response["keys"] = names
+		s.recordUsage(ctx, "memory_reassign", projectID, dest, len(ids))
+		data, _ := json.MarshalIndent(response, "", "  ")
+		return mcpsdk.NewToolResultText(string(data)), nil
+	}
+
+	result, err := s.store.ReassignMemories(ctx, projectID, dest, ids, onConflict == "overwrite")
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("reassign memories: %v", err)), nil
+	}
+
+	// Vectors move with the memories; only those missing or from another
+	// model are embedded again, for the destination's settings.
+	reembedded := 0
+	var embedErrs []string
+	for _, id := range result.Unembedded {
+		m := selected[id]
+		emb, err := s.embedFor(ctx, store.EntityMemory, dest, m.EmbedText(s.opts.MemoryEmbedKey))
+		if err == nil && emb != nil {
+			err = s.store.SetEmbedding(ctx, store.EntityMemory, id, emb)
+		}
+		if err != nil {
+			embedErrs = append(embedErrs, fmt.Sprintf("%s/%s: %v", m.Topic, m.Key, err))
+			continue
+		}
+		if emb != nil {
+			reembedded++
+		}
+	}
+
+	moved := make([]string, len(result.Moved))
+	for i, m := range result.Moved {
+		moved[i] = m.Topic + "/" + m.Key
+	}
+	conflicts := make([]string, len(result.Conflicts))
+	for i, m := range result.Conflicts {
+		conflicts[i] = m.Topic + "/" + m.Key
+	}
+	response["moved"] = len(moved)
+	response["moved_keys"] = moved
+	response["conflicts"] = conflicts
+	response["overwritten"] = result.Overwritten
+	response["reembedded"] = reembedded
+	if len(embedErrs) > 0 {
+		response["embed_errors"] = embedErrs
+	}
+	s.recordUsage(ctx, "memory_reassign", projectID, dest, len(moved))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// stringListArg reads a list argument given as a JSON array of strings or
+// as comma-separated text. A missing argument is an empty list.
+func stringListArg(req mcpsdk.CallToolRequest, name string) ([]string, error) {
+	raw, ok := req.Params.Arguments[name]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	text, isString := raw.(string)
+	if !isString {
+		b, _ := json.Marshal(raw)
+		text = string(b)
+	}
+	text = strings.TrimSpace(text)
+	var list []string
+	if strings.HasPrefix(text, "[") {
+		if err := json.Unmarshal([]byte(text), &list); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON array of strings or a comma-separated list: %v", name, err)
+		}
+	} else {
+		list = strings.Split(text, ",")
+	}
+	out := list[:0]
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/extract"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+func (s *Server) handleFileRefresh(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	path := stringArg(req, "path")
+	prefix := stringArg(req, "prefix")
+
+	if projectID == "" || (path == "" && prefix == "") {
+		return mcpsdk.NewToolResultError("project_id and one of path or prefix are required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	if p.RootPath == "" {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' has no root_path; re-register it with one", projectID)), nil
+	}
+	root, err := filepath.Abs(p.RootPath)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("resolve root_path: %v", err)), nil
+	}
+
+	files, err := s.store.ListFiles(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list files: %v", err)), nil
+	}
+
+	var changed, missing []string
+	var unchanged int
+	errs := map[string]string{}
+	for _, f := range files {
+		if path != "" && f.FilePath != path {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(f.FilePath, prefix) {
+			continue
+		}
+
+		abs := filepath.Join(root, f.FilePath)
+		if rel, err := filepath.Rel(root, abs); err != nil || strings.HasPrefix(rel, "..") {
+			errs[f.FilePath] = "path escapes project root"
+			continue
+		}
+		src, err := os.ReadFile(abs)
+		if os.IsNotExist(err) {
+			missing = append(missing, f.FilePath)
+			continue
+		}
+		if err != nil {
+			errs[f.FilePath] = err.Error()
+			continue
+		}
+
+		summary, symbols, fileType := extract.File(f.FilePath, src)
+		if f.FileType != "" {
+			fileType = f.FileType
+		}
+		if summary == f.Summary && symbolsEqual(symbols, f.Symbols) {
+			unchanged++
+			continue
+		}
+
+		entry := &store.FileEntry{
+			ProjectID: projectID,
+			FilePath:  f.FilePath,
+			FileType:  fileType,
+			Summary:   summary,
+			Symbols:   symbols,
+		}
+		var emb []float32
+		if _, _, embed := s.filePolicy(fileType, f.FilePath); embed {
+			if emb, err = s.embedFor(ctx, store.EntityFile, projectID, entry.EmbedText(s.opts.FileEmbedPath)); err != nil {
+				errs[f.FilePath] = err.Error()
+				continue
+			}
+		}
+		if err := s.store.IndexFile(ctx, entry, emb); err != nil {
+			errs[f.FilePath] = err.Error()
+			continue
+		}
+		changed = append(changed, f.FilePath)
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"changed":    changed,
+		"unchanged":  unchanged,
+		"missing":    missing,
+	}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+	s.recordUsage(ctx, "file_refresh", projectID, path+prefix, len(changed))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// symbolsEqual treats nil and empty symbol lists as equal.
+func symbolsEqual(a, b []store.Symbol) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
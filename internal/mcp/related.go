@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// relatedProject is one related_projects suggestion.
+type relatedProject struct {
+	ProjectID  string  `json:"project_id"`
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"`
+}
+
+func (s *Server) handleRelatedProjects(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	limit := intArg(req, "limit", 5)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	centroids, err := s.store.ProjectCentroids(ctx)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project centroids: %v", err)), nil
+	}
+	seed, ok := centroids[projectID]
+	if !ok {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' has no embedded memories to compare", projectID)), nil
+	}
+	// ListProjects is filtered to the caller's access scope, so projects
+	// the token may not see are never suggested.
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list projects: %v", err)), nil
+	}
+
+	var related []relatedProject
+	for _, p := range projects {
+		c, ok := centroids[p.ID]
+		if !ok || p.ID == projectID || len(c) != len(seed) {
+			continue
+		}
+		related = append(related, relatedProject{
+			ProjectID:  p.ID,
+			Name:       p.Name,
+			Similarity: round3(embedding.Cosine(seed, c)),
+		})
+	}
+	sort.SliceStable(related, func(i, j int) bool { return related[i].Similarity > related[j].Similarity })
+	if len(related) > limit {
+		related = related[:limit]
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"related":    related,
+	}
+	s.recordUsage(ctx, "related_projects", projectID, "", len(related))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	"github.com/Platform-LSS/devmemory/internal/webhook"
+)
+
+// notifySearch posts a summary of a search to the results webhook, if
+// one is configured. Delivery is asynchronous and cannot fail the tool.
+func (s *Server) notifySearch(tool, projectID, query, searchType string, hits []webhook.Hit) {
+	if s.webhook == nil {
+		return
+	}
+	s.webhook.Send(webhook.SearchEvent{
+		Tool:       tool,
+		ProjectID:  projectID,
+		Query:      query,
+		SearchType: searchType,
+		Results:    hits,
+		Time:       time.Now().UTC(),
+	})
+}
+
+func memoryHits(results []store.Memory) []webhook.Hit {
+	hits := make([]webhook.Hit, 0, len(results))
+	for _, m := range results {
+		hits = append(hits, webhook.Hit{Entity: store.EntityMemory, ID: m.ID, Score: m.Score})
+	}
+	return hits
+}
+
+func sessionHits(results []store.Session) []webhook.Hit {
+	hits := make([]webhook.Hit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, webhook.Hit{Entity: store.EntitySession, ID: r.ID, Score: r.Score})
+	}
+	return hits
+}
+
+func fileHits(results []store.FileEntry) []webhook.Hit {
+	hits := make([]webhook.Hit, 0, len(results))
+	for _, f := range results {
+		hits = append(hits, webhook.Hit{Entity: store.EntityFile, ID: f.ID, Score: f.Score})
+	}
+	return hits
+}
@@ -3,12 +3,17 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Platform-LSS/devmemory/internal/access"
 	"github.com/Platform-LSS/devmemory/internal/embedding"
 	"github.com/Platform-LSS/devmemory/internal/store"
+	"github.com/Platform-LSS/devmemory/internal/webhook"
 	mcpsdk "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -18,36 +23,194 @@ type EventPublisher interface {
 	Publish(event string)
 }
 
+// Options tunes tool behaviour. The zero value is not the default;
+// use DefaultOptions and override fields.
+type Options struct {
+	// FileEmbedPath includes the file path and type in the embedded text
+	// for file_index, not just the summary.
+	FileEmbedPath bool
+
+	// MemoryEmbedKey includes the topic and key in the embedded text for
+	// memory_set, not just the value.
+	MemoryEmbedKey bool
+
+	// EmbedUsageQueries stores the query embedding of search tools in
+	// usage_stats so usage_search can find similar past questions.
+	EmbedUsageQueries bool
+
+	// FileTypes is the allowlist of types file_index accepts, matched
+	// against file_type or the path's extension. Empty accepts all.
+	FileTypes []string
+
+	// PathOnlyFileTypes are indexed by path without an embedding.
+	PathOnlyFileTypes []string
+
+	// AutoCreateProject registers an unknown project_id on the first
+	// memory_set, session_create, or file_index instead of failing.
+	AutoCreateProject bool
+
+	// SearchTimeout bounds each memory, session, and file search query.
+	// Zero leaves only the server's statement_timeout, if any.
+	SearchTimeout time.Duration
+
+	// TopicInferenceThreshold makes memory_set infer a missing or generic
+	// topic: the value goes to the existing topic whose centroid is at
+	// least this similar, else to the catch-all topic. Zero disables it.
+	TopicInferenceThreshold float64
+
+	// CatchAllTopic is where memory_set files values with an empty topic
+	// or one of GenericTopics, for re-topicing via memory_uncategorized.
+	// Empty means DefaultCatchAllTopic.
+	CatchAllTopic string
+
+	// GenericTopics are topic names that say nothing (misc, temp) and are
+	// treated like an empty topic.
+	GenericTopics []string
+
+	// ReembedUnchanged makes memory_set embed a value again even when it
+	// equals the stored one. By default the stored vector is kept.
+	ReembedUnchanged bool
+
+	// MaxEmbeddingElements caps how many components of a vector a tool
+	// result includes unless the caller passes full=true. Zero never
+	// truncates.
+	MaxEmbeddingElements int
+
+	// DuplicateSessionTitles is what session_create does when another
+	// session of the project has the same title: DuplicateTitlesWarn
+	// (the default when empty), DuplicateTitlesReject, or
+	// DuplicateTitlesAllow.
+	DuplicateSessionTitles string
+
+	// VerboseTiming adds a timing object (total, embedding, and database
+	// time) to every tool result.
+	VerboseTiming bool
+
+	// ArchiveDir is the only directory archive_sessions may export to and
+	// session_restore may read from; clients name paths relative to it.
+	// Empty disables file exports.
+	ArchiveDir string
+
+	// BackupDir is the only directory project_diff may read backups from;
+	// clients name paths relative to it. Empty disables the tool; the
+	// --project-diff flag still reads any path.
+	BackupDir string
+
+	// PruneWindow is how far back prune scans replay memory searches;
+	// memories written within it are never flagged. Zero means
+	// DefaultPruneWindow.
+	PruneWindow time.Duration
+
+	// PruneMinScore is the score a memory must reach in some replayed
+	// search to escape flagging, unless the project has a tuned min_score.
+	PruneMinScore float64
+}
+
+// DefaultMaxEmbeddingElements is the default Options.MaxEmbeddingElements.
+const DefaultMaxEmbeddingElements = 8
+
+// DefaultOptions returns the options used when nothing is configured.
+func DefaultOptions() Options {
+	return Options{
+		FileEmbedPath:        true,
+		MemoryEmbedKey:       true,
+		FileTypes:            DefaultFileTypes,
+		PathOnlyFileTypes:    DefaultPathOnlyFileTypes,
+		MaxEmbeddingElements: DefaultMaxEmbeddingElements,
+		CatchAllTopic:        DefaultCatchAllTopic,
+		GenericTopics:        DefaultGenericTopics,
+	}
+}
+
 // Server wraps the MCP server with our store and embedding service.
 type Server struct {
 	mcp       *server.MCPServer
 	store     store.Store
 	embedding *embedding.Service
 	events    EventPublisher
+	opts      Options
+	queue     *store.WriteQueue // nil unless WRITE_QUEUE_ENABLED
+
+	// entityEmbedding overrides embedding for one entity type.
+	entityEmbedding map[string]*embedding.Service
+
+	webhook *webhook.Notifier // nil unless RESULTS_WEBHOOK_URL
 }
 
 // New creates a new MCP server with all tools registered.
-func New(s store.Store, emb *embedding.Service) *Server {
+func New(s store.Store, emb *embedding.Service, opts Options) *Server {
 	srv := &Server{
 		store:     s,
 		embedding: emb,
+		opts:      opts,
 	}
 
-	srv.mcp = server.NewMCPServer(
-		"devmemory",
-		"1.0.0",
+	serverOpts := []server.ServerOption{
 		server.WithToolCapabilities(true),
-	)
+		server.WithToolHandlerMiddleware(srv.checkAccess),
+	}
+	if opts.VerboseTiming {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(timeTool))
+	}
+	srv.mcp = server.NewMCPServer("devmemory", "1.0.0", serverOpts...)
 
 	srv.registerTools()
 	return srv
 }
 
+// allProjectTools act on every project at once, so only an unrestricted
+// token may call them.
+var allProjectTools = map[string]bool{"maintenance_orphans": true, "warm_caches": true}
+
+// checkAccess rejects tool calls for projects outside the access scope
+// of the request (see access.Policy). project_list, all_projects_status,
+// and search_all are filtered by the store instead.
+func (s *Server) checkAccess(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		scope := access.FromContext(ctx)
+		if scope.Unrestricted() {
+			return next(ctx, req)
+		}
+		name := req.Params.Name
+		projectID := stringArg(req, "project_id")
+		if name == "project_register" {
+			projectID = stringArg(req, "id")
+		}
+		if allProjectTools[name] || (name == "usage_search" && projectID == "") {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("access denied: %s needs a token with access to all projects", name)), nil
+		}
+		if projectID != "" && !scope.Allows(projectID) {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("access denied: this token may not access project %q", projectID)), nil
+		}
+		return next(ctx, req)
+	}
+}
+
 // SetEvents wires an optional event publisher for real-time dashboard updates.
 func (s *Server) SetEvents(ep EventPublisher) {
 	s.events = ep
 }
 
+// SetWriteQueue enables buffering memory_set writes while the database is
+// unreachable.
+func (s *Server) SetWriteQueue(q *store.WriteQueue) {
+	s.queue = q
+}
+
+// SetResultsWebhook posts a summary of every search to n.
+func (s *Server) SetResultsWebhook(n *webhook.Notifier) {
+	s.webhook = n
+}
+
+// SetEntityEmbedding makes entity (store.EntityMemory, EntitySession or
+// EntityFile) embed with emb instead of the shared service.
+func (s *Server) SetEntityEmbedding(entity string, emb *embedding.Service) {
+	if s.entityEmbedding == nil {
+		s.entityEmbedding = map[string]*embedding.Service{}
+	}
+	s.entityEmbedding[entity] = emb
+}
+
 // MCPServer returns the underlying MCP server for transport binding.
 func (s *Server) MCPServer() *server.MCPServer {
 	return s.mcp
@@ -56,12 +219,14 @@ func (s *Server) MCPServer() *server.MCPServer {
 // tokenEstimate returns a heuristic token count for a tool call.
 func tokenEstimate(toolName string, resultsCount int) int {
 	switch toolName {
-	case "memory_search":
+	case "memory_search", "memory_search_vector":
 		return resultsCount * 500
 	case "session_search":
 		return resultsCount * 2000
 	case "file_search":
 		return resultsCount * 800
+	case "search_all", "file_context":
+		return resultsCount * 1000
 	default:
 		return 100
 	}
@@ -69,6 +234,10 @@ func tokenEstimate(toolName string, resultsCount int) int {
 
 // recordUsage logs a tool invocation and publishes an SSE event.
 func (s *Server) recordUsage(ctx context.Context, toolName, projectID, query string, resultsCount int) {
+	s.recordUsageStat(ctx, toolName, projectID, query, resultsCount, nil)
+}
+
+func (s *Server) recordUsageStat(ctx context.Context, toolName, projectID, query string, resultsCount int, queryEmb store.Vector) {
 	tokens := tokenEstimate(toolName, resultsCount)
 	if err := s.store.RecordUsage(ctx, &store.UsageStat{
 		ProjectID:       projectID,
@@ -76,6 +245,7 @@ func (s *Server) recordUsage(ctx context.Context, toolName, projectID, query str
 		QueryText:       query,
 		ResultsCount:    resultsCount,
 		TokensEstimated: tokens,
+		QueryEmbedding:  queryEmb,
 	}); err != nil {
 		slog.Warn("record usage", "error", err)
 	}
@@ -111,28 +281,134 @@ func (s *Server) registerTools() {
 		s.handleProjectStatus,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("all_projects_status",
+			mcpsdk.WithDescription("Get every project's memory, session, and file counts plus how many rows lack embeddings, in one call. Useful before and after migrations or embedding model switches."),
+		),
+		s.handleAllProjectsStatus,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("embedding_coverage",
+			mcpsdk.WithDescription("Get the fraction of a project's memories, sessions, and files that have an embedding of the current model, i.e. are reachable by semantic search. Low coverage means a reindex is due."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+		),
+		s.handleEmbeddingCoverage,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("project_footprint",
+			mcpsdk.WithDescription("Get how many bytes a project takes: memory values, session content, file summaries and symbols, and stored embeddings. Use it to see what dominates storage before pruning or changing embedding settings."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+		),
+		s.handleProjectFootprint,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("related_projects",
+			mcpsdk.WithDescription("Suggest projects whose memories cover similar ground, ranked by cosine similarity of each project's memory embedding centroid. Useful for finding where knowledge from another repo might apply."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max projects to return (default 5)")),
+		),
+		s.handleRelatedProjects,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("project_digest",
+			mcpsdk.WithDescription("Write a Markdown \"state of the project\" overview to the memory project/digest: the newest published memories of each priming topic (or of the largest topics) and the latest sessions, each summarized to a line. Re-running replaces it; read it with memory_get at session start."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("per_topic", mcpsdk.Description("Memories per topic (default 3)")),
+			mcpsdk.WithString("sessions", mcpsdk.Description("Latest sessions to include (default 5)")),
+		),
+		s.handleProjectDigest,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("project_update",
+			mcpsdk.WithDescription("Update a project's name, root path, retention policy, key versioning, or embedding. Retention is in days; 0 removes the policy (retain forever)"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("name", mcpsdk.Description("New project name")),
+			mcpsdk.WithString("root_path", mcpsdk.Description("New filesystem root path")),
+			mcpsdk.WithString("session_retention_days", mcpsdk.Description("Delete sessions older than this many days")),
+			mcpsdk.WithString("memory_retention_days", mcpsdk.Description("Delete memories not updated for this many days")),
+			mcpsdk.WithString("auto_version_keys", mcpsdk.Description("true: memory_set on an existing key writes key@2, key@3, ... instead of overwriting; false: overwrite (default)")),
+			mcpsdk.WithString("embeddings_enabled", mcpsdk.Description("false: never embed this project's memories, sessions, or files and search it by keyword only; true: embed (default)")),
+			mcpsdk.WithString("priming_topics", mcpsdk.Description("Comma-separated topics memory_manifest lists first, in order; empty clears")),
+		),
+		s.handleProjectUpdate,
+	)
+
 	// --- Memory tools ---
 	s.mcp.AddTool(
 		mcpsdk.NewTool("memory_set",
-			mcpsdk.WithDescription("Store or update a memory entry. Generates embedding for semantic search."),
+			mcpsdk.WithDescription("Store or update a memory entry. Generates embedding for semantic search. New and updated memories are drafts until published with memory_publish."),
 			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
-			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Memory topic (e.g. 'architecture', 'lesson', 'preference')")),
-			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key within topic")),
+			mcpsdk.WithString("topic", mcpsdk.Description("Memory topic (e.g. 'architecture', 'lesson', 'preference'). An empty or generic topic (misc, temp) files the memory under the server's catch-all topic, or with topic inference enabled under the nearest existing topic; the topic used is returned")),
+			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key within topic. In projects with auto_version_keys, an existing key gets a new version key@N instead of being overwritten")),
 			mcpsdk.WithString("value", mcpsdk.Required(), mcpsdk.Description("Memory value (text content)")),
+			mcpsdk.WithString("if_absent", mcpsdk.Description("Only create the memory; if the key already exists (any version, with auto_version_keys) leave it untouched and report it skipped (default false)")),
 		),
 		s.handleMemorySet,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_uncategorized",
+			mcpsdk.WithDescription("List memories filed under the catch-all topic because they were stored without a topic or with a generic one (misc, temp), newest first, each with the nearest real topic when embeddings allow, so they can be re-topiced"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max memories to list (default 50)")),
+		),
+		s.handleMemoryUncategorized,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("topic_schema_set",
+			mcpsdk.WithDescription("Require every memory_set value in a topic to be JSON matching a JSON Schema (type, properties, required, additionalProperties, items, enum, const, min/max bounds, pattern). Writes that do not match are rejected with the failing field. Reports existing memories of the topic that do not match. An empty schema makes the topic free text again."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Memory topic")),
+			mcpsdk.WithString("schema", mcpsdk.Description("JSON Schema as a JSON object; empty removes the topic's schema")),
+		),
+		s.handleTopicSchemaSet,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("topic_usage",
+			mcpsdk.WithDescription("List a project's topics with their memory and draft counts. Topics named in project settings (priming_topics, topic_schemas) that no longer hold any memory are flagged empty; prune=true removes them from those settings."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("prune", mcpsdk.Description("Remove empty topics from priming_topics and topic_schemas (default false)")),
+		),
+		s.handleTopicUsage,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("memory_get",
 			mcpsdk.WithDescription("Get a specific memory by topic and key"),
 			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
 			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Memory topic")),
-			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key")),
+			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key; key@latest resolves to the newest of key, key@2, key@3, ...")),
 		),
 		s.handleMemoryGet,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_export_markdown",
+			mcpsdk.WithDescription("Export a project's memories as one Markdown document grouped by topic, with author and timestamps per entry"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic", mcpsdk.Description("Only export this topic")),
+		),
+		s.handleMemoryExportMarkdown,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_embedding",
+			mcpsdk.WithDescription("Get the stored embedding vector of a memory for client-side similarity. Returns the dimension and a preview of the first MCP_MAX_EMBEDDING_ELEMENTS components (truncated=true) unless full=true, since full vectors are large."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Memory topic")),
+			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key")),
+			mcpsdk.WithString("full", mcpsdk.Description("Return the whole vector (default false)")),
+		),
+		s.handleMemoryEmbedding,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("memory_list",
 			mcpsdk.WithDescription("List memories for a project, optionally filtered by topic"),
@@ -142,16 +418,198 @@ func (s *Server) registerTools() {
 		s.handleMemoryList,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_manifest",
+			mcpsdk.WithDescription("Compact catalog of a project's memories for session start: per topic, each key with a one-line preview and last update, no full values. Use it to pick which memories to fetch with memory_get."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("order", mcpsdk.Description("Topic order: 'priming' (the project's priming_topics first, then by recency) or 'recency' (most recently updated first). Default: priming if the project has priming_topics, else recency")),
+			mcpsdk.WithString("preview_chars", mcpsdk.Description(fmt.Sprintf("Preview length in characters (default %d)", defaultPreviewChars))),
+		),
+		s.handleMemoryManifest,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("memory_search",
 			mcpsdk.WithDescription("Semantic search over project memories. Uses vector similarity if embeddings are enabled, otherwise full-text search."),
 			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
 			mcpsdk.WithString("query", mcpsdk.Required(), mcpsdk.Description("Search query text")),
 			mcpsdk.WithString("limit", mcpsdk.Description("Max results (default 10)")),
+			mcpsdk.WithString("include_drafts", mcpsdk.Description("Include unreviewed draft memories: true or false (default false)")),
+			mcpsdk.WithString("min_score", mcpsdk.Description("Drop semantic results below this similarity, 0-1 (default: the project's tuned value from tune_threshold, else none)")),
+			mcpsdk.WithString("include_projects", mcpsdk.Description(`Also search these projects: a JSON array of project ids, e.g. ["api","web"], or * for every project. Results are merged by score; each carries its project_id (default: project_id only)`)),
 		),
 		s.handleMemorySearch,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_search_vector",
+			mcpsdk.WithDescription("Search project memories with an embedding the client computed itself, skipping the server's embedding call. The vector must come from the same model as the stored memory embeddings and have their dimension."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("vector", mcpsdk.Required(), mcpsdk.Description("Query embedding as a JSON array of numbers, e.g. [0.12, -0.03, ...]")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max results (default 10)")),
+			mcpsdk.WithString("include_drafts", mcpsdk.Description("Include unreviewed draft memories: true or false (default false)")),
+			mcpsdk.WithString("min_score", mcpsdk.Description("Drop results below this similarity, 0-1 (default: the project's tuned value from tune_threshold, else none)")),
+		),
+		s.handleMemorySearchVector,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_search_advanced",
+			mcpsdk.WithDescription("Full-text memory search with a raw Postgres tsquery for exact boolean and phrase control; never semantic. Operators: & (and), | (or), ! (not), <-> (followed by), :* (prefix), parentheses. Example: 'tabs & !spaces' or 'deploy <-> script'."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("tsquery", mcpsdk.Required(), mcpsdk.Description("Query in to_tsquery syntax; terms are stemmed with the english configuration")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max results (default 10)")),
+			mcpsdk.WithString("include_drafts", mcpsdk.Description("Include unreviewed draft memories: true or false (default false)")),
+		),
+		s.handleMemorySearchAdvanced,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_changes",
+			mcpsdk.WithDescription("Incremental sync: memories created or updated, and memories deleted, since a cursor. Returns a new cursor to pass as since on the next call. Delivery is at-least-once: changes from the last minute, and those at the cursor, may be returned again, so apply them by memory id; a renamed topic shows up as a change to the same id. Tags added by memory_bulk_tag or conflict flagging do not count as changes."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("since", mcpsdk.Description("Cursor from the previous call (RFC3339 timestamp); omit for everything")),
+		),
+		s.handleMemoryChanges,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("project_diff",
+			mcpsdk.WithDescription("Compare a project with a backup archive (written by the backup command): memories (topic/key), sessions (number), and files (path) added, removed, or modified since the backup, with a line diff of each changed value. Use it to review drift or audit what changed over a period."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("backup_path", mcpsdk.Required(), mcpsdk.Description("Path of the backup archive (.jsonl or .jsonl.gz), relative to the server's BACKUP_DIR")),
+		),
+		s.handleProjectDiff,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("tune_threshold",
+			mcpsdk.WithDescription("Find the memory_search min_score that maximizes F1 on labeled queries for this project's embedding model, and save it as the project default"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("pairs", mcpsdk.Required(), mcpsdk.Description(`JSON array of labeled queries, e.g. [{"query":"how do we deploy","expected":["ops/deploy","ops/ci"]}]`)),
+			mcpsdk.WithString("candidates", mcpsdk.Description("Results retrieved per query before filtering (default 20)")),
+			mcpsdk.WithString("save", mcpsdk.Description("Store the recommended threshold in project metadata (default true)")),
+		),
+		s.handleTuneThreshold,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_bulk_tag",
+			mcpsdk.WithDescription("Tag every memory semantically matching a query at or above min_score. Lists the matches; with confirm=true, applies the tag and returns the affected keys."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("query", mcpsdk.Required(), mcpsdk.Description("What the memories to tag are about, e.g. 'authentication'")),
+			mcpsdk.WithString("tag", mcpsdk.Required(), mcpsdk.Description("Tag to apply, e.g. 'security'")),
+			mcpsdk.WithString("min_score", mcpsdk.Description("Minimum similarity, 0-1 (default: the project's tuned value from tune_threshold)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Maximum memories to consider (default 50)")),
+			mcpsdk.WithString("confirm", mcpsdk.Description("Apply the tag: true or false (default false, list only)")),
+		),
+		s.handleMemoryBulkTag,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_publish",
+			mcpsdk.WithDescription("Publish a reviewed draft memory so it appears in memory_search"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Memory topic")),
+			mcpsdk.WithString("key", mcpsdk.Required(), mcpsdk.Description("Memory key")),
+		),
+		s.handleMemoryPublish,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_suggest_merges",
+			mcpsdk.WithDescription("Find overlapping topics by comparing the centroid embedding of each topic's memories and suggest merges. With apply=true, merges each group into its largest topic (keys that already exist in the target are left in place)."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("threshold", mcpsdk.Description("Minimum centroid cosine similarity to merge, 0-1 (default 0.85)")),
+			mcpsdk.WithString("apply", mcpsdk.Description("Apply the suggested merges: true or false (default false)")),
+		),
+		s.handleMemorySuggestMerges,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_merge_keys",
+			mcpsdk.WithDescription("Merge one memory key into another of the same topic: the target gets the combined value (or the source's value with strategy=replace), a new embedding, and both memories' tags, then the source is deleted. The target keeps its created_at."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic", mcpsdk.Required(), mcpsdk.Description("Topic of both memories")),
+			mcpsdk.WithString("source_key", mcpsdk.Required(), mcpsdk.Description("Key to merge and delete")),
+			mcpsdk.WithString("target_key", mcpsdk.Required(), mcpsdk.Description("Key to keep")),
+			mcpsdk.WithString("strategy", mcpsdk.Description("concat appends the source value to the target's; replace uses the source value (default concat)")),
+		),
+		s.handleMemoryMergeKeys,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_reassign",
+			mcpsdk.WithDescription("Move memories to another project, e.g. when splitting a monorepo: those in the given topics, the given topic/key pairs, and/or those semantically matching a query at or above min_score. Lists the selection; with confirm=true, moves it and returns the count moved. Vectors move along and are only recomputed when missing or from another model. A key the destination already has is left in place unless on_conflict=overwrite."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Source project")),
+			mcpsdk.WithString("to_project_id", mcpsdk.Required(), mcpsdk.Description("Destination project")),
+			mcpsdk.WithString("topics", mcpsdk.Description(`Topics to move whole: JSON array or comma-separated, e.g. ["frontend","ui"]`)),
+			mcpsdk.WithString("keys", mcpsdk.Description(`Memories to move as topic/key: JSON array or comma-separated, e.g. ["architecture/web-stack"]`)),
+			mcpsdk.WithString("query", mcpsdk.Description("Also move memories semantically matching this query")),
+			mcpsdk.WithString("min_score", mcpsdk.Description("Minimum similarity for query, 0-1 (default: the project's tuned value from tune_threshold)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Maximum query matches to consider (default 50)")),
+			mcpsdk.WithString("on_conflict", mcpsdk.Description("skip leaves a memory whose topic/key the destination has in the source; overwrite replaces the destination's (default skip)")),
+			mcpsdk.WithString("confirm", mcpsdk.Description("Move the memories: true or false (default false, list only)")),
+		),
+		s.handleMemoryReassign,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_islands",
+			mcpsdk.WithDescription("List memories disconnected from current work: not updated and not read via memory_get within the last N days, least recently updated first. Candidates to link, review, or remove."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("days", mcpsdk.Description("Idle window in days (default 90)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max memories to return (default 50, 0 = all)")),
+		),
+		s.handleMemoryIslands,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("prune_suggestions",
+			mcpsdk.WithDescription("List memories that past searches never surface: the project's memory searches from the window are replayed, and published memories unchanged for the whole window that no replayed search returned at min_score or above are flagged. Nothing is deleted; the report is refreshed every PRUNE_SCAN_INTERVAL or on refresh=true."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("refresh", mcpsdk.Description("true: replay the searches now instead of reading the last report (default false)")),
+			mcpsdk.WithString("window_days", mcpsdk.Description("With refresh, days of searches to replay (default PRUNE_WINDOW)")),
+			mcpsdk.WithString("min_score", mcpsdk.Description("With refresh, score a memory must reach to stay unflagged (default: the project's tuned threshold, else PRUNE_MIN_SCORE)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max candidates to return (default 50)")),
+		),
+		s.handlePruneSuggestions,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_clusters",
+			mcpsdk.WithDescription("Group a project's memories into k clusters by embedding similarity (k-means). Returns each cluster's members, the topics they come from, and the member closest to the centroid; clusters that cut across topics suggest a better organization."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("k", mcpsdk.Description("Number of clusters (default 5)")),
+			mcpsdk.WithString("max_memories", mcpsdk.Description("Memories to cluster; larger projects are randomly sampled (default 500, max 2000)")),
+		),
+		s.handleMemoryClusters,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_compare",
+			mcpsdk.WithDescription("Cosine similarity of two memories' embeddings, with both values side by side. A memory without a stored vector is embedded on the fly (not saved)."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("topic_a", mcpsdk.Required(), mcpsdk.Description("Topic of the first memory")),
+			mcpsdk.WithString("key_a", mcpsdk.Required(), mcpsdk.Description("Key of the first memory")),
+			mcpsdk.WithString("topic_b", mcpsdk.Required(), mcpsdk.Description("Topic of the second memory")),
+			mcpsdk.WithString("key_b", mcpsdk.Required(), mcpsdk.Description("Key of the second memory")),
+		),
+		s.handleMemoryCompare,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("memory_conflicts",
+			mcpsdk.WithDescription("Find pairs of memories that are about the same thing (high embedding similarity) but say different things (low word overlap), with a short diff of each pair. With flag=true, tags both memories of every pair 'conflict' for review."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("similarity", mcpsdk.Description("Minimum embedding similarity, 0-1 (default 0.85)")),
+			mcpsdk.WithString("max_overlap", mcpsdk.Description("Maximum word overlap of the values, 0-1; more similar values are duplicates, not conflicts (default 0.5)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max pairs to return (default 20, 0 = all)")),
+			mcpsdk.WithString("flag", mcpsdk.Description("Tag the memories of each pair 'conflict': true or false (default false)")),
+		),
+		s.handleMemoryConflicts,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("memory_delete",
 			mcpsdk.WithDescription("Delete a specific memory entry"),
@@ -165,12 +623,13 @@ func (s *Server) registerTools() {
 	// --- Session tools ---
 	s.mcp.AddTool(
 		mcpsdk.NewTool("session_create",
-			mcpsdk.WithDescription("Create or update a session transcript. Generates embedding from summary for semantic search."),
+			mcpsdk.WithDescription("Create or update a session transcript. Generates embedding from summary for semantic search. A title another session already has is reported (or rejected, per server config) with that session's number."),
 			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
 			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number (integer)")),
 			mcpsdk.WithString("title", mcpsdk.Required(), mcpsdk.Description("Session title")),
 			mcpsdk.WithString("summary", mcpsdk.Description("Session summary (used for embedding)")),
 			mcpsdk.WithString("content", mcpsdk.Description("Full session content/transcript")),
+			mcpsdk.WithString("expected_updated_at", mcpsdk.Description("Optimistic concurrency check: 'new' to require that the session does not exist yet, or the updated_at (RFC3339) from session_get to require that it is unchanged. Omit to overwrite unconditionally.")),
 		),
 		s.handleSessionCreate,
 	)
@@ -184,6 +643,17 @@ func (s *Server) registerTools() {
 		s.handleSessionGet,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_get_chunk",
+			mcpsdk.WithDescription("Read part of a session's content, for transcripts too large for session_get. Returns the characters from offset, the total size, and has_more; call again with offset+length to continue."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number")),
+			mcpsdk.WithString("offset", mcpsdk.Description("Character offset to start at (default 0)")),
+			mcpsdk.WithString("length", mcpsdk.Description(fmt.Sprintf("Characters to return (default %d, max %d)", defaultChunkLength, maxChunkLength))),
+		),
+		s.handleSessionGetChunk,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("session_list",
 			mcpsdk.WithDescription("List all sessions for a project"),
@@ -192,6 +662,72 @@ func (s *Server) registerTools() {
 		s.handleSessionList,
 	)
 
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_update",
+			mcpsdk.WithDescription("Rename or renumber an existing session. Only the fields provided are changed; the summary is re-embedded if it changes."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Current session number")),
+			mcpsdk.WithString("title", mcpsdk.Description("New session title")),
+			mcpsdk.WithString("summary", mcpsdk.Description("New session summary (re-embedded)")),
+			mcpsdk.WithString("new_session_num", mcpsdk.Description("New session number (must not already exist)")),
+		),
+		s.handleSessionUpdate,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_outline",
+			mcpsdk.WithDescription("Get a table of contents for a session transcript: nested Markdown headings with character offsets, so relevant sections can be read without loading the whole transcript"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number")),
+			mcpsdk.WithString("max_level", mcpsdk.Description("Deepest heading level to include, 1-6 (default 6)")),
+		),
+		s.handleSessionOutline,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_grep",
+			mcpsdk.WithDescription("Find a passage within one session's content: case-insensitive substring matches with surrounding context, character offsets (as in session_outline), and line numbers"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number")),
+			mcpsdk.WithString("query", mcpsdk.Required(), mcpsdk.Description("Text to find")),
+			mcpsdk.WithString("context", mcpsdk.Description("Characters of context on each side of a match (default 80)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max matches to return (default 20, 0 = all)")),
+		),
+		s.handleSessionGrep,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_resummarize",
+			mcpsdk.WithDescription("Regenerate a session's summary from its content at a target length, store it, and re-embed the session. The summary is extractive (leading sentences of each paragraph), so it needs the session content; restore archived sessions first."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number")),
+			mcpsdk.WithString("length", mcpsdk.Description("Target length: short (~300 chars), medium (~800), or long (~2000). Default medium")),
+		),
+		s.handleSessionResummarize,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("archive_sessions",
+			mcpsdk.WithDescription("Archive sessions older than a cutoff: optionally export content to files and distill each into a draft memory, then clear the content (title and summary are kept)"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("older_than_days", mcpsdk.Required(), mcpsdk.Description("Archive sessions created more than this many days ago")),
+			mcpsdk.WithString("export_dir", mcpsdk.Description("Directory under the server's ARCHIVE_DIR (relative, \".\" for ARCHIVE_DIR itself) to write each session's content to before clearing it; enables session_restore")),
+			mcpsdk.WithString("extract_memories", mcpsdk.Description("Save each session's summary and headings as a draft memory in topic session-archive (default false)")),
+			mcpsdk.WithString("dry_run", mcpsdk.Description("List what would be archived without changing anything (default false)")),
+		),
+		s.handleArchiveSessions,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("session_restore",
+			mcpsdk.WithDescription("Restore an archived session's content from its export file, or from content passed in"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("session_num", mcpsdk.Required(), mcpsdk.Description("Session number")),
+			mcpsdk.WithString("content", mcpsdk.Description("Content to restore (default: read the file recorded at archive time)")),
+		),
+		s.handleSessionRestore,
+	)
+
 	s.mcp.AddTool(
 		mcpsdk.NewTool("session_search",
 			mcpsdk.WithDescription("Semantic search over session transcripts"),
@@ -205,12 +741,12 @@ func (s *Server) registerTools() {
 	// --- File index tools ---
 	s.mcp.AddTool(
 		mcpsdk.NewTool("file_index",
-			mcpsdk.WithDescription("Index a project file with metadata and summary for semantic search"),
+			mcpsdk.WithDescription("Index a project file with metadata and summary for semantic search. Only text-bearing types on the allowlist are accepted; images and PDFs are recorded by path without an embedding."),
 			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
 			mcpsdk.WithString("file_path", mcpsdk.Required(), mcpsdk.Description("File path relative to project root")),
 			mcpsdk.WithString("file_type", mcpsdk.Description("File type (e.g. 'go', 'sql', 'md')")),
 			mcpsdk.WithString("summary", mcpsdk.Description("File summary (used for embedding)")),
-			mcpsdk.WithString("symbols", mcpsdk.Description("JSON array of symbols (functions, types, etc.)")),
+			mcpsdk.WithString("symbols", mcpsdk.Description(`JSON array of symbols, e.g. [{"name":"Load","kind":"func","line":12,"doc":"..."}] or ["Load","Save"], or symbol names one per line`)),
 		),
 		s.handleFileIndex,
 	)
@@ -224,6 +760,108 @@ func (s *Server) registerTools() {
 		),
 		s.handleFileSearch,
 	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("file_types",
+			mcpsdk.WithDescription("List the distinct file types indexed in a project with their file counts"),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+		),
+		s.handleFileTypes,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("file_context",
+			mcpsdk.WithDescription("Get the memories and sessions most related to an indexed file, using the file's embedding as the query. Use when opening a file to recall relevant decisions and history."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("file_path", mcpsdk.Required(), mcpsdk.Description("Indexed file path relative to project root")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max memories and max sessions (default 5 each)")),
+		),
+		s.handleFileContext,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("file_refresh",
+			mcpsdk.WithDescription("Re-read indexed files from the project's root_path, regenerate summary and symbols, and re-embed the ones that changed. Requires the server to have filesystem access to the project."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("path", mcpsdk.Description("Exact indexed file path to refresh")),
+			mcpsdk.WithString("prefix", mcpsdk.Description("Refresh every indexed file whose path starts with this prefix")),
+		),
+		s.handleFileRefresh,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("file_index_audit",
+			mcpsdk.WithDescription("Check indexed files against the project's root_path: list files modified on disk since they were indexed and files that no longer exist. With prune=true, removes the vanished ones from the index. Requires the server to have filesystem access to the project."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("prefix", mcpsdk.Description("Only audit indexed paths starting with this prefix")),
+			mcpsdk.WithString("prune", mcpsdk.Description("Unindex the files missing from disk (default false)")),
+		),
+		s.handleFileIndexAudit,
+	)
+
+	// --- Cross-entity tools ---
+	s.mcp.AddTool(
+		mcpsdk.NewTool("search_all",
+			mcpsdk.WithDescription("Search memories, sessions, and files across all projects in one call. Per-type limits control how much of each entity type is returned."),
+			mcpsdk.WithString("query", mcpsdk.Required(), mcpsdk.Description("Search query text")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Default max results per entity type (default 10)")),
+			mcpsdk.WithString("limit_memories", mcpsdk.Description("Max memory results (default: limit)")),
+			mcpsdk.WithString("limit_sessions", mcpsdk.Description("Max session results (default: limit)")),
+			mcpsdk.WithString("limit_files", mcpsdk.Description("Max file results (default: limit)")),
+			mcpsdk.WithString("memory_weight", mcpsdk.Description("Score multiplier for memories in the merged ranking (default 1.0)")),
+			mcpsdk.WithString("session_weight", mcpsdk.Description("Score multiplier for sessions in the merged ranking (default 1.0)")),
+			mcpsdk.WithString("file_weight", mcpsdk.Description("Score multiplier for files in the merged ranking (default 1.0)")),
+		),
+		s.handleSearchAll,
+	)
+
+	// --- Usage tools ---
+	s.mcp.AddTool(
+		mcpsdk.NewTool("usage_search",
+			mcpsdk.WithDescription("Explore past search queries. With a query, find similar questions agents asked before; without one, cluster recurring questions by similarity. Semantic results need USAGE_EMBED_QUERIES=true."),
+			mcpsdk.WithString("project_id", mcpsdk.Description("Project identifier (default: all projects)")),
+			mcpsdk.WithString("query", mcpsdk.Description("Find past queries similar to this text")),
+			mcpsdk.WithString("threshold", mcpsdk.Description("Cosine similarity for grouping queries into a cluster (default 0.8)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max results or clusters (default 20)")),
+		),
+		s.handleUsageSearch,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("search_suggest",
+			mcpsdk.WithDescription("Suggest better phrasings for a (partial) search query: past memory, session, file, and search_all queries in this project that found results and are close to the input by words or, with USAGE_EMBED_QUERIES=true, by meaning. Ranked by closeness weighted by how many results they found."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("query", mcpsdk.Required(), mcpsdk.Description("The query being written; the last word may be unfinished")),
+			mcpsdk.WithString("min_similarity", mcpsdk.Description("Minimum closeness, 0-1 (default 0.3)")),
+			mcpsdk.WithString("limit", mcpsdk.Description("Max suggestions (default 5)")),
+		),
+		s.handleSearchSuggest,
+	)
+
+	// --- Maintenance tools ---
+	s.mcp.AddTool(
+		mcpsdk.NewTool("maintenance_orphans",
+			mcpsdk.WithDescription("Find memories, sessions, and files whose project no longer exists. Set cleanup=true to delete them."),
+			mcpsdk.WithString("cleanup", mcpsdk.Description("Delete the orphans found (default false)")),
+		),
+		s.handleMaintenanceOrphans,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("warm_caches",
+			mcpsdk.WithDescription("Precompute the dashboard stats and project centroids now, so the next dashboard load or related_projects call is served from cache. The server also does this at startup and every CACHE_WARM_INTERVAL."),
+		),
+		s.handleWarmCaches,
+	)
+
+	s.mcp.AddTool(
+		mcpsdk.NewTool("embedding_audit",
+			mcpsdk.WithDescription("Report a project's embeddings by dimension per entity (memories, sessions, files) and how many differ from the configured EMBEDDING_DIM. Set repair=true to clear mismatched vectors so a reindex regenerates them."),
+			mcpsdk.WithString("project_id", mcpsdk.Required(), mcpsdk.Description("Project identifier")),
+			mcpsdk.WithString("repair", mcpsdk.Description("Clear embeddings whose dimension differs from EMBEDDING_DIM (default false)")),
+		),
+		s.handleEmbeddingAudit,
+	)
 }
 
 // --- Tool Handlers ---
@@ -249,6 +887,22 @@ func (s *Server) handleProjectRegister(ctx context.Context, req mcpsdk.CallToolR
 	return mcpsdk.NewToolResultText(fmt.Sprintf("Project '%s' registered (id=%s)", name, id)), nil
 }
 
+// ensureProject registers projectID with its id as name when
+// AutoCreateProject is on and the project does not exist yet.
+func (s *Server) ensureProject(ctx context.Context, projectID string) error {
+	if !s.opts.AutoCreateProject {
+		return nil
+	}
+	created, err := s.store.EnsureProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if created {
+		slog.Info("auto-registered project", "project_id", projectID)
+	}
+	return nil
+}
+
 func (s *Server) handleProjectList(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projects, err := s.store.ListProjects(ctx)
 	if err != nil {
@@ -276,44 +930,328 @@ func (s *Server) handleProjectStatus(ctx context.Context, req mcpsdk.CallToolReq
 	memories, _ := s.store.ListMemories(ctx, projectID, "")
 	sessions, _ := s.store.ListSessions(ctx, projectID)
 
+	embeddingStatus := s.embedding.Status()
+	if !p.EmbeddingsEnabled() {
+		embeddingStatus = "disabled for this project (keyword search only)"
+	}
 	status := map[string]any{
-		"project":          p,
-		"memory_count":     len(memories),
-		"session_count":    len(sessions),
-		"embedding_status": s.embedding.Status(),
+		"project":                 p,
+		"memory_count":            len(memories),
+		"session_count":           len(sessions),
+		"embeddings_enabled":      p.EmbeddingsEnabled(),
+		"embedding_status":        embeddingStatus,
+		"embedding_model_version": s.embedding.ModelVersion(),
+	}
+	if len(s.entityEmbedding) > 0 {
+		models := map[string]any{}
+		for _, entity := range []string{store.EntityMemory, store.EntitySession, store.EntityFile} {
+			e := s.embedder(entity)
+			models[entity] = map[string]any{"status": e.Status(), "model_version": e.ModelVersion(), "dim": e.Dim()}
+		}
+		status["entity_embeddings"] = models
+	}
+	// Rows with no vector of the current model version, including ones a
+	// model change left behind; a reindex fills them in.
+	if coverage, err := s.store.ListEmbeddingCoverage(ctx); err == nil {
+		status["unembedded"] = coverage[projectID]
 	}
 	s.recordUsage(ctx, "project_status", projectID, "", 1)
 	data, _ := json.MarshalIndent(status, "", "  ")
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+// projectHealth is one project's row in all_projects_status.
+type projectHealth struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	MemoryCount       int    `json:"memory_count"`
+	SessionCount      int    `json:"session_count"`
+	FileCount         int    `json:"file_count"`
+	EmbeddingsEnabled bool   `json:"embeddings_enabled"`
+	store.EmbeddingCoverage
+}
+
+func (s *Server) handleAllProjectsStatus(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	stats, err := s.store.GetAllProjectStats(ctx)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project stats: %v", err)), nil
+	}
+	coverage, err := s.store.ListEmbeddingCoverage(ctx)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embedding coverage: %v", err)), nil
+	}
+
+	projects := make([]projectHealth, 0, len(stats))
+	for _, ps := range stats {
+		projects = append(projects, projectHealth{
+			ID:                ps.Project.ID,
+			Name:              ps.Project.Name,
+			MemoryCount:       ps.MemoryCount,
+			SessionCount:      ps.SessionCount,
+			FileCount:         ps.FileCount,
+			EmbeddingsEnabled: ps.Project.EmbeddingsEnabled(),
+			EmbeddingCoverage: coverage[ps.Project.ID],
+		})
+	}
+	response := map[string]any{
+		"count":            len(projects),
+		"projects":         projects,
+		"embedding_status": s.embedding.Status(),
+		"vector_enabled":   coverage != nil,
+	}
+	s.recordUsage(ctx, "all_projects_status", "", "", len(projects))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleProjectUpdate(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+
+	if name := stringArg(req, "name"); name != "" {
+		p.Name = name
+	}
+	if rootPath := optionalStringArg(req, "root_path"); rootPath != nil {
+		p.RootPath = *rootPath
+	}
+	for _, key := range []string{store.MetaSessionRetentionDays, store.MetaMemoryRetentionDays} {
+		v := stringArg(req, key)
+		if v == "" {
+			continue
+		}
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("%s must be a non-negative integer", key)), nil
+		}
+		if p.Metadata == nil {
+			p.Metadata = map[string]any{}
+		}
+		if days == 0 {
+			delete(p.Metadata, key)
+		} else {
+			p.Metadata[key] = days
+		}
+	}
+	if v := optionalStringArg(req, store.MetaPrimingTopics); v != nil {
+		var topics []any
+		for _, t := range strings.Split(*v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				topics = append(topics, t)
+			}
+		}
+		if p.Metadata == nil {
+			p.Metadata = map[string]any{}
+		}
+		if topics == nil {
+			delete(p.Metadata, store.MetaPrimingTopics)
+		} else {
+			p.Metadata[store.MetaPrimingTopics] = topics
+		}
+	}
+	// Boolean settings are stored only when they differ from the default.
+	for key, def := range map[string]bool{store.MetaAutoVersionKeys: false, store.MetaEmbeddingsEnabled: true} {
+		v := stringArg(req, key)
+		if v == "" {
+			continue
+		}
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("%s must be true or false", key)), nil
+		}
+		if p.Metadata == nil {
+			p.Metadata = map[string]any{}
+		}
+		if on == def {
+			delete(p.Metadata, key)
+		} else {
+			p.Metadata[key] = on
+		}
+	}
+
+	if err := s.store.CreateProject(ctx, p); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("update project: %v", err)), nil
+	}
+	s.recordUsage(ctx, "project_update", projectID, "", 1)
+	data, _ := json.MarshalIndent(p, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
 func (s *Server) handleMemorySet(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 	topic := stringArg(req, "topic")
 	key := stringArg(req, "key")
 	value := stringArg(req, "value")
+	ifAbsent := boolArg(req, "if_absent", false)
+	givenTopic := topic
+	generic := s.genericTopic(topic)
+	infer := generic && s.opts.TopicInferenceThreshold > 0
+
+	if projectID == "" || key == "" || value == "" {
+		return mcpsdk.NewToolResultError("project_id, key, and value are required"), nil
+	}
+	if generic {
+		topic = s.catchAllTopic()
+	}
 
-	if projectID == "" || topic == "" || key == "" || value == "" {
-		return mcpsdk.NewToolResultError("project_id, topic, key, and value are required"), nil
+	// A queued write is replayed as an upsert, so create-only writes are
+	// never queued: they fail while the database is unreachable.
+	canQueue := func(err error) bool { return !ifAbsent && s.canQueue(err) }
+
+	var similarity float64
+	if infer {
+		var err error
+		topic, similarity, err = s.inferTopic(ctx, projectID, value)
+		if canQueue(err) {
+			topic = s.catchAllTopic()
+		} else if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("infer topic: %v", err)), nil
+		}
+	}
+	if s.queue != nil {
+		// Land earlier queued writes first so they cannot overwrite this one.
+		if _, err := s.queue.Replay(ctx, s.store); canQueue(err) {
+			return s.queueMemorySet(ctx, projectID, topic, key, value)
+		}
 	}
 
-	emb := s.embedding.Embed(ctx, value)
-	err := s.store.SetMemory(ctx, &store.Memory{
+	err := s.ensureProject(ctx, projectID)
+	if canQueue(err) {
+		return s.queueMemorySet(ctx, projectID, topic, key, value)
+	}
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("register project: %v", err)), nil
+	}
+	versioned, err := s.autoVersionKeys(ctx, projectID)
+	if canQueue(err) {
+		return s.queueMemorySet(ctx, projectID, topic, key, value)
+	}
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if err := s.checkTopicSchema(ctx, projectID, topic, value); err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	var prior *store.Memory
+	if versioned && !hasVersionSuffix(key) {
+		// Diff against the newest version and write the next one; an
+		// unchanged value adds no version.
+		latest, n, err := s.latestMemoryVersion(ctx, projectID, topic, key)
+		if canQueue(err) {
+			return s.queueMemorySet(ctx, projectID, topic, key, value)
+		}
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("get memory versions: %v", err)), nil
+		}
+		if latest != nil && ifAbsent {
+			return memorySkipped(topic, latest.Key), nil
+		}
+		if latest != nil && latest.Value == value {
+			return mcpsdk.NewToolResultText(fmt.Sprintf("Memory unchanged: %s/%s is already the latest version", topic, latest.Key)), nil
+		}
+		prior = latest
+		key = versionedKey(key, n+1)
+	} else if prior, err = s.store.GetMemory(ctx, projectID, topic, key); canQueue(err) {
+		return s.queueMemorySet(ctx, projectID, topic, key, value)
+	} else if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get memory: %v", err)), nil
+	} else if prior != nil && ifAbsent {
+		return memorySkipped(topic, key), nil
+	}
+
+	mem := &store.Memory{
 		ProjectID: projectID,
 		Topic:     topic,
 		Key:       key,
 		Value:     value,
-	}, emb)
-	if err != nil {
-		return mcpsdk.NewToolResultError(fmt.Sprintf("set memory: %v", err)), nil
+		Status:    store.MemoryDraft,
+	}
+	if prior != nil && prior.Key == key && prior.Value == value && prior.Status != "" {
+		// Re-asserting the stored value keeps its review: only a real
+		// change demotes a published memory to draft.
+		mem.Status = prior.Status
+	}
+	var emb []float32
+	var skipped, unchanged bool
+	disabled := !s.projectEmbeds(ctx, projectID)
+	if !disabled && !s.opts.ReembedUnchanged && prior != nil && prior.Key == key && prior.Value == value {
+		// Re-asserting the stored value: SetMemory keeps the stored
+		// vector when given none, as long as there is a current one.
+		stored, err := s.store.GetMemoryEmbedding(ctx, projectID, topic, key)
+		unchanged = err == nil && stored != nil
+	}
+	if !disabled && !unchanged {
+		if emb, skipped, err = s.embedder(store.EntityMemory).EmbedValue(ctx, mem.EmbedText(s.opts.MemoryEmbedKey)); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("embed memory: %v", err)), nil
+		}
+	}
+	if ifAbsent {
+		// The key may have been written since it was looked up; the insert
+		// itself decides.
+		created, err := s.store.CreateMemory(ctx, mem, emb)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("create memory: %v", err)), nil
+		}
+		if !created {
+			return memorySkipped(topic, key), nil
+		}
+	} else {
+		err = s.store.SetMemory(ctx, mem, emb)
+		if canQueue(err) {
+			return s.enqueueMemory(mem, emb)
+		}
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("set memory: %v", err)), nil
+		}
 	}
 
 	embedded := "no"
-	if emb != nil {
+	switch {
+	case emb != nil:
 		embedded = "yes"
+	case unchanged:
+		embedded = "unchanged"
+	case skipped:
+		embedded = "skipped (too short)"
+	case disabled:
+		embedded = "disabled for project"
 	}
 	s.recordUsage(ctx, "memory_set", projectID, topic+"/"+key, 1)
-	return mcpsdk.NewToolResultText(fmt.Sprintf("Memory set: %s/%s (embedded: %s)", topic, key, embedded)), nil
+	msg := fmt.Sprintf("Memory set: %s/%s (embedded: %s, status: %s)", topic, key, embedded, mem.Status)
+	switch {
+	case prior == nil:
+		msg += "\nCreated new memory."
+	case prior.Key != key:
+		msg += fmt.Sprintf("\nNew version of %s; changed %s", prior.Key, diffSummary(prior.Value, value))
+	case prior.Value == value:
+		msg += "\nValue unchanged."
+	default:
+		msg += "\nChanged " + diffSummary(prior.Value, value)
+	}
+	if infer && topic != s.catchAllTopic() {
+		msg += fmt.Sprintf("\nInferred topic: %s (similarity %.3f). If it is wrong, delete it with memory_delete and set it again with an explicit topic.", topic, similarity)
+	} else if generic {
+		given := "no topic"
+		if givenTopic != "" {
+			given = fmt.Sprintf("generic topic %q", givenTopic)
+		}
+		msg += fmt.Sprintf("\nFiled under %s (%s) for later organization; memory_uncategorized lists these so they can be moved to a real topic.", topic, given)
+	}
+	return mcpsdk.NewToolResultText(msg), nil
+}
+
+// memorySkipped is the memory_set result when if_absent finds the key taken.
+func memorySkipped(topic, key string) *mcpsdk.CallToolResult {
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Memory skipped: %s/%s already exists (if_absent); existing value left untouched", topic, key))
 }
 
 func (s *Server) handleMemoryGet(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
@@ -321,18 +1259,65 @@ func (s *Server) handleMemoryGet(ctx context.Context, req mcpsdk.CallToolRequest
 	topic := stringArg(req, "topic")
 	key := stringArg(req, "key")
 
-	m, err := s.store.GetMemory(ctx, projectID, topic, key)
+	var m *store.Memory
+	var err error
+	if base, ok := strings.CutSuffix(key, latestSuffix); ok {
+		m, _, err = s.latestMemoryVersion(ctx, projectID, topic, base)
+	} else {
+		m, err = s.store.GetMemory(ctx, projectID, topic, key)
+	}
 	if err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("get memory: %v", err)), nil
 	}
 	if m == nil {
 		return mcpsdk.NewToolResultText("not found"), nil
 	}
-	s.recordUsage(ctx, "memory_get", projectID, topic+"/"+key, 1)
+	s.recordUsage(ctx, "memory_get", projectID, topic+"/"+m.Key, 1)
 	data, _ := json.MarshalIndent(m, "", "  ")
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+func (s *Server) handleMemoryEmbedding(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topic := stringArg(req, "topic")
+	key := stringArg(req, "key")
+	full := boolArg(req, "full", false)
+
+	if projectID == "" || topic == "" || key == "" {
+		return mcpsdk.NewToolResultError("project_id, topic, and key are required"), nil
+	}
+
+	m, err := s.store.GetMemory(ctx, projectID, topic, key)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get memory: %v", err)), nil
+	}
+	if m == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+	vec, err := s.store.GetMemoryEmbedding(ctx, projectID, topic, key)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get embedding: %v", err)), nil
+	}
+	s.recordUsage(ctx, "memory_embedding", projectID, topic+"/"+key, 1)
+	if vec == nil {
+		return mcpsdk.NewToolResultText(fmt.Sprintf("not embedded: %s/%s has no stored vector", topic, key)), nil
+	}
+
+	response := map[string]any{
+		"topic": topic,
+		"key":   key,
+		"dim":   len(vec),
+	}
+	if v, truncated := s.limitVector(vec, full); truncated {
+		response["preview"] = v
+		response["truncated"] = true
+	} else {
+		response["embedding"] = v
+	}
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
 func (s *Server) handleMemoryList(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 	topic := stringArg(req, "topic")
@@ -350,32 +1335,79 @@ func (s *Server) handleMemorySearch(ctx context.Context, req mcpsdk.CallToolRequ
 	projectID := stringArg(req, "project_id")
 	query := stringArg(req, "query")
 	limit := intArg(req, "limit", 10)
+	includeDrafts := boolArg(req, "include_drafts", false)
 
 	if projectID == "" || query == "" {
 		return mcpsdk.NewToolResultError("project_id and query are required"), nil
 	}
+	projectIDs, err := s.searchProjects(ctx, req, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
 
-	emb := s.embedding.Embed(ctx, query)
-	results, err := s.store.SearchMemories(ctx, projectID, query, emb, limit)
+	var results []store.Memory
+	emb, partial, err := s.searchWithFallback(ctx, s.embedQueryFor(ctx, store.EntityMemory, projectID, query), func(ctx context.Context, emb store.Vector) (err error) {
+		results, err = s.searchMemoriesIn(ctx, projectIDs, query, emb, limit, includeDrafts)
+		return err
+	})
 	if err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
 	}
 
 	searchType := "full-text"
+	response := map[string]any{}
 	if emb != nil {
 		searchType = "semantic (vector)"
+		// Full-text ranks are on another scale, so min_score is semantic only.
+		if minScore := s.memoryMinScore(ctx, req, projectID); minScore > 0 {
+			kept := results[:0]
+			for _, m := range results {
+				if m.Score >= minScore {
+					kept = append(kept, m)
+				}
+			}
+			results = kept
+			response["min_score"] = minScore
+		}
 	}
-	response := map[string]any{
-		"search_type": searchType,
-		"query":       query,
-		"count":       len(results),
-		"results":     results,
+	response["search_type"] = searchType
+	response["query"] = query
+	if len(projectIDs) > 1 {
+		response["projects"] = projectIDs
+	}
+	response["count"] = len(results)
+	response["results"] = results
+	if s.searchDegraded(ctx, store.EntityMemory, projectID, emb) {
+		response["degraded"] = true
+		response["message"] = degradedMessage
+	}
+	if partial {
+		response["partial"] = true
+		response["degraded"] = true
+		response["message"] = timeoutMessage
 	}
-	s.recordUsage(ctx, "memory_search", projectID, query, len(results))
+	s.recordSearchUsage(ctx, "memory_search", store.EntityMemory, projectID, query, len(results), emb)
+	s.notifySearch("memory_search", projectID, query, searchType, memoryHits(results))
 	data, _ := json.MarshalIndent(response, "", "  ")
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+func (s *Server) handleMemoryPublish(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topic := stringArg(req, "topic")
+	key := stringArg(req, "key")
+
+	if projectID == "" || topic == "" || key == "" {
+		return mcpsdk.NewToolResultError("project_id, topic, and key are required"), nil
+	}
+
+	if err := s.store.PublishMemory(ctx, projectID, topic, key); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("publish memory: %v", err)), nil
+	}
+	s.recordUsage(ctx, "memory_publish", projectID, topic+"/"+key, 1)
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Published: %s/%s", topic, key)), nil
+}
+
 func (s *Server) handleMemoryDelete(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 	topic := stringArg(req, "topic")
@@ -400,25 +1432,64 @@ func (s *Server) handleSessionCreate(ctx context.Context, req mcpsdk.CallToolReq
 		return mcpsdk.NewToolResultError("project_id, session_num, and title are required"), nil
 	}
 
+	// expected_updated_at "new" means the session must not exist; a
+	// timestamp means it must not have changed since it was read.
+	expected := stringArg(req, "expected_updated_at")
+	var expectedAt *time.Time
+	if expected != "" && expected != "new" {
+		t, err := time.Parse(time.RFC3339Nano, expected)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("invalid expected_updated_at %q: use 'new' or an RFC3339 timestamp", expected)), nil
+		}
+		expectedAt = &t
+	}
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("register project: %v", err)), nil
+	}
+	duplicates, err := s.duplicateSessionTitles(ctx, projectID, sessionNum, title)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("check title: %v", err)), nil
+	}
+	if len(duplicates) > 0 && s.opts.DuplicateSessionTitles == DuplicateTitlesReject {
+		return mcpsdk.NewToolResultError(fmt.Sprintf(
+			"title %q is already used by %s. Update it with session_update, or use a distinct title.", title, sessionList(duplicates))), nil
+	}
+
 	// Embed the summary for semantic search
 	embText := summary
 	if embText == "" {
 		embText = title
 	}
-	emb := s.embedding.Embed(ctx, embText)
+	emb, err := s.embedFor(ctx, store.EntitySession, projectID, embText)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embed session: %v", err)), nil
+	}
 
-	err := s.store.CreateSession(ctx, &store.Session{
+	sess := &store.Session{
 		ProjectID:  projectID,
 		SessionNum: sessionNum,
 		Title:      title,
 		Summary:    summary,
 		Content:    content,
-	}, emb)
+	}
+	if expected == "" {
+		err = s.store.CreateSession(ctx, sess, emb)
+	} else {
+		err = s.store.CreateSessionExpect(ctx, sess, emb, expectedAt)
+	}
+	if errors.Is(err, store.ErrSessionConflict) {
+		return mcpsdk.NewToolResultError(fmt.Sprintf(
+			"conflict: %v. Re-read it with session_get and merge your changes, or use the next free session_num.", err)), nil
+	}
 	if err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("create session: %v", err)), nil
 	}
 	s.recordUsage(ctx, "session_create", projectID, title, 1)
-	return mcpsdk.NewToolResultText(fmt.Sprintf("Session %d created: %s", sessionNum, title)), nil
+	msg := fmt.Sprintf("Session %d created: %s", sessionNum, title)
+	if len(duplicates) > 0 {
+		msg += fmt.Sprintf("\nWarning: the title is already used by %s. If this continues it, update it with session_update instead.", sessionList(duplicates))
+	}
+	return mcpsdk.NewToolResultText(msg), nil
 }
 
 func (s *Server) handleSessionGet(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
@@ -437,6 +1508,40 @@ func (s *Server) handleSessionGet(ctx context.Context, req mcpsdk.CallToolReques
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+// Chunk sizes for session_get_chunk, in characters.
+const (
+	defaultChunkLength = 20000
+	maxChunkLength     = 100000
+)
+
+func (s *Server) handleSessionGetChunk(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	offset := intArg(req, "offset", 0)
+	length := intArg(req, "length", defaultChunkLength)
+
+	if projectID == "" || sessionNum == 0 {
+		return mcpsdk.NewToolResultError("project_id and session_num are required"), nil
+	}
+	if offset < 0 {
+		return mcpsdk.NewToolResultError("offset must not be negative"), nil
+	}
+	if length <= 0 || length > maxChunkLength {
+		length = maxChunkLength
+	}
+
+	chunk, err := s.store.GetSessionChunk(ctx, projectID, sessionNum, offset, length)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get session chunk: %v", err)), nil
+	}
+	if chunk == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+	s.recordUsage(ctx, "session_get_chunk", projectID, "", 1)
+	data, _ := json.MarshalIndent(chunk, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
 func (s *Server) handleSessionList(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 
@@ -449,6 +1554,73 @@ func (s *Server) handleSessionList(ctx context.Context, req mcpsdk.CallToolReque
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+func (s *Server) handleSessionUpdate(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	title := optionalStringArg(req, "title")
+	summary := optionalStringArg(req, "summary")
+
+	if projectID == "" || sessionNum == 0 {
+		return mcpsdk.NewToolResultError("project_id and session_num are required"), nil
+	}
+
+	var newNum *int
+	if v := stringArg(req, "new_session_num"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 {
+			return mcpsdk.NewToolResultError("new_session_num must be a non-zero integer"), nil
+		}
+		newNum = &n
+	}
+	if title == nil && summary == nil && newNum == nil {
+		return mcpsdk.NewToolResultError("at least one of title, summary, or new_session_num is required"), nil
+	}
+	if title != nil && *title == "" {
+		return mcpsdk.NewToolResultError("title cannot be empty"), nil
+	}
+
+	// Re-embed when the embedded text changes: the summary, or the title
+	// when the session is left without a summary, as in session_create.
+	var embText string
+	if summary != nil && *summary != "" {
+		embText = *summary
+	} else if summary != nil || title != nil {
+		cur, err := s.store.GetSession(ctx, projectID, sessionNum)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("get session: %v", err)), nil
+		}
+		if cur == nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("session %d not found in project %s", sessionNum, projectID)), nil
+		}
+		switch {
+		case summary == nil && cur.Summary != "":
+			// Only the title changed; the summary stays embedded.
+		case title != nil:
+			embText = *title
+		default:
+			embText = cur.Title
+		}
+	}
+	var emb []float32
+	if embText != "" {
+		var err error
+		if emb, err = s.embedFor(ctx, store.EntitySession, projectID, embText); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("embed session: %v", err)), nil
+		}
+	}
+
+	if err := s.store.UpdateSessionMeta(ctx, projectID, sessionNum, title, summary, newNum, emb); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("update session: %v", err)), nil
+	}
+
+	finalNum := sessionNum
+	if newNum != nil {
+		finalNum = *newNum
+	}
+	s.recordUsage(ctx, "session_update", projectID, strconv.Itoa(sessionNum), 1)
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Session %d updated (now session %d)", sessionNum, finalNum)), nil
+}
+
 func (s *Server) handleSessionSearch(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 	query := stringArg(req, "query")
@@ -458,8 +1630,11 @@ func (s *Server) handleSessionSearch(ctx context.Context, req mcpsdk.CallToolReq
 		return mcpsdk.NewToolResultError("project_id and query are required"), nil
 	}
 
-	emb := s.embedding.Embed(ctx, query)
-	results, err := s.store.SearchSessions(ctx, projectID, query, emb, limit)
+	var results []store.Session
+	emb, partial, err := s.searchWithFallback(ctx, s.embedQueryFor(ctx, store.EntitySession, projectID, query), func(ctx context.Context, emb store.Vector) (err error) {
+		results, err = s.store.SearchSessions(ctx, projectID, query, emb, limit)
+		return err
+	})
 	if err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("search sessions: %v", err)), nil
 	}
@@ -474,39 +1649,102 @@ func (s *Server) handleSessionSearch(ctx context.Context, req mcpsdk.CallToolReq
 		"count":       len(results),
 		"results":     results,
 	}
-	s.recordUsage(ctx, "session_search", projectID, query, len(results))
+	if s.searchDegraded(ctx, store.EntitySession, projectID, emb) {
+		response["degraded"] = true
+		response["message"] = degradedMessage
+	}
+	if partial {
+		response["partial"] = true
+		response["degraded"] = true
+		response["message"] = timeoutMessage
+	}
+	s.recordSearchUsage(ctx, "session_search", store.EntitySession, projectID, query, len(results), emb)
+	s.notifySearch("session_search", projectID, query, searchType, sessionHits(results))
 	data, _ := json.MarshalIndent(response, "", "  ")
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
 
+// symbolsArg reads the file_index symbols: a JSON array of symbol objects
+// or names (as the array itself or a string holding it), or a plain list
+// of names, one per line. Malformed JSON is an error rather than no
+// symbols.
+func symbolsArg(req mcpsdk.CallToolRequest, name string) ([]store.Symbol, error) {
+	raw, ok := req.Params.Arguments[name]
+	if !ok || raw == nil || raw == "" {
+		return nil, nil
+	}
+	text, isString := raw.(string)
+	if !isString {
+		data, _ := json.Marshal(raw)
+		text = string(data)
+	}
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "[") {
+		var symbols []store.Symbol
+		for _, line := range strings.Split(trimmed, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				symbols = append(symbols, store.Symbol{Name: line})
+			}
+		}
+		return symbols, nil
+	}
+	var symbols []store.Symbol
+	if err := json.Unmarshal([]byte(trimmed), &symbols); err != nil {
+		return nil, fmt.Errorf("%s is not a valid JSON array of symbols: %v", name, err)
+	}
+	for i, sym := range symbols {
+		if strings.TrimSpace(sym.Name) == "" {
+			return nil, fmt.Errorf("%s[%d] has no name", name, i)
+		}
+	}
+	return symbols, nil
+}
+
 func (s *Server) handleFileIndex(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	projectID := stringArg(req, "project_id")
 	filePath := stringArg(req, "file_path")
 	fileType := stringArg(req, "file_type")
 	summary := stringArg(req, "summary")
-	symbolsStr := stringArg(req, "symbols")
 
 	if projectID == "" || filePath == "" {
 		return mcpsdk.NewToolResultError("project_id and file_path are required"), nil
 	}
+	symbols, err := symbolsArg(req, "symbols")
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	kind, index, embed := s.filePolicy(fileType, filePath)
+	if !index {
+		return mcpsdk.NewToolResultError(fmt.Sprintf(
+			"file type %q is not indexable; allowed types: %s (set FILE_INDEX_TYPES to change)",
+			kind, strings.Join(s.opts.FileTypes, ", "))), nil
+	}
 
-	var symbols []any
-	if symbolsStr != "" {
-		json.Unmarshal([]byte(symbolsStr), &symbols)
+	if err := s.ensureProject(ctx, projectID); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("register project: %v", err)), nil
 	}
 
-	emb := s.embedding.Embed(ctx, summary)
-	err := s.store.IndexFile(ctx, &store.FileEntry{
+	entry := &store.FileEntry{
 		ProjectID: projectID,
 		FilePath:  filePath,
 		FileType:  fileType,
 		Summary:   summary,
 		Symbols:   symbols,
-	}, emb)
-	if err != nil {
+	}
+	var emb []float32
+	if embed {
+		var err error
+		if emb, err = s.embedFor(ctx, store.EntityFile, projectID, entry.EmbedText(s.opts.FileEmbedPath)); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("embed file: %v", err)), nil
+		}
+	}
+	if err := s.store.IndexFile(ctx, entry, emb); err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("index file: %v", err)), nil
 	}
 	s.recordUsage(ctx, "file_index", projectID, filePath, 1)
+	if !embed {
+		return mcpsdk.NewToolResultText(fmt.Sprintf("Indexed: %s (path only; %s files are not embedded)", filePath, kind)), nil
+	}
 	return mcpsdk.NewToolResultText(fmt.Sprintf("Indexed: %s", filePath)), nil
 }
 
@@ -519,8 +1757,11 @@ func (s *Server) handleFileSearch(ctx context.Context, req mcpsdk.CallToolReques
 		return mcpsdk.NewToolResultError("project_id and query are required"), nil
 	}
 
-	emb := s.embedding.Embed(ctx, query)
-	results, err := s.store.SearchFiles(ctx, projectID, query, emb, limit)
+	var results []store.FileEntry
+	emb, partial, err := s.searchWithFallback(ctx, s.embedQueryFor(ctx, store.EntityFile, projectID, query), func(ctx context.Context, emb store.Vector) (err error) {
+		results, err = s.store.SearchFiles(ctx, projectID, query, emb, limit)
+		return err
+	})
 	if err != nil {
 		return mcpsdk.NewToolResultError(fmt.Sprintf("search files: %v", err)), nil
 	}
@@ -535,7 +1776,75 @@ func (s *Server) handleFileSearch(ctx context.Context, req mcpsdk.CallToolReques
 		"count":       len(results),
 		"results":     results,
 	}
-	s.recordUsage(ctx, "file_search", projectID, query, len(results))
+	if s.searchDegraded(ctx, store.EntityFile, projectID, emb) {
+		response["degraded"] = true
+		response["message"] = degradedMessage
+	}
+	if partial {
+		response["partial"] = true
+		response["degraded"] = true
+		response["message"] = timeoutMessage
+	}
+	s.recordSearchUsage(ctx, "file_search", store.EntityFile, projectID, query, len(results), emb)
+	s.notifySearch("file_search", projectID, query, searchType, fileHits(results))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+func (s *Server) handleSearchAll(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	query := stringArg(req, "query")
+	if query == "" {
+		return mcpsdk.NewToolResultError("query is required"), nil
+	}
+	limits := store.SearchLimits{
+		Default:  intArg(req, "limit", 10),
+		Memories: intArg(req, "limit_memories", 0),
+		Sessions: intArg(req, "limit_sessions", 0),
+		Files:    intArg(req, "limit_files", 0),
+	}
+
+	weights := store.SearchWeights{
+		Memory:  floatArg(req, "memory_weight", 1),
+		Session: floatArg(req, "session_weight", 1),
+		File:    floatArg(req, "file_weight", 1),
+	}
+	if weights.Memory <= 0 || weights.Session <= 0 || weights.File <= 0 {
+		return mcpsdk.NewToolResultError("weights must be positive"), nil
+	}
+
+	vectors := s.queryVectors(ctx, query)
+	results, err := s.store.SearchAll(ctx, query, vectors, limits, weights)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search all: %v", err)), nil
+	}
+
+	emb := vectors.Memory
+	searchType := "full-text"
+	if emb != nil {
+		searchType = "semantic (vector)"
+	}
+	count := len(results.Memories) + len(results.Sessions) + len(results.Files)
+	response := map[string]any{
+		"search_type": searchType,
+		"query":       query,
+		"count":       count,
+		"memories":    results.Memories,
+		"sessions":    results.Sessions,
+		"files":       results.Files,
+		"ranked":      results.Ranked,
+	}
+	if len(results.Warnings) > 0 {
+		response["warnings"] = results.Warnings
+	}
+	if emb == nil && s.embedder(store.EntityMemory).Enabled() {
+		response["degraded"] = true
+		response["message"] = degradedMessage
+	}
+	s.recordSearchUsage(ctx, "search_all", store.EntityMemory, "", query, count, emb)
+	hits := memoryHits(results.Memories)
+	hits = append(hits, sessionHits(results.Sessions)...)
+	hits = append(hits, fileHits(results.Files)...)
+	s.notifySearch("search_all", "", query, searchType, hits)
 	data, _ := json.MarshalIndent(response, "", "  ")
 	return mcpsdk.NewToolResultText(string(data)), nil
 }
@@ -554,6 +1863,42 @@ func stringArg(req mcpsdk.CallToolRequest, name string) string {
 	return s
 }
 
+// optionalStringArg returns nil when the argument was not supplied at all,
+// so callers can tell "leave unchanged" apart from an explicit empty string.
+func optionalStringArg(req mcpsdk.CallToolRequest, name string) *string {
+	if _, ok := req.Params.Arguments[name]; !ok {
+		return nil
+	}
+	v := stringArg(req, name)
+	return &v
+}
+
+func boolArg(req mcpsdk.CallToolRequest, name string, defaultVal bool) bool {
+	v := stringArg(req, name)
+	if v == "" {
+		return defaultVal
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		slog.Warn("invalid bool arg", "name", name, "value", v)
+		return defaultVal
+	}
+	return b
+}
+
+func floatArg(req mcpsdk.CallToolRequest, name string, defaultVal float64) float64 {
+	v := stringArg(req, name)
+	if v == "" {
+		return defaultVal
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		slog.Warn("invalid float arg", "name", name, "value", v)
+		return defaultVal
+	}
+	return f
+}
+
 func intArg(req mcpsdk.CallToolRequest, name string, defaultVal int) int {
 	v := stringArg(req, name)
 	if v == "" {
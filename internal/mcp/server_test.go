@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCheckAccess(t *testing.T) {
+	policy, err := access.Parse(strings.NewReader("admin: *\nteam: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		token  string // "" for no scope in the context
+		tool   string
+		args   map[string]any
+		denied string // substring of the denial, or "" when allowed
+	}{
+		{"no policy", "", "maintenance_orphans", nil, ""},
+		{"admin all-project tool", "admin", "maintenance_orphans", nil, ""},
+		{"admin any project", "admin", "memory_get", map[string]any{"project_id": "z"}, ""},
+		{"own project", "team", "memory_get", map[string]any{"project_id": "a"}, ""},
+		{"other project", "team", "memory_get", map[string]any{"project_id": "b"}, `project "b"`},
+		{"no project", "team", "project_list", nil, ""},
+		{"register own", "team", "project_register", map[string]any{"id": "a"}, ""},
+		{"register other", "team", "project_register", map[string]any{"id": "b"}, `project "b"`},
+		{"orphans", "team", "maintenance_orphans", nil, "all projects"},
+		{"warm caches", "team", "warm_caches", map[string]any{"project_id": "a"}, "all projects"},
+		{"usage_search everywhere", "team", "usage_search", nil, "all projects"},
+		{"usage_search own project", "team", "usage_search", map[string]any{"project_id": "a"}, ""},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := s.checkAccess(func(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+				called = true
+				return mcpsdk.NewToolResultText("ok"), nil
+			})
+			ctx := context.Background()
+			if tt.token != "" {
+				sc, _ := policy.Lookup(tt.token)
+				ctx = access.WithScope(ctx, sc)
+			}
+			var req mcpsdk.CallToolRequest
+			req.Params.Name = tt.tool
+			req.Params.Arguments = tt.args
+			res, err := h(ctx, req)
+			if err != nil {
+				t.Fatalf("handler error: %v", err)
+			}
+			if tt.denied == "" {
+				if !called || res.IsError {
+					t.Fatalf("call was denied, want it allowed")
+				}
+				return
+			}
+			if called || !res.IsError {
+				t.Fatalf("call was allowed, want it denied")
+			}
+			text := res.Content[0].(mcpsdk.TextContent).Text
+			if !strings.Contains(text, tt.denied) {
+				t.Errorf("denial %q does not mention %q", text, tt.denied)
+			}
+		})
+	}
+}
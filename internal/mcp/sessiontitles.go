@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Values of Options.DuplicateSessionTitles.
+const (
+	DuplicateTitlesWarn   = "warn"
+	DuplicateTitlesReject = "reject"
+	DuplicateTitlesAllow  = "allow"
+)
+
+// duplicateSessionTitles returns the other sessions of the project titled
+// like title, unless duplicates are allowed. Rewriting sessionNum itself
+// is not a duplicate.
+func (s *Server) duplicateSessionTitles(ctx context.Context, projectID string, sessionNum int, title string) ([]int, error) {
+	if s.opts.DuplicateSessionTitles == DuplicateTitlesAllow {
+		return nil, nil
+	}
+	nums, err := s.store.SessionNumsWithTitle(ctx, projectID, title)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(nums, func(n int) bool { return n == sessionNum }), nil
+}
+
+// sessionList names session numbers: "session 3", "sessions 3 and 7",
+// or "sessions 3, 7 and 9".
+func sessionList(nums []int) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = strconv.Itoa(n)
+	}
+	if len(parts) == 1 {
+		return "session " + parts[0]
+	}
+	return "sessions " + strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
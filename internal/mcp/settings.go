@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// autoVersionKeys reports whether the project keeps memory history as
+// versioned keys instead of overwriting.
+func (s *Server) autoVersionKeys(ctx context.Context, projectID string) (bool, error) {
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil || p == nil {
+		return false, err
+	}
+	on, _ := p.Metadata[store.MetaAutoVersionKeys].(bool)
+	return on, nil
+}
+
+// projectEmbeds reports whether a project's rows are embedded. Unknown
+// projects and lookup errors fall back to the global setting.
+func (s *Server) projectEmbeds(ctx context.Context, projectID string) bool {
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		slog.Warn("get project settings", "project", projectID, "error", err)
+		return true
+	}
+	return p == nil || p.EmbeddingsEnabled()
+}
+
+// embedder returns the embedding service for an entity type: its own
+// model if one was set with SetEntityEmbedding, else the shared one.
+func (s *Server) embedder(entity string) *embedding.Service {
+	if e := s.entityEmbedding[entity]; e != nil {
+		return e
+	}
+	return s.embedding
+}
+
+// embedFor is EmbedChecked for a project's stored text of an entity type;
+// projects with embeddings disabled get no vector.
+func (s *Server) embedFor(ctx context.Context, entity, projectID, text string) ([]float32, error) {
+	if !s.projectEmbeds(ctx, projectID) {
+		return nil, nil
+	}
+	return s.embedder(entity).EmbedChecked(ctx, text)
+}
+
+// degradedMessage explains a degraded search to the caller.
+const degradedMessage = "embedding service unavailable; results are keyword matches only"
+
+// searchDegraded reports whether a search expected a query vector but got
+// none: embeddings are on for the project, so the service must be failing.
+func (s *Server) searchDegraded(ctx context.Context, entity, projectID string, emb []float32) bool {
+	return emb == nil && s.embedder(entity).Enabled() && s.projectEmbeds(ctx, projectID)
+}
+
+// embedQueryFor is EmbedQuery for a search of one entity type within one
+// project. Projects with embeddings disabled have no vectors, so they
+// search by keyword.
+func (s *Server) embedQueryFor(ctx context.Context, entity, projectID, query string) []float32 {
+	if !s.projectEmbeds(ctx, projectID) {
+		return nil
+	}
+	return s.embedder(entity).EmbedQuery(ctx, query)
+}
+
+// queryVectors embeds a cross-project query once per distinct model.
+func (s *Server) queryVectors(ctx context.Context, query string) store.QueryVectors {
+	done := map[*embedding.Service]store.Vector{}
+	embed := func(entity string) store.Vector {
+		e := s.embedder(entity)
+		if v, ok := done[e]; ok {
+			return v
+		}
+		v := e.EmbedQuery(ctx, query)
+		done[e] = v
+		return v
+	}
+	return store.QueryVectors{
+		Memory:  embed(store.EntityMemory),
+		Session: embed(store.EntitySession),
+		File:    embed(store.EntityFile),
+	}
+}
+
+// canQueue reports whether a failed memory write should go to the write
+// queue: the queue is enabled and the database is unreachable.
+func (s *Server) canQueue(err error) bool {
+	return s.queue != nil && store.IsConnError(err)
+}
+
+// queueMemorySet buffers a memory_set that could not reach the database.
+// Without it there is no version history or project settings to consult,
+// so the value goes to the key as given; a stray vector on a project with
+// embeddings disabled is harmless, as its searches never use vectors.
+func (s *Server) queueMemorySet(ctx context.Context, projectID, topic, key, value string) (*mcpsdk.CallToolResult, error) {
+	mem := &store.Memory{
+		ProjectID: projectID,
+		Topic:     topic,
+		Key:       key,
+		Value:     value,
+		Status:    store.MemoryDraft,
+	}
+	emb, _, err := s.embedder(store.EntityMemory).EmbedValue(ctx, mem.EmbedText(s.opts.MemoryEmbedKey))
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embed memory: %v", err)), nil
+	}
+	return s.enqueueMemory(mem, emb)
+}
+
+func (s *Server) enqueueMemory(m *store.Memory, emb []float32) (*mcpsdk.CallToolResult, error) {
+	if err := s.queue.Enqueue(m, emb); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("queue memory: %v", err)), nil
+	}
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Memory queued: %s/%s (database unreachable; queued, will persist when DB recovers)", m.Topic, m.Key)), nil
+}
+
+// limitVector cuts vec to Options.MaxEmbeddingElements components for a
+// tool result, unless full is set, and reports whether it did.
+func (s *Server) limitVector(vec store.Vector, full bool) (store.Vector, bool) {
+	n := s.opts.MaxEmbeddingElements
+	if full || n <= 0 || len(vec) <= n {
+		return vec, false
+	}
+	return vec[:n], true
+}
+
+// timeoutMessage explains a search that fell back after a timeout.
+const timeoutMessage = "vector search timed out; results are keyword matches only"
+
+// searchWithFallback runs search with emb under Options.SearchTimeout. If
+// the vector query times out, it runs search again by keyword alone,
+// which is usually much faster, so the caller gets some results instead
+// of an error; partial reports that this happened. It returns the vector
+// the results were found with.
+func (s *Server) searchWithFallback(ctx context.Context, emb store.Vector, search func(context.Context, store.Vector) error) (_ store.Vector, partial bool, err error) {
+	err = s.runSearch(ctx, emb, search)
+	if emb == nil || !store.IsQueryTimeout(err) || ctx.Err() != nil {
+		return emb, false, err
+	}
+	slog.Warn("vector search timed out, falling back to keyword search", "error", err)
+	return nil, true, s.runSearch(ctx, nil, search)
+}
+
+func (s *Server) runSearch(ctx context.Context, emb store.Vector, search func(context.Context, store.Vector) error) error {
+	if s.opts.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.SearchTimeout)
+		defer cancel()
+	}
+	return search(ctx, emb)
+}
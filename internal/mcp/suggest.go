@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// suggestCandidates is how many of the most successful past queries
+// search_suggest ranks against the input.
+const suggestCandidates = 1000
+
+// querySuggestion is a past query offered by search_suggest.
+type querySuggestion struct {
+	Query      string  `json:"query"`
+	Similarity float64 `json:"similarity"`
+	AvgResults float64 `json:"avg_results"`
+	TimesAsked int     `json:"times_asked"`
+	Score      float64 `json:"score"`
+}
+
+func (s *Server) handleSearchSuggest(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	query := strings.TrimSpace(stringArg(req, "query"))
+	limit := intArg(req, "limit", 5)
+	minSimilarity := floatArg(req, "min_similarity", 0.3)
+
+	if projectID == "" || query == "" {
+		return mcpsdk.NewToolResultError("project_id and query are required"), nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	// Only embedded usage rows can match semantically, so skip the call
+	// when queries are not being embedded.
+	var emb []float32
+	if s.opts.EmbedUsageQueries {
+		emb = s.embedding.EmbedQuery(ctx, query)
+	}
+	past, err := s.store.ListSuccessfulQueries(ctx, projectID, emb, suggestCandidates)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list past queries: %v", err)), nil
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	var suggestions []querySuggestion
+	for _, q := range past {
+		if strings.EqualFold(q.QueryText, query) {
+			continue
+		}
+		sim := max(lexicalSimilarity(words, q.QueryText), q.Score)
+		if sim < minSimilarity {
+			continue
+		}
+		suggestions = append(suggestions, querySuggestion{
+			Query:      q.QueryText,
+			Similarity: round3(sim),
+			AvgResults: round3(q.AvgResults),
+			TimesAsked: q.Count,
+			// Closeness matters most; result counts break ties with
+			// diminishing returns.
+			Score: round3(sim * math.Log1p(q.AvgResults)),
+		})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	matchType := "lexical"
+	if emb != nil {
+		matchType = "lexical + semantic"
+	}
+	response := map[string]any{
+		"project_id":  projectID,
+		"query":       query,
+		"match_type":  matchType,
+		"count":       len(suggestions),
+		"suggestions": suggestions,
+	}
+	s.recordUsage(ctx, "search_suggest", projectID, query, len(suggestions))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// lexicalSimilarity is the share of the input words found in candidate.
+// The input may be unfinished, so its last word also matches as a prefix.
+func lexicalSimilarity(words []string, candidate string) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+	have := strings.Fields(strings.ToLower(candidate))
+	matched := 0
+	for i, w := range words {
+		for _, h := range have {
+			if h == w || (i == len(words)-1 && strings.HasPrefix(h, w)) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(words))
+}
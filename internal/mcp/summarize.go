@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	"github.com/Platform-LSS/devmemory/internal/summary"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// summaryLengths maps a session_resummarize length to its character budget.
+var summaryLengths = map[string]int{
+	"short":  300,
+	"medium": 800,
+	"long":   2000,
+}
+
+// handleSessionResummarize rebuilds a session's summary from its content at
+// the requested length, stores it, and re-embeds the session. There is no
+// generative summarizer, so the summary is extractive: leading sentences of
+// the transcript's paragraphs, in document order.
+func (s *Server) handleSessionResummarize(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	sessionNum := intArg(req, "session_num", 0)
+	length := stringArg(req, "length")
+	if length == "" {
+		length = "medium"
+	}
+
+	if projectID == "" || sessionNum == 0 {
+		return mcpsdk.NewToolResultError("project_id and session_num are required"), nil
+	}
+	budget, ok := summaryLengths[length]
+	if !ok {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("invalid length %q: use short, medium, or long", length)), nil
+	}
+
+	sess, err := s.store.GetSession(ctx, projectID, sessionNum)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get session: %v", err)), nil
+	}
+	if sess == nil {
+		return mcpsdk.NewToolResultText("not found"), nil
+	}
+	if strings.TrimSpace(sess.Content) == "" {
+		return mcpsdk.NewToolResultError("session has no content to summarize (if it was archived, run session_restore first)"), nil
+	}
+
+	text := summary.Extractive(sess.Content, budget)
+	if text == "" {
+		return mcpsdk.NewToolResultError("session content has no prose to summarize"), nil
+	}
+	emb, err := s.embedFor(ctx, store.EntitySession, projectID, text)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("embed session: %v", err)), nil
+	}
+	if err := s.store.UpdateSessionMeta(ctx, projectID, sessionNum, nil, &text, nil, emb); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("update session: %v", err)), nil
+	}
+
+	s.recordUsage(ctx, "session_resummarize", projectID, strconv.Itoa(sessionNum), 1)
+	return mcpsdk.NewToolResultText(fmt.Sprintf("Session %d summary updated (%s, %d chars):\n\n%s",
+		sessionNum, length, utf8.RuneCountInString(text), text)), nil
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleMemoryBulkTag applies a tag to every memory whose similarity to a
+// query reaches min_score. Without confirm it only lists the matches, so
+// the threshold can be checked before anything is written.
+func (s *Server) handleMemoryBulkTag(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	query := stringArg(req, "query")
+	tag := strings.ToLower(strings.TrimSpace(stringArg(req, "tag")))
+	limit := intArg(req, "limit", 50)
+	confirm := boolArg(req, "confirm", false)
+
+	if projectID == "" || query == "" || tag == "" {
+		return mcpsdk.NewToolResultError("project_id, query, and tag are required"), nil
+	}
+	minScore := s.memoryMinScore(ctx, req, projectID)
+	if minScore <= 0 {
+		return mcpsdk.NewToolResultError("min_score is required (or tune a project default with tune_threshold)"), nil
+	}
+
+	emb := s.embedQueryFor(ctx, store.EntityMemory, projectID, query)
+	if emb == nil {
+		// A keyword match has no similarity to hold against min_score.
+		return mcpsdk.NewToolResultError("memory_bulk_tag needs semantic search; embeddings are not available for this project"), nil
+	}
+
+	results, err := s.store.SearchMemories(ctx, projectID, query, emb, limit, true)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+	}
+	var ids []int64
+	var keys []string
+	for _, m := range results {
+		if m.Score < minScore {
+			break // results are ordered by similarity
+		}
+		ids = append(ids, m.ID)
+		keys = append(keys, m.Topic+"/"+m.Key)
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"query":      query,
+		"tag":        tag,
+		"min_score":  minScore,
+		"confirmed":  confirm,
+		"count":      len(keys),
+		"keys":       keys,
+	}
+	if confirm && len(ids) > 0 {
+		tagged, err := s.store.TagMemories(ctx, projectID, ids, tag)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("tag memories: %v", err)), nil
+		}
+		response["tagged"] = tagged
+	}
+	s.recordUsage(ctx, "memory_bulk_tag", projectID, tag+": "+query, len(keys))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
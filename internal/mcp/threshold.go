@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// tunePair is a labeled query: the memories (topic/key) it should find.
+type tunePair struct {
+	Query    string   `json:"query"`
+	Expected []string `json:"expected"`
+}
+
+// thresholdScore is the retrieval quality at one min_score.
+type thresholdScore struct {
+	Threshold float64 `json:"threshold"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// memoryMinScore is the min_score for a semantic memory search: the
+// explicit argument, else the project's tuned value, else 0 (no filter).
+func (s *Server) memoryMinScore(ctx context.Context, req mcpsdk.CallToolRequest, projectID string) float64 {
+	if v := floatArg(req, "min_score", -1); v >= 0 {
+		return v
+	}
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil || p == nil {
+		return 0
+	}
+	v, _ := p.Metadata[store.MetaMinScore].(float64)
+	return v
+}
+
+func (s *Server) handleTuneThreshold(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	pairsStr := stringArg(req, "pairs")
+	candidates := intArg(req, "candidates", 20)
+	save := boolArg(req, "save", true)
+
+	if projectID == "" || pairsStr == "" {
+		return mcpsdk.NewToolResultError("project_id and pairs are required"), nil
+	}
+	var pairs []tunePair
+	if err := json.Unmarshal([]byte(pairsStr), &pairs); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("invalid pairs JSON: %v", err)), nil
+	}
+	if len(pairs) == 0 {
+		return mcpsdk.NewToolResultError("pairs must contain at least one query"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	if !s.embedder(store.EntityMemory).Enabled() || !p.EmbeddingsEnabled() {
+		return mcpsdk.NewToolResultError("min_score applies to semantic search; embeddings are not enabled for this project"), nil
+	}
+
+	// Retrieve candidates once per query; the sweep only re-filters them.
+	results := make([][]store.Memory, len(pairs))
+	for i, pair := range pairs {
+		if pair.Query == "" || len(pair.Expected) == 0 {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("pair %d needs a query and at least one expected topic/key", i+1)), nil
+		}
+		emb := s.embedder(store.EntityMemory).EmbedQuery(ctx, pair.Query)
+		if emb == nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("could not embed query %q", pair.Query)), nil
+		}
+		if results[i], err = s.store.SearchMemories(ctx, projectID, pair.Query, emb, candidates, false); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+		}
+	}
+
+	sweep := sweepThresholds(pairs, results)
+	best := sweep[0]
+	for _, sc := range sweep[1:] {
+		// Ties go to the higher threshold: same F1, less noise.
+		if sc.F1 >= best.F1 {
+			best = sc
+		}
+	}
+
+	response := map[string]any{
+		"project_id":  projectID,
+		"pairs":       len(pairs),
+		"recommended": best,
+		"saved":       false,
+	}
+	var curve []thresholdScore
+	for i, sc := range sweep {
+		if i%5 == 0 {
+			curve = append(curve, sc)
+		}
+	}
+	response["curve"] = curve
+
+	if save && best.F1 > 0 {
+		if p.Metadata == nil {
+			p.Metadata = map[string]any{}
+		}
+		p.Metadata[store.MetaMinScore] = best.Threshold
+		if err := s.store.CreateProject(ctx, p); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("save threshold: %v", err)), nil
+		}
+		response["saved"] = true
+	}
+	s.recordUsage(ctx, "tune_threshold", projectID, "", len(pairs))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// sweepThresholds scores thresholds 0.00 to 1.00 in steps of 0.01 with
+// micro-averaged precision, recall, and F1 over all pairs.
+func sweepThresholds(pairs []tunePair, results [][]store.Memory) []thresholdScore {
+	var sweep []thresholdScore
+	for step := 0; step <= 100; step++ {
+		t := float64(step) / 100
+		var tp, fp, fn int
+		for i, pair := range pairs {
+			want := map[string]bool{}
+			for _, k := range pair.Expected {
+				want[k] = true
+			}
+			found := 0
+			for _, m := range results[i] {
+				if m.Score < t {
+					continue
+				}
+				if want[m.Topic+"/"+m.Key] {
+					found++
+				} else {
+					fp++
+				}
+			}
+			tp += found
+			fn += len(want) - found
+		}
+		sc := thresholdScore{Threshold: t}
+		if tp+fp > 0 {
+			sc.Precision = round3(float64(tp) / float64(tp+fp))
+		}
+		if tp+fn > 0 {
+			sc.Recall = round3(float64(tp) / float64(tp+fn))
+		}
+		if sc.Precision+sc.Recall > 0 {
+			sc.F1 = round3(2 * sc.Precision * sc.Recall / (sc.Precision + sc.Recall))
+		}
+		sweep = append(sweep, sc)
+	}
+	return sweep
+}
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
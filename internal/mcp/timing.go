@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/timing"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timeTool records where a tool call spends its time and adds the report
+// to the result: as a "timing" field when the result is a JSON object,
+// else as a second text content of its own.
+func timeTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		ctx, rec := timing.WithRecorder(ctx)
+		result, err := next(ctx, req)
+		if err != nil || result == nil {
+			return result, err
+		}
+		report, _ := json.Marshal(rec.Report())
+		if len(result.Content) > 0 {
+			if text, ok := result.Content[0].(mcpsdk.TextContent); ok {
+				if withTiming, ok := addTimingField(text.Text, report); ok {
+					text.Text = withTiming
+					result.Content[0] = text
+					return result, nil
+				}
+			}
+		}
+		result.Content = append(result.Content, mcpsdk.NewTextContent(`{"timing": `+string(report)+`}`))
+		return result, nil
+	}
+}
+
+// addTimingField appends a "timing" field to a JSON object, keeping its
+// fields in their original order.
+func addTimingField(text string, report []byte) (string, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") || !json.Valid([]byte(trimmed)) {
+		return "", false
+	}
+	body := strings.TrimSpace(strings.TrimSuffix(trimmed, "}"))
+	sep := ",\n"
+	if body == "{" {
+		sep = "\n"
+	}
+	return body + sep + `  "timing": ` + string(report) + "\n}", true
+}
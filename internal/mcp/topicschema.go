@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Platform-LSS/devmemory/internal/jsonschema"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// topicSchema returns the JSON Schema memories of topic must satisfy, or
+// nil when the topic is free text.
+func (s *Server) topicSchema(ctx context.Context, projectID, topic string) (map[string]any, error) {
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return p.TopicSchema(topic), nil
+}
+
+// checkTopicSchema validates value against the schema of topic, if any.
+// The error is worded for the tool result.
+func (s *Server) checkTopicSchema(ctx context.Context, projectID, topic, value string) error {
+	schema, err := s.topicSchema(ctx, projectID, topic)
+	if err != nil {
+		return fmt.Errorf("get topic schema: %v", err)
+	}
+	if schema == nil {
+		return nil
+	}
+	if err := jsonschema.Validate(schema, value); err != nil {
+		return fmt.Errorf("value does not match the schema of topic '%s': %v", topic, err)
+	}
+	return nil
+}
+
+func (s *Server) handleTopicSchemaSet(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	topic := stringArg(req, "topic")
+	raw := stringArg(req, "schema")
+
+	if projectID == "" || topic == "" {
+		return mcpsdk.NewToolResultError("project_id and topic are required"), nil
+	}
+	var schema map[string]any
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("schema must be a JSON object: %v", err)), nil
+		}
+		if err := jsonschema.Check(schema); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("invalid schema: %v", err)), nil
+		}
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	if p.Metadata == nil {
+		p.Metadata = map[string]any{}
+	}
+	schemas, _ := p.Metadata[store.MetaTopicSchemas].(map[string]any)
+	if schemas == nil {
+		schemas = map[string]any{}
+	}
+	if schema == nil {
+		delete(schemas, topic)
+	} else {
+		schemas[topic] = schema
+	}
+	if len(schemas) == 0 {
+		delete(p.Metadata, store.MetaTopicSchemas)
+	} else {
+		p.Metadata[store.MetaTopicSchemas] = schemas
+	}
+	if err := s.store.CreateProject(ctx, p); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("update project: %v", err)), nil
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"topic":      topic,
+		"schema":     schema,
+	}
+	// Existing memories are not rejected, only reported, so they can be
+	// fixed with memory_set.
+	if schema != nil {
+		memories, err := s.store.ListMemories(ctx, projectID, topic)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+		}
+		nonconforming := map[string]string{}
+		for _, m := range memories {
+			if err := jsonschema.Validate(schema, m.Value); err != nil {
+				nonconforming[m.Key] = err.Error()
+			}
+		}
+		response["checked"] = len(memories)
+		response["nonconforming"] = nonconforming
+	}
+	s.recordUsage(ctx, "topic_schema_set", projectID, topic, 1)
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
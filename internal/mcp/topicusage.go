@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// topicUsage is one topic of a topic_usage report. A topic that only
+// project settings still name has no memories and is flagged empty.
+type topicUsage struct {
+	Topic        string     `json:"topic"`
+	Memories     int        `json:"memories"`
+	Drafts       int        `json:"drafts"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	Empty        bool       `json:"empty,omitempty"`
+	ReferencedBy []string   `json:"referenced_by,omitempty"`
+}
+
+func (s *Server) handleTopicUsage(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	prune := boolArg(req, "prune", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+
+	p, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("get project: %v", err)), nil
+	}
+	if p == nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' not found", projectID)), nil
+	}
+	counts, err := s.store.ListTopicCounts(ctx, projectID)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list topics: %v", err)), nil
+	}
+
+	byTopic := map[string]*topicUsage{}
+	topics := make([]*topicUsage, 0, len(counts))
+	for _, c := range counts {
+		t := &topicUsage{Topic: c.Topic, Memories: c.Memories, Drafts: c.Drafts, UpdatedAt: &c.UpdatedAt}
+		byTopic[c.Topic] = t
+		topics = append(topics, t)
+	}
+	// Topics are derived from memories; the project settings below are
+	// the only other place a topic name lives on after its last memory.
+	schemas, _ := p.Metadata[store.MetaTopicSchemas].(map[string]any)
+	refs := map[string][]string{store.MetaPrimingTopics: p.PrimingTopics()}
+	for name := range schemas {
+		refs[store.MetaTopicSchemas] = append(refs[store.MetaTopicSchemas], name)
+	}
+	var empty []string
+	for _, setting := range []string{store.MetaPrimingTopics, store.MetaTopicSchemas} {
+		for _, name := range refs[setting] {
+			t := byTopic[name]
+			if t == nil {
+				t = &topicUsage{Topic: name, Empty: true}
+				byTopic[name] = t
+				topics = append(topics, t)
+				empty = append(empty, name)
+			}
+			t.ReferencedBy = append(t.ReferencedBy, setting)
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Topic < topics[j].Topic })
+	sort.Strings(empty)
+
+	response := map[string]any{
+		"project_id": projectID,
+		"topics":     topics,
+		"empty":      len(empty),
+	}
+	if prune && len(empty) > 0 {
+		pruneTopicRefs(p, byTopic)
+		if err := s.store.CreateProject(ctx, p); err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("update project: %v", err)), nil
+		}
+		response["pruned"] = empty
+	} else if len(empty) > 0 {
+		response["next_step"] = "Call topic_usage again with prune=true to drop the empty topics from the project settings."
+	}
+	s.recordUsage(ctx, "topic_usage", projectID, "", len(topics))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// pruneTopicRefs removes the empty topics of usage from the priming list
+// and topic schemas of p.
+func pruneTopicRefs(p *store.Project, usage map[string]*topicUsage) {
+	isEmpty := func(name string) bool { return usage[name] != nil && usage[name].Empty }
+
+	var priming []any
+	for _, name := range p.PrimingTopics() {
+		if !isEmpty(name) {
+			priming = append(priming, name)
+		}
+	}
+	if priming == nil {
+		delete(p.Metadata, store.MetaPrimingTopics)
+	} else {
+		p.Metadata[store.MetaPrimingTopics] = priming
+	}
+
+	if schemas, ok := p.Metadata[store.MetaTopicSchemas].(map[string]any); ok {
+		for name := range schemas {
+			if isEmpty(name) {
+				delete(schemas, name)
+			}
+		}
+		if len(schemas) == 0 {
+			delete(p.Metadata, store.MetaTopicSchemas)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// tsqueryHelp is appended to errors for malformed advanced queries.
+const tsqueryHelp = `use to_tsquery syntax, e.g. 'tabs & !spaces', 'deploy <-> script', 'migrat:*', '(go | rust) & test'`
+
+func (s *Server) handleMemorySearchAdvanced(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	query := stringArg(req, "tsquery")
+	limit := intArg(req, "limit", 10)
+	includeDrafts := boolArg(req, "include_drafts", false)
+
+	if projectID == "" || query == "" {
+		return mcpsdk.NewToolResultError("project_id and tsquery are required"), nil
+	}
+	if err := checkParens(query); err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("invalid tsquery: %v; %s", err, tsqueryHelp)), nil
+	}
+
+	results, err := s.store.SearchMemoriesTSQuery(ctx, projectID, query, limit, includeDrafts)
+	if errors.Is(err, store.ErrInvalidTSQuery) {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("%v; %s", err, tsqueryHelp)), nil
+	}
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+	}
+
+	response := map[string]any{
+		"search_type": "full-text (tsquery)",
+		"tsquery":     query,
+		"count":       len(results),
+		"results":     results,
+	}
+	s.recordUsage(ctx, "memory_search_advanced", projectID, query, len(results))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// checkParens catches unbalanced parentheses before the query reaches
+// Postgres, whose message for them ("syntax error in tsquery") does not
+// say what is wrong.
+func checkParens(q string) error {
+	depth := 0
+	for _, r := range q {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return fmt.Errorf("unmatched ')'")
+			}
+		}
+	}
+	if depth > 0 {
+		return fmt.Errorf("unclosed '('")
+	}
+	return nil
+}
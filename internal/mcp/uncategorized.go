@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// uncategorizedEntry is a memory in the catch-all topic, with the nearest
+// real topic when its embedding is available.
+type uncategorizedEntry struct {
+	Key            string    `json:"key"`
+	Preview        string    `json:"preview"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	SuggestedTopic string    `json:"suggested_topic,omitempty"`
+	Similarity     float64   `json:"similarity,omitempty"`
+}
+
+func (s *Server) handleMemoryUncategorized(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	limit := intArg(req, "limit", 50)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id is required"), nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	topic := s.catchAllTopic()
+	memories, err := s.store.ListMemories(ctx, projectID, topic)
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("list memories: %v", err)), nil
+	}
+	sort.SliceStable(memories, func(i, j int) bool { return memories[i].UpdatedAt.After(memories[j].UpdatedAt) })
+	total := len(memories)
+	if len(memories) > limit {
+		memories = memories[:limit]
+	}
+
+	// Suggestions compare each stored vector with the other topics'
+	// centroids; without embeddings the list is still useful.
+	centroids, err := s.store.TopicCentroids(ctx, projectID)
+	if err != nil || !s.projectEmbeds(ctx, projectID) {
+		centroids = nil
+	}
+	entries := make([]uncategorizedEntry, len(memories))
+	for i, m := range memories {
+		entries[i] = uncategorizedEntry{Key: m.Key, Preview: preview(m.Value, defaultPreviewChars), UpdatedAt: m.UpdatedAt}
+		if len(centroids) == 0 {
+			continue
+		}
+		vec, err := s.store.GetMemoryEmbedding(ctx, projectID, topic, m.Key)
+		if err != nil || vec == nil {
+			continue
+		}
+		if t, sim := s.nearestTopic(vec, centroids); t != "" {
+			entries[i].SuggestedTopic, entries[i].Similarity = t, round3(sim)
+		}
+	}
+
+	response := map[string]any{
+		"project_id": projectID,
+		"topic":      topic,
+		"total":      total,
+		"memories":   entries,
+		"hint":       "Move a memory by setting it again under its real topic with memory_set, then memory_delete it here.",
+	}
+	s.recordUsage(ctx, "memory_uncategorized", projectID, "", len(entries))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Platform-LSS/devmemory/internal/embedding"
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+// queryCluster groups past queries that ask roughly the same thing.
+type queryCluster struct {
+	Representative string   `json:"representative"`
+	TotalCount     int      `json:"total_count"`
+	Queries        []string `json:"queries"`
+}
+
+// recordSearchUsage is recordUsage for search tools: the query embedding
+// the handler already computed is stored too when EmbedUsageQueries is on.
+func (s *Server) recordSearchUsage(ctx context.Context, toolName, entity, projectID, query string, resultsCount int, emb store.Vector) {
+	// usage_stats holds vectors of the shared model only.
+	if !s.opts.EmbedUsageQueries || s.embedder(entity) != s.embedding {
+		emb = nil
+	}
+	s.recordUsageStat(ctx, toolName, projectID, query, resultsCount, emb)
+}
+
+func (s *Server) handleUsageSearch(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	query := stringArg(req, "query")
+	limit := intArg(req, "limit", 20)
+	threshold := floatArg(req, "threshold", 0.8)
+
+	response := map[string]any{
+		"project_id": projectID,
+		"embedding":  s.opts.EmbedUsageQueries,
+	}
+
+	if query != "" {
+		emb := s.embedding.EmbedQuery(ctx, query)
+		results, err := s.store.SearchUsageQueries(ctx, projectID, query, emb, limit)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("search usage: %v", err)), nil
+		}
+		searchType := "substring"
+		if emb != nil {
+			searchType = "semantic (vector)"
+		}
+		response["search_type"] = searchType
+		response["query"] = query
+		response["count"] = len(results)
+		response["results"] = results
+		s.recordUsage(ctx, "usage_search", projectID, query, len(results))
+	} else {
+		queries, err := s.store.ListUsageQueries(ctx, projectID, 0)
+		if err != nil {
+			return mcpsdk.NewToolResultError(fmt.Sprintf("list usage queries: %v", err)), nil
+		}
+		clusters := clusterQueries(queries, threshold)
+		if len(clusters) > limit {
+			clusters = clusters[:limit]
+		}
+		response["threshold"] = threshold
+		response["queries"] = len(queries)
+		response["clusters"] = clusters
+		s.recordUsage(ctx, "usage_search", projectID, "", len(clusters))
+	}
+
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// clusterQueries greedily assigns each query, most frequent first, to the
+// first cluster whose representative is at least threshold similar. The
+// most frequent phrasing represents each cluster.
+func clusterQueries(queries []store.UsageQuery, threshold float64) []queryCluster {
+	sort.SliceStable(queries, func(i, j int) bool { return queries[i].Count > queries[j].Count })
+
+	var clusters []queryCluster
+	var seeds []store.Vector
+	for _, q := range queries {
+		placed := false
+		for i, seed := range seeds {
+			if embedding.Cosine(seed, q.Embedding) >= threshold {
+				clusters[i].TotalCount += q.Count
+				clusters[i].Queries = append(clusters[i].Queries, q.QueryText)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			seeds = append(seeds, q.Embedding)
+			clusters = append(clusters, queryCluster{
+				Representative: q.QueryText,
+				TotalCount:     q.Count,
+				Queries:        []string{q.QueryText},
+			})
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].TotalCount > clusters[j].TotalCount })
+	return clusters
+}
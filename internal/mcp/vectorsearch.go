@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+	mcpsdk "github.com/mark3labs/mcp-go/mcp"
+)
+
+func (s *Server) handleMemorySearchVector(ctx context.Context, req mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	projectID := stringArg(req, "project_id")
+	limit := intArg(req, "limit", 10)
+	includeDrafts := boolArg(req, "include_drafts", false)
+
+	if projectID == "" {
+		return mcpsdk.NewToolResultError("project_id and vector are required"), nil
+	}
+	vec, err := vectorArg(req, "vector")
+	if err != nil {
+		return mcpsdk.NewToolResultError(err.Error()), nil
+	}
+	emb := s.embedder(store.EntityMemory)
+	if !emb.Enabled() || !s.projectEmbeds(ctx, projectID) {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("project '%s' has no memory embeddings to search; use memory_search", projectID)), nil
+	}
+	if len(vec) != emb.Dim() {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("vector has %d dimensions; memory embeddings have %d", len(vec), emb.Dim())), nil
+	}
+
+	var results []store.Memory
+	err = s.runSearch(ctx, vec, func(ctx context.Context, vec store.Vector) (err error) {
+		results, err = s.store.SearchMemories(ctx, projectID, "", vec, limit, includeDrafts)
+		return err
+	})
+	if err != nil {
+		return mcpsdk.NewToolResultError(fmt.Sprintf("search memories: %v", err)), nil
+	}
+	response := map[string]any{}
+	if minScore := s.memoryMinScore(ctx, req, projectID); minScore > 0 {
+		kept := results[:0]
+		for _, m := range results {
+			if m.Score >= minScore {
+				kept = append(kept, m)
+			}
+		}
+		results = kept
+		response["min_score"] = minScore
+	}
+	response["search_type"] = "semantic (vector)"
+	response["count"] = len(results)
+	response["results"] = results
+
+	s.recordUsage(ctx, "memory_search_vector", projectID, "", len(results))
+	s.notifySearch("memory_search_vector", projectID, "", "semantic (vector)", memoryHits(results))
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return mcpsdk.NewToolResultText(string(data)), nil
+}
+
+// vectorArg reads a vector argument given as a JSON array of numbers,
+// either as the array itself or as a string holding it.
+func vectorArg(req mcpsdk.CallToolRequest, name string) (store.Vector, error) {
+	raw, ok := req.Params.Arguments[name]
+	if !ok || raw == "" {
+		return nil, fmt.Errorf("%s is required", name)
+	}
+	var data []byte
+	if text, isString := raw.(string); isString {
+		data = []byte(text)
+	} else {
+		data, _ = json.Marshal(raw)
+	}
+	var vec store.Vector
+	if err := json.Unmarshal(data, &vec); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON array of numbers: %v", name, err)
+	}
+	if len(vec) == 0 {
+		return nil, fmt.Errorf("%s must not be empty", name)
+	}
+	return vec, nil
+}
@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+)
+
+// latestSuffix in a memory_get key resolves to the newest version.
+const latestSuffix = "@latest"
+
+// versionedKey names version n of base: base itself for 1, else base@n.
+func versionedKey(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return fmt.Sprintf("%s@%d", base, n)
+}
+
+// keyVersion parses the version of key relative to base; false when key
+// is not a version of base.
+func keyVersion(base, key string) (int, bool) {
+	if key == base {
+		return 1, true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(key, base+"@"))
+	if err != nil || !strings.HasPrefix(key, base+"@") || n < 2 {
+		return 0, false
+	}
+	return n, true
+}
+
+// hasVersionSuffix reports whether key already names a specific version.
+func hasVersionSuffix(key string) bool {
+	i := strings.LastIndex(key, "@")
+	if i < 0 {
+		return false
+	}
+	_, err := strconv.Atoi(key[i+1:])
+	return err == nil
+}
+
+// latestMemoryVersion returns the newest version of base and its number,
+// or nil and 0 when no version exists.
+func (s *Server) latestMemoryVersion(ctx context.Context, projectID, topic, base string) (*store.Memory, int, error) {
+	versions, err := s.store.ListMemoryVersions(ctx, projectID, topic, base)
+	if err != nil {
+		return nil, 0, err
+	}
+	var latest *store.Memory
+	newest := 0
+	for i := range versions {
+		if n, ok := keyVersion(base, versions[i].Key); ok && n > newest {
+			latest, newest = &versions[i], n
+		}
+	}
+	return latest, newest, nil
+}
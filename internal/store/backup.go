@@ -0,0 +1,259 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BackupFormat is the version of the backup stream written by Backup.
+const BackupFormat = 1
+
+// backupTables lists the tables in a backup, parents before children so a
+// restore satisfies the foreign keys as it goes.
+var backupTables = []string{"projects", "memories", "memory_deletions", "sessions", "file_index", "usage_stats"}
+
+// embeddingColumns are dropped from rows by a backup without embeddings.
+var embeddingColumns = []string{"embedding", "embedding_model", "query_embedding"}
+
+// BackupManifest describes a backup. The first line of the stream holds
+// it without Counts; the last line repeats it with Counts, so a truncated
+// backup is detected on restore.
+type BackupManifest struct {
+	Format     int            `json:"format"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Migration  string         `json:"migration"` // newest schema migration applied
+	Embeddings bool           `json:"embeddings"`
+	Counts     map[string]int `json:"counts,omitempty"`
+}
+
+// backupRecord is one line of a backup stream: a manifest, a table row,
+// or the closing manifest.
+type backupRecord struct {
+	Manifest *BackupManifest `json:"manifest,omitempty"`
+	Table    string          `json:"table,omitempty"`
+	Row      json.RawMessage `json:"row,omitempty"`
+	End      *BackupManifest `json:"end,omitempty"`
+}
+
+// RestoreResult counts rows restored and rows skipped because they
+// already existed, per table.
+type RestoreResult struct {
+	Manifest BackupManifest `json:"manifest"`
+	Restored map[string]int `json:"restored"`
+	Skipped  map[string]int `json:"skipped"`
+}
+
+// Backup streams every project, memory, session, file, and usage stat to
+// w as JSON lines, one row per line, from a single consistent snapshot.
+// Rows are the table columns as JSON, so a restore into a newer schema
+// fills added columns with their defaults. Session content kept in the
+// content store is inlined so the backup stands on its own.
+func (s *PostgresStore) Backup(ctx context.Context, w io.Writer, embeddings bool) (*BackupManifest, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	m := &BackupManifest{Format: BackupFormat, CreatedAt: time.Now().UTC(), Embeddings: embeddings && s.vectorEnabled}
+	if err := tx.QueryRow(ctx, `SELECT coalesce(max(version), '') FROM schema_migrations`).Scan(&m.Migration); err != nil {
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(backupRecord{Manifest: m}); err != nil {
+		return nil, err
+	}
+	m.Counts = map[string]int{}
+	for _, table := range backupTables {
+		row := `to_jsonb(t)`
+		if !m.Embeddings {
+			row += ` - '{` + strings.Join(embeddingColumns, ",") + `}'::text[]`
+		}
+		rows, err := tx.Query(ctx, `SELECT `+row+` FROM `+table+` t ORDER BY t.id`)
+		if err != nil {
+			return nil, fmt.Errorf("back up %s: %w", table, err)
+		}
+		for rows.Next() {
+			var data json.RawMessage
+			if err := rows.Scan(&data); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("back up %s: %w", table, err)
+			}
+			if table == "sessions" {
+				if data, err = s.inlineContent(ctx, data); err != nil {
+					rows.Close()
+					return nil, err
+				}
+			}
+			if err := enc.Encode(backupRecord{Table: table, Row: data}); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			m.Counts[table]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("back up %s: %w", table, err)
+		}
+	}
+	if err := enc.Encode(backupRecord{End: m}); err != nil {
+		return nil, err
+	}
+	return m, bw.Flush()
+}
+
+// inlineContent replaces a session row's content_ref with the content it
+// points to.
+func (s *PostgresStore) inlineContent(ctx context.Context, row json.RawMessage) (json.RawMessage, error) {
+	var cols map[string]json.RawMessage
+	if err := json.Unmarshal(row, &cols); err != nil {
+		return nil, err
+	}
+	var meta map[string]any
+	json.Unmarshal(cols["metadata"], &meta)
+	if _, ok := meta[MetaContentRef]; !ok {
+		return row, nil
+	}
+	var num int
+	json.Unmarshal(cols["session_num"], &num)
+	sess := &Session{SessionNum: num, Metadata: meta}
+	if err := s.loadContent(ctx, sess); err != nil {
+		return nil, err
+	}
+	delete(meta, MetaContentRef)
+	cols["content"], _ = json.Marshal(sess.Content)
+	cols["metadata"], _ = json.Marshal(meta)
+	return json.Marshal(cols)
+}
+
+// Restore loads a stream written by Backup in one transaction. Rows that
+// collide with existing ones (same id or natural key) are skipped, so
+// restoring into a non-empty database only adds what is missing. A
+// stream without its closing manifest is rejected as truncated.
+func (s *PostgresStore) Restore(ctx context.Context, r io.Reader) (*RestoreResult, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var first backupRecord
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if first.Manifest == nil {
+		return nil, fmt.Errorf("not a devmemory backup: missing manifest")
+	}
+	if first.Manifest.Format > BackupFormat {
+		return nil, fmt.Errorf("backup format %d is newer than this build supports (%d)", first.Manifest.Format, BackupFormat)
+	}
+	result := &RestoreResult{Manifest: *first.Manifest, Restored: map[string]int{}, Skipped: map[string]int{}}
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		columns := map[string][]string{}
+		for _, table := range backupTables {
+			cols, err := tableColumns(ctx, tx, table)
+			if err != nil {
+				return err
+			}
+			columns[table] = cols
+		}
+
+		var end *BackupManifest
+		for {
+			var rec backupRecord
+			if err := dec.Decode(&rec); err == io.EOF {
+				return fmt.Errorf("backup is truncated: no closing manifest")
+			} else if err != nil {
+				return fmt.Errorf("read backup: %w", err)
+			}
+			if rec.End != nil {
+				end = rec.End
+				break
+			}
+			known, ok := columns[rec.Table]
+			if !ok {
+				return fmt.Errorf("backup has rows for unknown table %q", rec.Table)
+			}
+			restored, err := restoreRow(ctx, tx, rec.Table, known, rec.Row)
+			if err != nil {
+				return err
+			}
+			if restored {
+				result.Restored[rec.Table]++
+			} else {
+				result.Skipped[rec.Table]++
+			}
+		}
+		for table, n := range end.Counts {
+			if got := result.Restored[table] + result.Skipped[table]; got != n {
+				return fmt.Errorf("backup is incomplete: %s has %d rows, manifest says %d", table, got, n)
+			}
+		}
+		result.Manifest.Counts = end.Counts
+
+		// Restored ids are explicit; move each sequence past them.
+		for _, table := range backupTables {
+			if _, err := tx.Exec(ctx,
+				`SELECT setval(pg_get_serial_sequence($1, 'id'), max(id)) FROM `+table+` HAVING max(id) IS NOT NULL`,
+				table); err != nil {
+				return fmt.Errorf("reset %s id sequence: %w", table, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.stats.invalidate()
+	return result, nil
+}
+
+// tableColumns returns the columns of table in the current schema.
+func tableColumns(ctx context.Context, tx pgx.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT column_name FROM information_schema.columns
+		 WHERE table_schema = current_schema() AND table_name = $1`, table)
+	if err != nil {
+		return nil, fmt.Errorf("read %s columns: %w", table, err)
+	}
+	cols, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("read %s columns: %w", table, err)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s not found; run the migrations first", table)
+	}
+	return cols, nil
+}
+
+// restoreRow inserts one backed-up row, using only the columns present in
+// both the row and the table so that missing ones take their defaults.
+// It reports false when the row already exists.
+func restoreRow(ctx context.Context, tx pgx.Tx, table string, known []string, row json.RawMessage) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(row, &fields); err != nil {
+		return false, fmt.Errorf("read %s row: %w", table, err)
+	}
+	var cols []string
+	for c := range fields {
+		if slices.Contains(known, c) {
+			cols = append(cols, pgx.Identifier{c}.Sanitize())
+		}
+	}
+	slices.Sort(cols)
+	list := strings.Join(cols, ", ")
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO `+table+` (`+list+`)
+		 SELECT `+list+` FROM jsonb_populate_record(NULL::`+table+`, $1)
+		 ON CONFLICT DO NOTHING`, string(row))
+	if err != nil {
+		return false, fmt.Errorf("restore %s row: %w", table, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
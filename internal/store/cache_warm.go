@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WarmCaches precomputes the dashboard stats and project centroids so the
+// next reader finds them cached. Dashboard stats are skipped when their
+// cache is disabled.
+func (s *PostgresStore) WarmCaches(ctx context.Context) error {
+	s.stats.mu.Lock()
+	ttl := s.stats.ttl
+	s.stats.mu.Unlock()
+	if ttl > 0 {
+		if _, err := s.GetDashboardStats(ctx); err != nil {
+			return err
+		}
+	}
+	_, err := s.ProjectCentroids(ctx)
+	return err
+}
+
+// RunCacheWarmer calls WarmCaches every interval until ctx is cancelled,
+// starting at once so the first dashboard load after a restart is not
+// the one that pays for the queries.
+func RunCacheWarmer(ctx context.Context, s Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := s.WarmCaches(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("cache warm failed", "error", err)
+		} else {
+			slog.Debug("caches warmed", "duration", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
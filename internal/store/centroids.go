@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// centroidCache holds the last ProjectCentroids result. It is tagged with
+// the stats cache generation, which every write bumps, so it is reused
+// until the next write.
+type centroidCache struct {
+	mu        sync.Mutex
+	centroids map[string]Vector
+	gen       uint64
+	valid     bool
+}
+
+// generation returns the current write generation of c.
+func (c *statsCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// ProjectCentroids returns the mean of each project's memory embeddings
+// of the current model version. Projects without any are left out. The
+// result is cached until the next write; treat it as read-only.
+func (s *PostgresStore) ProjectCentroids(ctx context.Context) (map[string]Vector, error) {
+	if !s.vectorEnabled {
+		return nil, nil
+	}
+	gen := s.stats.generation()
+	s.centroids.mu.Lock()
+	defer s.centroids.mu.Unlock()
+	if s.centroids.valid && s.centroids.gen == gen {
+		return s.centroids.centroids, nil
+	}
+
+	rows, err := s.query(ctx,
+		`SELECT project_id, avg(embedding)::text FROM memories
+		 WHERE embedding IS NOT NULL AND `+currentModel(1)+`
+		 GROUP BY project_id`, s.modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	centroids := map[string]Vector{}
+	for rows.Next() {
+		var projectID, text string
+		if err := rows.Scan(&projectID, &text); err != nil {
+			return nil, err
+		}
+		v, err := parseVector(text)
+		if err != nil {
+			return nil, err
+		}
+		centroids[projectID] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.centroids.centroids, s.centroids.gen, s.centroids.valid = centroids, gen, true
+	return centroids, nil
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultContentThreshold is the session content size, in bytes, above
+// which content goes to the content store instead of Postgres.
+const DefaultContentThreshold = 64 << 10
+
+// MetaContentRef is the session metadata key holding the content store
+// key of content kept outside Postgres.
+const MetaContentRef = "content_ref"
+
+// ContentStore keeps large session content outside the database. Keys
+// are slash-separated; see sessionContentKey. Deleting a key that does
+// not exist is not an error.
+type ContentStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// OpenContentStore returns the content store for rawURL: file:///dir (or
+// a bare path) for a local directory, or s3://bucket/prefix for an
+// S3-compatible bucket. S3 takes ?endpoint= (default AWS) and ?region=
+// (default AWS_REGION, else us-east-1) and reads AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN for credentials.
+func OpenContentStore(rawURL string) (ContentStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse content store url: %w", err)
+	}
+	switch u.Scheme {
+	case "", "file":
+		dir := u.Path
+		if dir == "" {
+			return nil, fmt.Errorf("content store url %q has no directory", rawURL)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create content store dir: %w", err)
+		}
+		return &fileContentStore{dir: dir}, nil
+	case "s3":
+		return newS3ContentStore(u)
+	default:
+		return nil, fmt.Errorf("unsupported content store scheme %q (use file or s3)", u.Scheme)
+	}
+}
+
+// sessionContentKey is the content store key for one session's content:
+// sessions/<project>/<session_num>/<content hash>. The hash keeps a new
+// version from overwriting the old one before the row points at it.
+func sessionContentKey(projectID string, num int, content string) string {
+	return "sessions/" + url.PathEscape(projectID) + "/" + strconv.Itoa(num) + "/" + sha256Hex([]byte(content))
+}
+
+// fileContentStore keeps each object as a file under dir.
+type fileContentStore struct {
+	dir string
+}
+
+func (f *fileContentStore) Put(_ context.Context, key string, data []byte) error {
+	path := filepath.Join(f.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *fileContentStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (f *fileContentStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(filepath.Join(f.dir, filepath.FromSlash(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// s3ContentStore talks to an S3-compatible API with path-style requests
+// signed with AWS Signature Version 4.
+type s3ContentStore struct {
+	endpoint string // scheme://host, no trailing slash
+	bucket   string
+	prefix   string
+	region   string
+	key      string
+	secret   string
+	token    string
+	client   *http.Client
+}
+
+func newS3ContentStore(u *url.URL) (*s3ContentStore, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("content store url %q has no bucket", u.String())
+	}
+	q := u.Query()
+	region := q.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := strings.TrimRight(q.Get("endpoint"), "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	s := &s3ContentStore{
+		endpoint: endpoint,
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		region:   region,
+		key:      os.Getenv("AWS_ACCESS_KEY_ID"),
+		secret:   os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+	if s.key == "" || s.secret == "" {
+		return nil, fmt.Errorf("s3 content store needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if s.prefix != "" {
+		s.prefix += "/"
+	}
+	return s, nil
+}
+
+func (s *s3ContentStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3ContentStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete relies on S3 answering 204 for a key that does not exist.
+func (s *s3ContentStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a signed request for one object and fails on a non-2xx status.
+func (s *s3ContentStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/"+s.bucket+"/"+s.prefix+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// sign adds SigV4 headers for a request without query parameters. Host
+// and every header already on req are signed.
+func (s *s3ContentStore) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if s.token != "" {
+		req.Header.Set("x-amz-security-token", s.token)
+	}
+
+	values := map[string]string{"host": req.URL.Host}
+	for name, v := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{req.Method, req.URL.EscapedPath(), "", headers.String(), signed, payloadHash}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	k := hmacSHA256([]byte("AWS4"+s.secret), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		k = hmacSHA256(k, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.key, scope, signed, hex.EncodeToString(hmacSHA256(k, toSign))))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// dsnPassword matches the password of a key/value connection string,
+// quoted or bare.
+var dsnPassword = regexp.MustCompile(`password\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+
+// RedactDSN masks the password in a connection string, URL or key/value
+// form, so it can appear in logs and error messages.
+func RedactDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// Unparseable: mask everything between the scheme and the host.
+			scheme, rest, _ := strings.Cut(dsn, "://")
+			if i := strings.LastIndex(rest, "@"); i >= 0 {
+				return scheme + "://xxxxx@" + rest[i+1:]
+			}
+			return dsn
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", "xxxxx")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password=xxxxx")
+}
+
+// redactedError is an error whose message has had a connection string
+// masked; the original stays reachable through errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError masks dsn wherever it appears in err's message.
+func redactError(err error, dsn string) error {
+	if err == nil || dsn == "" {
+		return err
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, dsn) {
+		return err
+	}
+	return &redactedError{msg: strings.ReplaceAll(msg, dsn, RedactDSN(dsn)), err: err}
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EmbeddingExportFormat is the version of the stream written by
+// ExportEmbeddings.
+const EmbeddingExportFormat = 1
+
+// embeddingExportEntities are the entities in an embeddings export, in
+// stream order.
+var embeddingExportEntities = []string{EntityMemory, EntitySession, EntityFile}
+
+// EmbeddingManifest describes an embeddings export. Like a backup, the
+// stream starts with it and ends with it repeated with Counts.
+type EmbeddingManifest struct {
+	Format    int            `json:"format"`
+	ProjectID string         `json:"project_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Counts    map[string]int `json:"counts,omitempty"`
+}
+
+// embeddingRecord is one line of an embeddings export: a manifest, one
+// row's vector, or the closing manifest. Embedding is the vector as a
+// JSON array, which is also pgvector's text form.
+type embeddingRecord struct {
+	Manifest  *EmbeddingManifest `json:"manifest,omitempty"`
+	Entity    string             `json:"entity,omitempty"`
+	ID        int64              `json:"id,omitempty"`
+	Model     *string            `json:"model,omitempty"`
+	Embedding json.RawMessage    `json:"embedding,omitempty"`
+	End       *EmbeddingManifest `json:"end,omitempty"`
+}
+
+// EmbeddingImportResult counts vectors loaded and vectors skipped because
+// their row does not exist, per entity.
+type EmbeddingImportResult struct {
+	Manifest EmbeddingManifest `json:"manifest"`
+	Imported map[string]int    `json:"imported"`
+	Skipped  map[string]int    `json:"skipped"`
+}
+
+// ExportEmbeddings streams the stored vectors of a project's memories,
+// sessions, and files to w as JSON lines keyed by entity and row id, with
+// their model version. Together with a backup made without embeddings it
+// restores the project in full; without it, the vectors are regenerated
+// by a reindex.
+func (s *PostgresStore) ExportEmbeddings(ctx context.Context, projectID string, w io.Writer) (*EmbeddingManifest, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector is not available; there are no embeddings to export")
+	}
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	m := &EmbeddingManifest{Format: EmbeddingExportFormat, ProjectID: projectID, CreatedAt: time.Now().UTC()}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(embeddingRecord{Manifest: m}); err != nil {
+		return nil, err
+	}
+	m.Counts = map[string]int{}
+	for _, entity := range embeddingExportEntities {
+		rows, err := tx.Query(ctx,
+			`SELECT id, embedding_model, embedding::text FROM `+entityTables[entity]+`
+			 WHERE project_id=$1 AND embedding IS NOT NULL ORDER BY id`, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("export %s embeddings: %w", entity, err)
+		}
+		for rows.Next() {
+			rec := embeddingRecord{Entity: entity}
+			var vec string
+			if err := rows.Scan(&rec.ID, &rec.Model, &vec); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("export %s embeddings: %w", entity, err)
+			}
+			rec.Embedding = json.RawMessage(vec)
+			if err := enc.Encode(rec); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			m.Counts[entity]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("export %s embeddings: %w", entity, err)
+		}
+	}
+	if err := enc.Encode(embeddingRecord{End: m}); err != nil {
+		return nil, err
+	}
+	return m, bw.Flush()
+}
+
+// ImportEmbeddings loads a stream written by ExportEmbeddings for the same
+// project into its existing rows, in one transaction, replacing their
+// vectors and model versions. Content is never touched; vectors whose row
+// no longer exists are skipped. A stream without its closing manifest is
+// rejected as truncated.
+func (s *PostgresStore) ImportEmbeddings(ctx context.Context, projectID string, r io.Reader) (*EmbeddingImportResult, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector is not available; embeddings cannot be imported")
+	}
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var first embeddingRecord
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if first.Manifest == nil {
+		return nil, fmt.Errorf("not a devmemory embeddings export: missing manifest")
+	}
+	if first.Manifest.Format > EmbeddingExportFormat {
+		return nil, fmt.Errorf("embeddings export format %d is newer than this build supports (%d)", first.Manifest.Format, EmbeddingExportFormat)
+	}
+	if first.Manifest.ProjectID != projectID {
+		return nil, fmt.Errorf("embeddings export is of project %q, not %q", first.Manifest.ProjectID, projectID)
+	}
+	result := &EmbeddingImportResult{Manifest: *first.Manifest, Imported: map[string]int{}, Skipped: map[string]int{}}
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		for {
+			var rec embeddingRecord
+			if err := dec.Decode(&rec); err == io.EOF {
+				return fmt.Errorf("embeddings export is truncated: no closing manifest")
+			} else if err != nil {
+				return fmt.Errorf("read embeddings export: %w", err)
+			}
+			if rec.End != nil {
+				for entity, n := range rec.End.Counts {
+					if got := result.Imported[entity] + result.Skipped[entity]; got != n {
+						return fmt.Errorf("embeddings export is incomplete: %s has %d vectors, manifest says %d", entity, got, n)
+					}
+				}
+				result.Manifest.Counts = rec.End.Counts
+				return nil
+			}
+			table, ok := entityTables[rec.Entity]
+			if !ok {
+				return fmt.Errorf("embeddings export has vectors for unknown entity %q", rec.Entity)
+			}
+			tag, err := tx.Exec(ctx,
+				`UPDATE `+table+` SET embedding=$1::vector, embedding_model=$2
+				 WHERE id=$3 AND project_id=$4`,
+				string(rec.Embedding), rec.Model, rec.ID, projectID)
+			if err != nil {
+				return fmt.Errorf("import %s %d embedding: %w", rec.Entity, rec.ID, err)
+			}
+			if tag.RowsAffected() == 1 {
+				result.Imported[rec.Entity]++
+			} else {
+				result.Skipped[rec.Entity]++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.stats.invalidate()
+	return result, nil
+}
@@ -6,13 +6,43 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// noTransaction is the directive that runs a migration outside a
+// transaction, one statement at a time, as CREATE INDEX CONCURRENTLY needs.
+var noTransaction = regexp.MustCompile(`(?m)^\s*--\s*devmemory:no-transaction\s*$`)
+
+// requiresVector is the directive that marks a migration as pgvector DDL.
+// On a server without the extension it is skipped and left unrecorded,
+// so the schema works in keyword-only mode and a later run, once pgvector
+// is installed, applies it. Such migrations must be idempotent.
+var requiresVector = regexp.MustCompile(`(?m)^\s*--\s*devmemory:requires-vector\s*$`)
+
 // RunMigrations executes SQL migration files from the given directory.
-func RunMigrations(ctx context.Context, pool *pgxpool.Pool, dir string) error {
+// Each file runs in a transaction together with its schema_migrations row,
+// so a failing file leaves nothing half-applied; see noTransaction for the
+// exception, and requiresVector for migrations skipped without pgvector.
+// The pool's search_path decides where tables land; a
+// non-public schema is created first so that the path resolves. Errors
+// never carry the pool's connection string unredacted.
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool, dir, schema string) error {
+	return redactError(runMigrations(ctx, pool, dir, schema), pool.Config().ConnString())
+}
+
+func runMigrations(ctx context.Context, pool *pgxpool.Pool, dir, schema string) error {
+	if schema != "" && schema != "public" {
+		if _, err := pool.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+pgx.Identifier{schema}.Sanitize()); err != nil {
+			return fmt.Errorf("create schema %s: %w", schema, err)
+		}
+	}
+
 	// Create migrations tracking table
 	_, err := pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
@@ -30,6 +60,7 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool, dir string) error {
 	}
 	sort.Strings(files)
 
+	var vectorAvailable *bool // looked up at the first requiresVector file
 	for _, f := range files {
 		version := filepath.Base(f)
 
@@ -51,20 +82,143 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool, dir string) error {
 			return fmt.Errorf("read migration %s: %w", version, err)
 		}
 
-		slog.Info("applying migration", "version", version)
-		_, err = pool.Exec(ctx, string(sql))
-		if err != nil {
-			return fmt.Errorf("apply migration %s: %w", version, err)
+		if requiresVector.Match(sql) {
+			if vectorAvailable == nil {
+				var available bool
+				if err := pool.QueryRow(ctx,
+					`SELECT EXISTS(SELECT 1 FROM pg_available_extensions WHERE name='vector')`).Scan(&available); err != nil {
+					return fmt.Errorf("check vector extension: %w", err)
+				}
+				vectorAvailable = &available
+			}
+			if !*vectorAvailable {
+				slog.Warn("skipping migration: pgvector is not available on this server; semantic search stays off until it is installed and migrations run again", "version", version)
+				continue
+			}
 		}
 
-		// Record
-		_, err = pool.Exec(ctx,
-			`INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+		if noTransaction.Match(sql) {
+			slog.Info("applying migration", "version", version, "transaction", false)
+			err = applyStatements(ctx, pool, version, string(sql))
+		} else {
+			slog.Info("applying migration", "version", version)
+			err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, string(sql)); err != nil {
+					return fmt.Errorf("apply migration %s: %w", version, err)
+				}
+				return recordMigration(ctx, tx, version)
+			})
+		}
 		if err != nil {
-			return fmt.Errorf("record migration %s: %w", version, err)
+			return err
 		}
 	}
 
 	slog.Info("migrations complete")
 	return nil
 }
+
+// applyStatements runs a no-transaction migration statement by statement:
+// a multi-statement query would still run in an implicit transaction.
+// The file is recorded only once every statement has succeeded, so its
+// statements should be idempotent (IF NOT EXISTS) to allow a rerun.
+func applyStatements(ctx context.Context, pool *pgxpool.Pool, version, sql string) error {
+	for i, stmt := range splitStatements(sql) {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("apply migration %s (statement %d): %w", version, i+1, err)
+		}
+	}
+	return recordMigration(ctx, pool, version)
+}
+
+func recordMigration(ctx context.Context, db interface {
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+}, version string) error {
+	if _, err := db.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return fmt.Errorf("record migration %s: %w", version, err)
+	}
+	return nil
+}
+
+// splitStatements splits SQL on semicolons that are outside quotes,
+// dollar-quoted bodies, and comments. Empty statements are dropped.
+func splitStatements(sql string) []string {
+	var stmts []string
+	start := 0
+	add := func(end int) {
+		if stmt := strings.TrimSpace(sql[start:end]); stmt != "" && !onlyComments(stmt) {
+			stmts = append(stmts, stmt)
+		}
+	}
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			// '' and "" escape the quote, which the scan handles as a
+			// close followed by a reopen.
+			if j := strings.IndexByte(sql[i+1:], c); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			depth := 0
+			for ; i < len(sql); i++ {
+				if strings.HasPrefix(sql[i:], "/*") {
+					depth++
+					i++
+				} else if strings.HasPrefix(sql[i:], "*/") {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		case c == '$':
+			if tag := dollarTag(sql[i:]); tag != "" {
+				if j := strings.Index(sql[i+len(tag):], tag); j >= 0 {
+					i += len(tag) + j + len(tag) - 1
+				} else {
+					i = len(sql)
+				}
+			}
+		case c == ';':
+			add(i)
+			start = i + 1
+		}
+	}
+	add(len(sql))
+	return stmts
+}
+
+// dollarTag returns the opening tag ($$ or $name$) at the start of s, or
+// "" when s does not start one (e.g. a $1 parameter).
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (i > 1 && c >= '0' && c <= '9'):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// onlyComments reports whether stmt holds nothing but -- comments.
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -3,27 +3,184 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/Platform-LSS/devmemory/internal/access"
+	"github.com/Platform-LSS/devmemory/internal/summary"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostgresStore struct {
 	pool *pgxpool.Pool
+
+	// vectorEnabled is false when the pgvector extension is missing. The
+	// store then runs keyword-only: embeddings are ignored on write and
+	// every search takes the full-text branch.
+	vectorEnabled bool
+
+	// sessionWeights are the keyword-search rank weights for session
+	// title, summary, and content (ts_rank labels A, B, C).
+	sessionWeights [3]float32
+
+	// stats caches GetDashboardStats; see SetStatsCacheTTL.
+	stats statsCache
+
+	// centroids caches ProjectCentroids until the next write.
+	centroids centroidCache
+
+	// logQueries enables debug logging of SQL; see SetQueryLogging.
+	logQueries bool
+
+	// content receives session content larger than contentThreshold
+	// bytes; nil keeps all content inline. See SetContentStore.
+	content          ContentStore
+	contentThreshold int
+
+	// modelVersion tags stored embeddings; vectors from another version
+	// count as unembedded. See SetModelVersion.
+	modelVersion string
+
+	// synonyms expands keyword search terms; see SetSynonyms.
+	synonyms Synonyms
+
+	// searchAllConcurrency is how many projects SearchAll searches at
+	// once; see SetSearchAllConcurrency.
+	searchAllConcurrency int
+
+	// summaryThreshold is the memory value length above which a summary
+	// is stored; 0 disables summaries. See SetMemorySummaries.
+	summaryThreshold int
 }
 
-func NewPostgresStore(ctx context.Context, databaseURL string) (*PostgresStore, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+func NewPostgresStore(ctx context.Context, databaseURL, schema string) (*PostgresStore, error) {
+	pool, err := NewPool(ctx, databaseURL, schema)
 	if err != nil {
-		return nil, fmt.Errorf("connect to database: %w", err)
+		return nil, err
 	}
 	if err := pool.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("ping database: %w", err)
+		pool.Close()
+		return nil, redactError(fmt.Errorf("ping database %s: %w", RedactDSN(databaseURL), err), databaseURL)
+	}
+	slog.Info("connected to PostgreSQL", "database", RedactDSN(databaseURL))
+
+	s := &PostgresStore{pool: pool, sessionWeights: DefaultSessionWeights}
+	s.stats.ttl = DefaultStatsCacheTTL
+	// The embedding columns come from a migration that is skipped without
+	// pgvector, so the extension alone is not enough.
+	if err := pool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM pg_extension WHERE extname='vector')
+		    AND EXISTS(SELECT 1 FROM information_schema.columns
+		               WHERE table_schema=current_schema() AND table_name='memories' AND column_name='embedding')`).
+		Scan(&s.vectorEnabled); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("check vector extension: %w", err)
+	}
+	if !s.vectorEnabled {
+		slog.Warn("pgvector extension or embedding columns missing; running in keyword-only mode (install pgvector and run migrations again to enable semantic search)")
+	}
+	return s, nil
+}
+
+// DefaultStatsCacheTTL is how long dashboard stats are reused by default.
+const DefaultStatsCacheTTL = 5 * time.Second
+
+// DefaultSessionWeights match Postgres' own ts_rank defaults for labels A, B, C.
+var DefaultSessionWeights = [3]float32{1.0, 0.4, 0.2}
+
+// SetSessionWeights sets the keyword rank weights for session title,
+// summary, and content.
+func (s *PostgresStore) SetSessionWeights(title, summary, content float32) {
+	s.sessionWeights = [3]float32{title, summary, content}
+}
+
+// SetContentStore moves session content larger than threshold bytes to
+// cs on write, leaving a MetaContentRef in the session's metadata.
+// GetSession reads it back transparently. Sessions written earlier stay
+// as they are.
+func (s *PostgresStore) SetContentStore(cs ContentStore, threshold int) {
+	s.content, s.contentThreshold = cs, threshold
+}
+
+// SetSearchAllConcurrency sets how many projects SearchAll searches in
+// parallel, each on its own pool connection. Values below 1 mean one at a
+// time.
+func (s *PostgresStore) SetSearchAllConcurrency(n int) {
+	s.searchAllConcurrency = n
+}
+
+// SetMemorySummaries stores an extractive summary with every memory
+// whose value is longer than threshold characters, for list views.
+// Memories written earlier get one the next time they are written.
+func (s *PostgresStore) SetMemorySummaries(threshold int) {
+	s.summaryThreshold = threshold
+}
+
+// memorySummaryChars is the length budget of a memory summary.
+const memorySummaryChars = 300
+
+// memorySummary returns the summary stored with value, or "" if value is
+// short enough to show whole.
+func (s *PostgresStore) memorySummary(value string) string {
+	if s.summaryThreshold <= 0 || utf8.RuneCountInString(value) <= s.summaryThreshold {
+		return ""
+	}
+	return summary.Extractive(value, memorySummaryChars)
+}
+
+// SetModelVersion sets the embedding model version stamped on every
+// embedding written from now on. Searches, samples, and coverage counts
+// skip vectors of any other version until they are reindexed. The empty
+// version matches vectors stored before versions were tracked.
+func (s *PostgresStore) SetModelVersion(v string) {
+	s.modelVersion = v
+}
+
+// currentModel is the SQL condition for a row whose embedding came from
+// the configured model version, bound to parameter $n.
+func currentModel(n int) string {
+	return fmt.Sprintf("embedding_model IS NOT DISTINCT FROM NULLIF($%d, '')", n)
+}
+
+// VectorEnabled reports whether the pgvector extension is available.
+func (s *PostgresStore) VectorEnabled() bool {
+	return s.vectorEnabled
+}
+
+// NewPool creates a connection pool whose search_path points at schema.
+// An empty schema means "public". Non-public schemas keep public on the
+// path so extension types such as vector still resolve.
+func NewPool(ctx context.Context, databaseURL, schema string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, redactError(fmt.Errorf("parse database url: %w", err), databaseURL)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = searchPath(schema)
+	// Probe idle connections often enough that ones killed by a restart or
+	// proxy are usually evicted before a request picks them up.
+	cfg.HealthCheckPeriod = 15 * time.Second
+	cfg.ConnConfig.Tracer = queryTimer{}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, redactError(fmt.Errorf("connect to database %s: %w", RedactDSN(databaseURL), err), databaseURL)
 	}
-	slog.Info("connected to PostgreSQL")
-	return &PostgresStore{pool: pool}, nil
+	return pool, nil
+}
+
+func searchPath(schema string) string {
+	if schema == "" || schema == "public" {
+		return "public"
+	}
+	return pgx.Identifier{schema}.Sanitize() + ", public"
 }
 
 func (s *PostgresStore) Close() {
@@ -34,7 +191,7 @@ func (s *PostgresStore) Close() {
 
 func (s *PostgresStore) CreateProject(ctx context.Context, p *Project) error {
 	meta, _ := json.Marshal(p.Metadata)
-	_, err := s.pool.Exec(ctx,
+	_, err := s.exec(ctx,
 		`INSERT INTO projects (id, name, root_path, metadata)
 		 VALUES ($1, $2, $3, $4)
 		 ON CONFLICT (id) DO UPDATE SET name=$2, root_path=$3, metadata=$4, updated_at=now()`,
@@ -42,10 +199,22 @@ func (s *PostgresStore) CreateProject(ctx context.Context, p *Project) error {
 	return err
 }
 
+// EnsureProject creates a minimal project named after id unless it
+// already exists, and reports whether it created one. An existing
+// project is left untouched.
+func (s *PostgresStore) EnsureProject(ctx context.Context, id string) (bool, error) {
+	tag, err := s.exec(ctx,
+		`INSERT INTO projects (id, name) VALUES ($1, $1) ON CONFLICT (id) DO NOTHING`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (s *PostgresStore) GetProject(ctx context.Context, id string) (*Project, error) {
 	p := &Project{}
 	var meta []byte
-	err := s.pool.QueryRow(ctx,
+	err := s.queryRow(ctx,
 		`SELECT id, name, root_path, metadata, created_at, updated_at FROM projects WHERE id=$1`, id).
 		Scan(&p.ID, &p.Name, &p.RootPath, &meta, &p.CreatedAt, &p.UpdatedAt)
 	if err == pgx.ErrNoRows {
@@ -58,8 +227,11 @@ func (s *PostgresStore) GetProject(ctx context.Context, id string) (*Project, er
 	return p, nil
 }
 
+// ListProjects returns the projects the access scope of ctx allows, which
+// also limits SearchAll.
 func (s *PostgresStore) ListProjects(ctx context.Context) ([]Project, error) {
-	rows, err := s.pool.Query(ctx,
+	scope := access.FromContext(ctx)
+	rows, err := s.query(ctx,
 		`SELECT id, name, root_path, metadata, created_at, updated_at FROM projects ORDER BY name`)
 	if err != nil {
 		return nil, err
@@ -73,43 +245,111 @@ func (s *PostgresStore) ListProjects(ctx context.Context) ([]Project, error) {
 			return nil, err
 		}
 		json.Unmarshal(meta, &p.Metadata)
-		projects = append(projects, p)
+		if scope.Allows(p.ID) {
+			projects = append(projects, p)
+		}
 	}
 	return projects, nil
 }
 
 // --- Memories ---
 
+// memoryColumns is the column list scanned by scanMemory.
+const memoryColumns = `id, project_id, topic, key, value, summary, created_at, updated_at, created_by, status, tags`
+
+// scanMemory scans memoryColumns plus any extra destinations (e.g. score).
+func scanMemory(row pgx.Row, m *Memory, extra ...any) error {
+	dest := append([]any{&m.ID, &m.ProjectID, &m.Topic, &m.Key, &m.Value, &m.Summary, &m.CreatedAt, &m.UpdatedAt, &m.CreatedBy, &m.Status, &m.Tags}, extra...)
+	return row.Scan(dest...)
+}
+
+// SetMemory upserts a memory. An empty Status means published; an update
+// takes the new status, so an agent rewrite of a published memory goes
+// back to draft for review.
 func (s *PostgresStore) SetMemory(ctx context.Context, m *Memory, embedding Vector) error {
-	var embStr *string
-	if embedding != nil {
-		es := vectorToString(embedding)
-		embStr = &es
-	}
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO memories (project_id, topic, key, value, embedding, created_by)
-		 VALUES ($1, $2, $3, $4, $5::vector, $6)
+	status := m.Status
+	if status == "" {
+		status = MemoryPublished
+	}
+	if !s.vectorEnabled {
+		_, err := s.exec(ctx,
+			`INSERT INTO memories (project_id, topic, key, value, created_by, status, summary)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 ON CONFLICT (project_id, topic, key) DO UPDATE
+			 SET value=$4, status=$6, summary=$7, updated_at=now()`,
+			m.ProjectID, m.Topic, m.Key, m.Value, m.CreatedBy, status, s.memorySummary(m.Value))
+		return err
+	}
+	_, err := s.exec(ctx,
+		`INSERT INTO memories (project_id, topic, key, value, embedding, embedding_model, created_by, status, summary)
+		 VALUES ($1, $2, $3, $4, $5::vector, NULLIF($8, ''), $6, $7, $9)
 		 ON CONFLICT (project_id, topic, key) DO UPDATE
-		 SET value=$4, embedding=COALESCE($5::vector, memories.embedding), updated_at=now()`,
-		m.ProjectID, m.Topic, m.Key, m.Value, embStr, m.CreatedBy)
+		 SET value=$4, embedding=COALESCE($5::vector, memories.embedding),
+		     embedding_model=CASE WHEN $5::vector IS NULL THEN memories.embedding_model ELSE NULLIF($8, '') END,
+		     status=$7, summary=$9, updated_at=now()`,
+		m.ProjectID, m.Topic, m.Key, m.Value, vectorArg(embedding), m.CreatedBy, status, s.modelVersion, s.memorySummary(m.Value))
 	return err
 }
 
+func (s *PostgresStore) CreateMemory(ctx context.Context, m *Memory, embedding Vector) (bool, error) {
+	status := m.Status
+	if status == "" {
+		status = MemoryPublished
+	}
+	var tag pgconn.CommandTag
+	var err error
+	if !s.vectorEnabled {
+		tag, err = s.exec(ctx,
+			`INSERT INTO memories (project_id, topic, key, value, created_by, status, summary)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 ON CONFLICT (project_id, topic, key) DO NOTHING`,
+			m.ProjectID, m.Topic, m.Key, m.Value, m.CreatedBy, status, s.memorySummary(m.Value))
+	} else {
+		tag, err = s.exec(ctx,
+			`INSERT INTO memories (project_id, topic, key, value, embedding, embedding_model, created_by, status, summary)
+			 VALUES ($1, $2, $3, $4, $5::vector, NULLIF($8, ''), $6, $7, $9)
+			 ON CONFLICT (project_id, topic, key) DO NOTHING`,
+			m.ProjectID, m.Topic, m.Key, m.Value, vectorArg(embedding), m.CreatedBy, status, s.modelVersion, s.memorySummary(m.Value))
+	}
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (s *PostgresStore) GetMemory(ctx context.Context, projectID, topic, key string) (*Memory, error) {
 	m := &Memory{}
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, project_id, topic, key, value, created_at, updated_at, created_by
+	err := scanMemory(s.queryRow(ctx,
+		`SELECT `+memoryColumns+`
 		 FROM memories WHERE project_id=$1 AND topic=$2 AND key=$3`,
-		projectID, topic, key).
-		Scan(&m.ID, &m.ProjectID, &m.Topic, &m.Key, &m.Value, &m.CreatedAt, &m.UpdatedAt, &m.CreatedBy)
+		projectID, topic, key), m)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
 	return m, err
 }
 
+// GetMemoryEmbedding returns the stored vector of a memory, or nil when the
+// memory does not exist or has no embedding of the current model version.
+func (s *PostgresStore) GetMemoryEmbedding(ctx context.Context, projectID, topic, key string) (Vector, error) {
+	if !s.vectorEnabled {
+		return nil, nil
+	}
+	var vec *string
+	err := s.queryRow(ctx,
+		`SELECT embedding::text FROM memories WHERE project_id=$1 AND topic=$2 AND key=$3 AND `+currentModel(4),
+		projectID, topic, key, s.modelVersion).Scan(&vec)
+	if err == pgx.ErrNoRows || (err == nil && vec == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseVector(*vec)
+}
+
 func (s *PostgresStore) ListMemories(ctx context.Context, projectID, topic string) ([]Memory, error) {
-	query := `SELECT id, project_id, topic, key, value, created_at, updated_at, created_by
+	query := `SELECT ` + memoryColumns + `
 		 FROM memories WHERE project_id=$1`
 	args := []any{projectID}
 	if topic != "" {
@@ -117,7 +357,7 @@ func (s *PostgresStore) ListMemories(ctx context.Context, projectID, topic strin
 		args = append(args, topic)
 	}
 	query += ` ORDER BY topic, key`
-	rows, err := s.pool.Query(ctx, query, args...)
+	rows, err := s.query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +365,7 @@ func (s *PostgresStore) ListMemories(ctx context.Context, projectID, topic strin
 	var memories []Memory
 	for rows.Next() {
 		var m Memory
-		if err := rows.Scan(&m.ID, &m.ProjectID, &m.Topic, &m.Key, &m.Value, &m.CreatedAt, &m.UpdatedAt, &m.CreatedBy); err != nil {
+		if err := scanMemory(rows, &m); err != nil {
 			return nil, err
 		}
 		memories = append(memories, m)
@@ -134,41 +374,29 @@ func (s *PostgresStore) ListMemories(ctx context.Context, projectID, topic strin
 }
 
 func (s *PostgresStore) DeleteMemory(ctx context.Context, projectID, topic, key string) error {
-	_, err := s.pool.Exec(ctx,
-		`DELETE FROM memories WHERE project_id=$1 AND topic=$2 AND key=$3`,
+	_, err := s.exec(ctx,
+		`WITH gone AS (
+		     DELETE FROM memories WHERE project_id=$1 AND topic=$2 AND key=$3
+		     RETURNING id, project_id, topic, key)
+		 `+recordDeletions,
 		projectID, topic, key)
 	return err
 }
 
-func (s *PostgresStore) SearchMemories(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]Memory, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-
-	// Semantic search if embedding provided, otherwise full-text search
-	var sqlQuery string
-	var args []any
-
-	if embedding != nil {
-		embStr := vectorToString(embedding)
-		sqlQuery = `SELECT id, project_id, topic, key, value, created_at, updated_at, created_by,
-			    1 - (embedding <=> $2::vector) AS score
-			    FROM memories
-			    WHERE project_id=$1 AND embedding IS NOT NULL
-			    ORDER BY embedding <=> $2::vector
-			    LIMIT $3`
-		args = []any{projectID, embStr, limit}
-	} else {
-		sqlQuery = `SELECT id, project_id, topic, key, value, created_at, updated_at, created_by,
-			    ts_rank(to_tsvector('english', value), websearch_to_tsquery('english', $2)) AS score
-			    FROM memories
-			    WHERE project_id=$1 AND to_tsvector('english', value) @@ websearch_to_tsquery('english', $2)
-			    ORDER BY score DESC
-			    LIMIT $3`
-		args = []any{projectID, query, limit}
-	}
+// recordDeletions completes a "WITH gone AS (DELETE ... RETURNING id,
+// project_id, topic, key)" statement by writing a tombstone per deleted
+// memory for memory_changes.
+const recordDeletions = `INSERT INTO memory_deletions (memory_id, project_id, topic, key)
+		 SELECT id, project_id, topic, key FROM gone`
 
-	rows, err := s.pool.Query(ctx, sqlQuery, args...)
+// ListMemoriesChangedSince returns the memories of a project created or
+// updated at or after since, oldest change first. Tagging does not count
+// as a change; see TagMemories.
+func (s *PostgresStore) ListMemoriesChangedSince(ctx context.Context, projectID string, since time.Time) ([]Memory, error) {
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+` FROM memories
+		 WHERE project_id=$1 AND updated_at >= $2 ORDER BY updated_at, id`,
+		projectID, since)
 	if err != nil {
 		return nil, err
 	}
@@ -176,315 +404,1644 @@ func (s *PostgresStore) SearchMemories(ctx context.Context, projectID string, qu
 	var memories []Memory
 	for rows.Next() {
 		var m Memory
-		if err := rows.Scan(&m.ID, &m.ProjectID, &m.Topic, &m.Key, &m.Value, &m.CreatedAt, &m.UpdatedAt, &m.CreatedBy, &m.Score); err != nil {
+		if err := scanMemory(rows, &m); err != nil {
 			return nil, err
 		}
 		memories = append(memories, m)
 	}
-	return memories, nil
-}
-
-// --- Sessions ---
-
-func (s *PostgresStore) CreateSession(ctx context.Context, sess *Session, embedding Vector) error {
-	meta, _ := json.Marshal(sess.Metadata)
-	var embStr *string
-	if embedding != nil {
-		es := vectorToString(embedding)
-		embStr = &es
-	}
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO sessions (project_id, session_num, title, summary, content, embedding, metadata)
-		 VALUES ($1, $2, $3, $4, $5, $6::vector, $7)
-		 ON CONFLICT (project_id, session_num) DO UPDATE
-		 SET title=$3, summary=$4, content=$5, embedding=COALESCE($6::vector, sessions.embedding), metadata=$7`,
-		sess.ProjectID, sess.SessionNum, sess.Title, sess.Summary, sess.Content, embStr, meta)
-	return err
-}
-
-func (s *PostgresStore) GetSession(ctx context.Context, projectID string, sessionNum int) (*Session, error) {
-	sess := &Session{}
-	var meta []byte
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, project_id, session_num, title, summary, content, metadata, created_at
-		 FROM sessions WHERE project_id=$1 AND session_num=$2`,
-		projectID, sessionNum).
-		Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &sess.Content, &meta, &sess.CreatedAt)
-	if err == pgx.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	json.Unmarshal(meta, &sess.Metadata)
-	return sess, nil
+	return memories, rows.Err()
 }
 
-func (s *PostgresStore) ListSessions(ctx context.Context, projectID string) ([]Session, error) {
-	rows, err := s.pool.Query(ctx,
-		`SELECT id, project_id, session_num, title, summary, metadata, created_at
-		 FROM sessions WHERE project_id=$1 ORDER BY session_num`, projectID)
+// ListMemoryDeletionsSince returns the tombstones of memories of a
+// project deleted at or after since, oldest first.
+func (s *PostgresStore) ListMemoryDeletionsSince(ctx context.Context, projectID string, since time.Time) ([]MemoryDeletion, error) {
+	rows, err := s.query(ctx,
+		`SELECT memory_id, topic, key, deleted_at FROM memory_deletions
+		 WHERE project_id=$1 AND deleted_at >= $2 ORDER BY deleted_at, id`,
+		projectID, since)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var sessions []Session
+	var deletions []MemoryDeletion
 	for rows.Next() {
-		var sess Session
-		var meta []byte
-		if err := rows.Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &meta, &sess.CreatedAt); err != nil {
+		var d MemoryDeletion
+		if err := rows.Scan(&d.MemoryID, &d.Topic, &d.Key, &d.DeletedAt); err != nil {
 			return nil, err
 		}
-		json.Unmarshal(meta, &sess.Metadata)
-		sessions = append(sessions, sess)
+		deletions = append(deletions, d)
 	}
-	return sessions, nil
+	return deletions, rows.Err()
 }
 
-func (s *PostgresStore) SearchSessions(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]Session, error) {
+func (s *PostgresStore) SearchMemories(ctx context.Context, projectID string, query string, embedding Vector, limit int, includeDrafts bool) ([]Memory, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
+	statusFilter := ` AND status='published'`
+	if includeDrafts {
+		statusFilter = ""
+	}
+
+	// Semantic search if embedding provided, otherwise full-text search
 	var sqlQuery string
 	var args []any
 
-	if embedding != nil {
+	if embedding != nil && s.vectorEnabled {
 		embStr := vectorToString(embedding)
-		sqlQuery = `SELECT id, project_id, session_num, title, summary, metadata, created_at,
+		sqlQuery = `SELECT ` + memoryColumns + `,
 			    1 - (embedding <=> $2::vector) AS score
-			    FROM sessions
-			    WHERE project_id=$1 AND embedding IS NOT NULL
+			    FROM memories
+			    WHERE project_id=$1 AND embedding IS NOT NULL AND ` + currentModel(4) + statusFilter + `
 			    ORDER BY embedding <=> $2::vector
 			    LIMIT $3`
-		args = []any{projectID, embStr, limit}
+		args = []any{projectID, embStr, limit, s.modelVersion}
 	} else {
-		sqlQuery = `SELECT id, project_id, session_num, title, summary, metadata, created_at,
-			    ts_rank(to_tsvector('english', coalesce(title,'') || ' ' || coalesce(summary,'') || ' ' || coalesce(content,'')),
-			    websearch_to_tsquery('english', $2)) AS score
-			    FROM sessions
-			    WHERE project_id=$1
-			    AND to_tsvector('english', coalesce(title,'') || ' ' || coalesce(summary,'') || ' ' || coalesce(content,''))
-			    @@ websearch_to_tsquery('english', $2)
+		args = []any{projectID, query, limit}
+		tsq := s.keywordQuery(2, &args)
+		sqlQuery = `SELECT ` + memoryColumns + `,
+			    ts_rank(to_tsvector('english', value), ` + tsq + `) AS score
+			    FROM memories
+			    WHERE project_id=$1 AND to_tsvector('english', value) @@ ` + tsq + statusFilter + `
 			    ORDER BY score DESC
 			    LIMIT $3`
-		args = []any{projectID, query, limit}
 	}
 
-	rows, err := s.pool.Query(ctx, sqlQuery, args...)
+	rows, err := s.query(ctx, sqlQuery, args...)
 	if err != nil {
-		slog.Error("session search query failed", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
-	var sessions []Session
+	var memories []Memory
 	for rows.Next() {
-		var sess Session
-		var meta []byte
-		if err := rows.Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &meta, &sess.CreatedAt, &sess.Score); err != nil {
+		var m Memory
+		if err := scanMemory(rows, &m, &m.Score); err != nil {
 			return nil, err
 		}
-		json.Unmarshal(meta, &sess.Metadata)
-		sessions = append(sessions, sess)
+		memories = append(memories, m)
 	}
-	return sessions, nil
+	return memories, nil
 }
 
-// --- File Index ---
-
-func (s *PostgresStore) IndexFile(ctx context.Context, f *FileEntry, embedding Vector) error {
-	symbols, _ := json.Marshal(f.Symbols)
-	var embStr *string
-	if embedding != nil {
-		es := vectorToString(embedding)
-		embStr = &es
-	}
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO file_index (project_id, file_path, file_type, symbols, summary, embedding)
-		 VALUES ($1, $2, $3, $4, $5, $6::vector)
-		 ON CONFLICT (project_id, file_path) DO UPDATE
-		 SET file_type=$3, symbols=$4, summary=$5, embedding=COALESCE($6::vector, file_index.embedding), last_indexed=now()`,
-		f.ProjectID, f.FilePath, f.FileType, symbols, f.Summary, embStr)
-	return err
-}
+// ErrInvalidTSQuery is returned by SearchMemoriesTSQuery when Postgres
+// rejects the query syntax.
+var ErrInvalidTSQuery = errors.New("invalid tsquery")
 
-func (s *PostgresStore) SearchFiles(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]FileEntry, error) {
+// SearchMemoriesTSQuery is a full-text memory search with a raw tsquery
+// (to_tsquery syntax: &, |, !, <->, :*, parentheses), ranked like the
+// keyword branch of SearchMemories.
+func (s *PostgresStore) SearchMemoriesTSQuery(ctx context.Context, projectID, tsquery string, limit int, includeDrafts bool) ([]Memory, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-
-	var sqlQuery string
-	var args []any
-
-	if embedding != nil {
-		embStr := vectorToString(embedding)
-		sqlQuery = `SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed,
-			    1 - (embedding <=> $2::vector) AS score
-			    FROM file_index
-			    WHERE project_id=$1 AND embedding IS NOT NULL
-			    ORDER BY embedding <=> $2::vector
-			    LIMIT $3`
-		args = []any{projectID, embStr, limit}
-	} else {
-		sqlQuery = `SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed,
-			    ts_rank(to_tsvector('english', coalesce(summary,'')), websearch_to_tsquery('english', $2)) AS score
-			    FROM file_index
-			    WHERE project_id=$1
-			    AND to_tsvector('english', coalesce(summary,'')) @@ websearch_to_tsquery('english', $2)
-			    ORDER BY score DESC
-			    LIMIT $3`
-		args = []any{projectID, query, limit}
+	statusFilter := ` AND status='published'`
+	if includeDrafts {
+		statusFilter = ""
 	}
-
-	rows, err := s.pool.Query(ctx, sqlQuery, args...)
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+`,
+		    ts_rank(to_tsvector('english', value), to_tsquery('english', $2)) AS score
+		 FROM memories
+		 WHERE project_id=$1 AND to_tsvector('english', value) @@ to_tsquery('english', $2)`+statusFilter+`
+		 ORDER BY score DESC
+		 LIMIT $3`, projectID, tsquery, limit)
 	if err != nil {
-		return nil, err
+		return nil, tsqueryError(err)
 	}
 	defer rows.Close()
-	var files []FileEntry
+	var memories []Memory
 	for rows.Next() {
-		var f FileEntry
-		var symbols []byte
-		if err := rows.Scan(&f.ID, &f.ProjectID, &f.FilePath, &f.FileType, &symbols, &f.Summary, &f.LastIndexed, &f.Score); err != nil {
+		var m Memory
+		if err := scanMemory(rows, &m, &m.Score); err != nil {
 			return nil, err
 		}
-		json.Unmarshal(symbols, &f.Symbols)
-		files = append(files, f)
+		memories = append(memories, m)
 	}
-	return files, nil
+	// The query is parsed when the statement runs, so a syntax error may
+	// only show up here.
+	if err := rows.Err(); err != nil {
+		return nil, tsqueryError(err)
+	}
+	return memories, nil
 }
 
-// --- Usage & Dashboard ---
-
-func (s *PostgresStore) RecordUsage(ctx context.Context, u *UsageStat) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO usage_stats (project_id, tool_name, query_text, results_count, tokens_estimated)
-		 VALUES ($1, $2, $3, $4, $5)`,
-		u.ProjectID, u.ToolName, u.QueryText, u.ResultsCount, u.TokensEstimated)
+// tsqueryError wraps a Postgres syntax error in ErrInvalidTSQuery.
+func tsqueryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42601" { // syntax_error
+		return fmt.Errorf("%w: %s", ErrInvalidTSQuery, pgErr.Message)
+	}
 	return err
 }
 
-func (s *PostgresStore) GetDashboardStats(ctx context.Context) (*DashboardStats, error) {
-	ds := &DashboardStats{}
-
-	// Count projects, memories, sessions, files
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM projects`).Scan(&ds.ProjectCount)
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM memories`).Scan(&ds.MemoryCount)
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM sessions`).Scan(&ds.SessionCount)
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM file_index`).Scan(&ds.FileCount)
-
-	// Total usage stats
-	_ = s.pool.QueryRow(ctx,
-		`SELECT coalesce(count(*),0), coalesce(sum(tokens_estimated),0) FROM usage_stats`).
-		Scan(&ds.TotalQueries, &ds.TotalTokensSaved)
-
-	// Last 24h
-	_ = s.pool.QueryRow(ctx,
-		`SELECT coalesce(count(*),0), coalesce(sum(tokens_estimated),0) FROM usage_stats WHERE created_at > now() - interval '24 hours'`).
-		Scan(&ds.QueriesLast24h, &ds.TokensLast24h)
-
-	// Per-project stats
-	projects, err := s.ListProjects(ctx)
+// PublishMemory marks a draft memory as published.
+func (s *PostgresStore) PublishMemory(ctx context.Context, projectID, topic, key string) error {
+	tag, err := s.exec(ctx,
+		`UPDATE memories SET status='published', updated_at=now()
+		 WHERE project_id=$1 AND topic=$2 AND key=$3`,
+		projectID, topic, key)
 	if err != nil {
-		return ds, err
+		return err
 	}
-	for _, p := range projects {
-		ps, err := s.GetProjectStats(ctx, p.ID)
-		if err != nil {
-			continue
-		}
-		ds.Projects = append(ds.Projects, *ps)
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("memory %s/%s not found in project %s", topic, key, projectID)
 	}
-
-	return ds, nil
+	return nil
 }
 
-func (s *PostgresStore) GetProjectStats(ctx context.Context, projectID string) (*ProjectStats, error) {
-	p, err := s.GetProject(ctx, projectID)
-	if err != nil || p == nil {
+// TopicCentroids averages the embeddings of each topic's memories.
+// Topics with no embedded memories are omitted.
+func (s *PostgresStore) TopicCentroids(ctx context.Context, projectID string) ([]TopicCentroid, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector extension not installed")
+	}
+	rows, err := s.query(ctx,
+		`SELECT topic, count(*), avg(embedding)::text
+		 FROM memories WHERE project_id=$1 AND embedding IS NOT NULL AND `+currentModel(2)+`
+		 GROUP BY topic ORDER BY topic`, projectID, s.modelVersion)
+	if err != nil {
 		return nil, err
 	}
-
-	ps := &ProjectStats{Project: *p}
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM memories WHERE project_id=$1`, projectID).Scan(&ps.MemoryCount)
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM sessions WHERE project_id=$1`, projectID).Scan(&ps.SessionCount)
-	_ = s.pool.QueryRow(ctx, `SELECT count(*) FROM file_index WHERE project_id=$1`, projectID).Scan(&ps.FileCount)
-	_ = s.pool.QueryRow(ctx,
-		`SELECT coalesce(count(*),0), coalesce(sum(tokens_estimated),0) FROM usage_stats WHERE project_id=$1`,
-		projectID).Scan(&ps.QueryCount, &ps.TokensSaved)
-
-	return ps, nil
+	defer rows.Close()
+	var centroids []TopicCentroid
+	for rows.Next() {
+		var tc TopicCentroid
+		var vec string
+		if err := rows.Scan(&tc.Topic, &tc.Count, &vec); err != nil {
+			return nil, err
+		}
+		if tc.Centroid, err = parseVector(vec); err != nil {
+			return nil, fmt.Errorf("topic %s centroid: %w", tc.Topic, err)
+		}
+		centroids = append(centroids, tc)
+	}
+	return centroids, rows.Err()
 }
 
-func (s *PostgresStore) SearchAll(ctx context.Context, query string, embedding Vector, limit int) (*SearchAllResult, error) {
-	if limit <= 0 {
-		limit = 10
+// SampleMemoryEmbeddings returns the embeddings of up to limit memories of
+// a project, a random sample when more are embedded, plus the number that
+// are embedded in total.
+func (s *PostgresStore) SampleMemoryEmbeddings(ctx context.Context, projectID string, limit int) ([]MemoryVector, int, error) {
+	if !s.vectorEnabled {
+		return nil, 0, fmt.Errorf("pgvector extension not installed")
 	}
-
-	result := &SearchAllResult{}
-
-	// Get all projects to search across
-	projects, err := s.ListProjects(ctx)
+	rows, err := s.query(ctx,
+		`SELECT topic, key, embedding::text, count(*) OVER ()
+		 FROM memories WHERE project_id=$1 AND embedding IS NOT NULL AND `+currentModel(3)+`
+		 ORDER BY random() LIMIT $2`, projectID, limit, s.modelVersion)
 	if err != nil {
-		return result, err
+		return nil, 0, err
 	}
-
-	for _, p := range projects {
-		memories, err := s.SearchMemories(ctx, p.ID, query, embedding, limit)
-		if err == nil {
-			result.Memories = append(result.Memories, memories...)
-		}
-		sessions, err := s.SearchSessions(ctx, p.ID, query, embedding, limit)
-		if err == nil {
-			result.Sessions = append(result.Sessions, sessions...)
+	defer rows.Close()
+	var vectors []MemoryVector
+	total := 0
+	for rows.Next() {
+		var mv MemoryVector
+		var vec string
+		if err := rows.Scan(&mv.Topic, &mv.Key, &vec, &total); err != nil {
+			return nil, 0, err
 		}
-		files, err := s.SearchFiles(ctx, p.ID, query, embedding, limit)
-		if err == nil {
-			result.Files = append(result.Files, files...)
+		if mv.Embedding, err = parseVector(vec); err != nil {
+			return nil, 0, fmt.Errorf("memory %s/%s embedding: %w", mv.Topic, mv.Key, err)
 		}
+		vectors = append(vectors, mv)
 	}
+	return vectors, total, rows.Err()
+}
 
-	// Sort each slice by score descending and cap at limit
-	sortAndCap := func(n int) int {
-		if n > limit {
-			return limit
-		}
-		return n
+// RenameTopic moves every memory in topic from into topic to. Keys that
+// already exist in the target are left in place and counted as conflicts.
+func (s *PostgresStore) RenameTopic(ctx context.Context, projectID, from, to string) (moved, conflicts int, err error) {
+	tag, err := s.exec(ctx,
+		`UPDATE memories m SET topic=$3, updated_at=now()
+		 WHERE m.project_id=$1 AND m.topic=$2
+		 AND NOT EXISTS (SELECT 1 FROM memories t WHERE t.project_id=$1 AND t.topic=$3 AND t.key=m.key)`,
+		projectID, from, to)
+	if err != nil {
+		return 0, 0, err
 	}
+	err = s.queryRow(ctx,
+		`SELECT count(*) FROM memories WHERE project_id=$1 AND topic=$2`, projectID, from).
+		Scan(&conflicts)
+	return int(tag.RowsAffected()), conflicts, err
+}
 
-	// Sort memories by score desc
-	for i := 0; i < len(result.Memories); i++ {
-		for j := i + 1; j < len(result.Memories); j++ {
-			if result.Memories[j].Score > result.Memories[i].Score {
-				result.Memories[i], result.Memories[j] = result.Memories[j], result.Memories[i]
-			}
-		}
+// MergeMemoryKeys gives the target memory the merged value and the tags
+// of both, then deletes the source, in one statement. The target keeps its
+// created_at. A nil embedding keeps the target's vector. It reports false
+// when either memory does not exist, in which case nothing changes.
+func (s *PostgresStore) MergeMemoryKeys(ctx context.Context, projectID, topic, source, target, value string, embedding Vector) (bool, error) {
+	set := `value=$5, summary=$6, updated_at=now()`
+	args := []any{projectID, topic, source, target, value, s.memorySummary(value)}
+	if s.vectorEnabled {
+		set += `, embedding=COALESCE($7::vector, m.embedding),
+		     embedding_model=CASE WHEN $7::vector IS NULL THEN m.embedding_model ELSE NULLIF($8, '') END`
+		args = append(args, vectorArg(embedding), s.modelVersion)
+	}
+	tag, err := s.exec(ctx,
+		`WITH src AS (
+		     SELECT id, tags FROM memories
+		     WHERE project_id=$1 AND topic=$2 AND key=$3
+		     AND EXISTS (SELECT 1 FROM memories WHERE project_id=$1 AND topic=$2 AND key=$4)),
+		 merged AS (
+		     UPDATE memories m SET `+set+`,
+		         tags=ARRAY(SELECT DISTINCT t FROM unnest(m.tags || src.tags) t ORDER BY t)
+		     FROM src WHERE m.project_id=$1 AND m.topic=$2 AND m.key=$4
+		     RETURNING m.id),
+		 gone AS (
+		     DELETE FROM memories WHERE id IN (SELECT id FROM src)
+		     RETURNING id, project_id, topic, key)
+		 `+recordDeletions,
+		args...)
+	if err != nil {
+		return false, err
 	}
-	result.Memories = result.Memories[:sortAndCap(len(result.Memories))]
+	return tag.RowsAffected() > 0, nil
+}
 
-	// Sort sessions by score desc
-	for i := 0; i < len(result.Sessions); i++ {
-		for j := i + 1; j < len(result.Sessions); j++ {
-			if result.Sessions[j].Score > result.Sessions[i].Score {
-				result.Sessions[i], result.Sessions[j] = result.Sessions[j], result.Sessions[i]
-			}
+// ListDraftMemories returns drafts across all projects the access scope
+// of ctx allows, oldest first, for review.
+func (s *PostgresStore) ListDraftMemories(ctx context.Context) ([]Memory, error) {
+	scope := access.FromContext(ctx)
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+` FROM memories WHERE status='draft' ORDER BY updated_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var memories []Memory
+	for rows.Next() {
+		var m Memory
+		if err := scanMemory(rows, &m); err != nil {
+			return nil, err
+		}
+		if scope.Allows(m.ProjectID) {
+			memories = append(memories, m)
 		}
 	}
-	result.Sessions = result.Sessions[:sortAndCap(len(result.Sessions))]
+	return memories, nil
+}
 
-	// Sort files by score desc
-	for i := 0; i < len(result.Files); i++ {
+// ListMemoryVersions returns key and its versioned siblings key@2, key@3,
+// ... within a topic, in no particular order.
+func (s *PostgresStore) ListMemoryVersions(ctx context.Context, projectID, topic, key string) ([]Memory, error) {
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+` FROM memories
+		 WHERE project_id=$1 AND topic=$2
+		 AND (key=$3 OR (left(key, length($3)+1) = $3 || '@' AND substr(key, length($3)+2) ~ '^[0-9]+$'))`,
+		projectID, topic, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var memories []Memory
+	for rows.Next() {
+		var m Memory
+		if err := scanMemory(rows, &m); err != nil {
+			return nil, err
+		}
+		memories = append(memories, m)
+	}
+	return memories, rows.Err()
+}
+
+// TagMemories adds tag to the given memories of a project and returns how
+// many did not have it yet. Tagging leaves updated_at alone: it labels the
+// memory rather than changing its content, so tags do not reach
+// memory_changes mirrors either.
+func (s *PostgresStore) TagMemories(ctx context.Context, projectID string, ids []int64, tag string) (int, error) {
+	ct, err := s.exec(ctx,
+		`UPDATE memories SET tags = array_append(tags, $3)
+		 WHERE project_id=$1 AND id = ANY($2) AND NOT ($3 = ANY(tags))`,
+		projectID, ids, tag)
+	if err != nil {
+		return 0, err
+	}
+	return int(ct.RowsAffected()), nil
+}
+
+// ListIdleMemories returns memories not written and not read via
+// memory_get since the given time, least recently updated first. Reads
+// are taken from usage_stats, so they only count while usage is recorded.
+func (s *PostgresStore) ListIdleMemories(ctx context.Context, projectID string, since time.Time) ([]Memory, error) {
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+` FROM memories
+		 WHERE project_id=$1 AND updated_at < $2
+		 AND NOT EXISTS (SELECT 1 FROM usage_stats u
+		     WHERE u.project_id=memories.project_id AND u.tool_name='memory_get'
+		     AND u.query_text=memories.topic || '/' || memories.key AND u.created_at >= $2)
+		 ORDER BY updated_at`, projectID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var memories []Memory
+	for rows.Next() {
+		var m Memory
+		if err := scanMemory(rows, &m); err != nil {
+			return nil, err
+		}
+		memories = append(memories, m)
+	}
+	return memories, nil
+}
+
+// --- Sessions ---
+
+// putContent sends content over the threshold to the content store and
+// returns its key, or "" when the content stays inline.
+func (s *PostgresStore) putContent(ctx context.Context, projectID string, num int, content string) (string, error) {
+	if s.content == nil || len(content) <= s.contentThreshold {
+		return "", nil
+	}
+	key := sessionContentKey(projectID, num, content)
+	if err := s.content.Put(ctx, key, []byte(content)); err != nil {
+		return "", fmt.Errorf("store session content: %w", err)
+	}
+	return key, nil
+}
+
+// contentRefs returns the MetaContentRef of every session matching where,
+// a condition on sessions aliased s, so the objects can be released once
+// the rows change. It returns nothing without a content store.
+func (s *PostgresStore) contentRefs(ctx context.Context, where string, args ...any) ([]string, error) {
+	if s.content == nil {
+		return nil, nil
+	}
+	rows, err := s.query(ctx,
+		`SELECT s.metadata->>'`+MetaContentRef+`' FROM sessions s
+		 WHERE s.metadata ? '`+MetaContentRef+`' AND `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("read content refs: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// sessionContentRef is the MetaContentRef of one session, or "".
+func (s *PostgresStore) sessionContentRef(ctx context.Context, projectID string, num int) (string, error) {
+	refs, err := s.contentRefs(ctx, `s.project_id=$1 AND s.session_num=$2`, projectID, num)
+	if err != nil || len(refs) == 0 {
+		return "", err
+	}
+	return refs[0], nil
+}
+
+// releaseContent deletes the content store objects for refs that no
+// session references any more. The rows are already written, so a failed
+// delete is only logged; it leaves an unreferenced object behind.
+func (s *PostgresStore) releaseContent(ctx context.Context, refs ...string) {
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+		var used bool
+		if err := s.queryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM sessions WHERE metadata->>$1 = $2)`, MetaContentRef, ref).Scan(&used); err != nil {
+			slog.Warn("check session content ref", "key", ref, "error", err)
+			continue
+		}
+		if used {
+			continue
+		}
+		if err := s.content.Delete(ctx, ref); err != nil {
+			slog.Warn("delete session content", "key", ref, "error", err)
+		}
+	}
+}
+
+// sessionRow returns the content and metadata columns to write for sess.
+// A MetaContentRef left over from an earlier read is replaced, so the ref
+// always matches the content just written.
+func (s *PostgresStore) sessionRow(ctx context.Context, sess *Session) (string, []byte, error) {
+	ref, err := s.putContent(ctx, sess.ProjectID, sess.SessionNum, sess.Content)
+	if err != nil {
+		return "", nil, err
+	}
+	content, meta := sess.Content, sess.Metadata
+	if _, stale := meta[MetaContentRef]; stale || ref != "" {
+		meta = make(map[string]any, len(sess.Metadata)+1)
+		for k, v := range sess.Metadata {
+			meta[k] = v
+		}
+		delete(meta, MetaContentRef)
+		if ref != "" {
+			content, meta[MetaContentRef] = "", ref
+		}
+	}
+	data, _ := json.Marshal(meta)
+	return content, data, nil
+}
+
+// loadContent fills in session content kept in the content store.
+func (s *PostgresStore) loadContent(ctx context.Context, sess *Session) error {
+	key, ok := sess.Metadata[MetaContentRef].(string)
+	if !ok || sess.Content != "" {
+		return nil
+	}
+	if s.content == nil {
+		return fmt.Errorf("session %d content is in a content store (%s) but none is configured", sess.SessionNum, key)
+	}
+	data, err := s.content.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("load session %d content: %w", sess.SessionNum, err)
+	}
+	sess.Content = string(data)
+	return nil
+}
+
+// CreateSession writes a session, replacing any with the same number; the
+// replaced content's content store object is released.
+func (s *PostgresStore) CreateSession(ctx context.Context, sess *Session, embedding Vector) error {
+	oldRef, err := s.sessionContentRef(ctx, sess.ProjectID, sess.SessionNum)
+	if err != nil {
+		return err
+	}
+	content, meta, err := s.sessionRow(ctx, sess)
+	if err != nil {
+		return err
+	}
+	if !s.vectorEnabled {
+		_, err = s.exec(ctx,
+			`INSERT INTO sessions (project_id, session_num, title, summary, content, metadata)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (project_id, session_num) DO UPDATE
+			 SET title=$3, summary=$4, content=$5, metadata=$6, updated_at=now()`,
+			sess.ProjectID, sess.SessionNum, sess.Title, sess.Summary, content, meta)
+	} else {
+		_, err = s.exec(ctx,
+			`INSERT INTO sessions (project_id, session_num, title, summary, content, embedding, embedding_model, metadata)
+			 VALUES ($1, $2, $3, $4, $5, $6::vector, NULLIF($8, ''), $7)
+			 ON CONFLICT (project_id, session_num) DO UPDATE
+			 SET title=$3, summary=$4, content=$5, embedding=COALESCE($6::vector, sessions.embedding),
+			     embedding_model=CASE WHEN $6::vector IS NULL THEN sessions.embedding_model ELSE NULLIF($8, '') END,
+			     metadata=$7, updated_at=now()`,
+			sess.ProjectID, sess.SessionNum, sess.Title, sess.Summary, content, vectorArg(embedding), meta, s.modelVersion)
+	}
+	if err != nil {
+		return err
+	}
+	s.releaseContent(ctx, oldRef)
+	return nil
+}
+
+// ErrSessionConflict is returned by CreateSessionExpect when the session
+// changed (or was created) since the caller last read it.
+var ErrSessionConflict = errors.New("session was modified concurrently")
+
+// CreateSessionExpect writes a session only if it is unchanged since the
+// caller read it. With expectedUpdatedAt nil the session must not exist
+// yet; otherwise its updated_at must equal *expectedUpdatedAt. A mismatch
+// returns ErrSessionConflict instead of overwriting another writer.
+func (s *PostgresStore) CreateSessionExpect(ctx context.Context, sess *Session, embedding Vector, expectedUpdatedAt *time.Time) error {
+	oldRef, err := s.sessionContentRef(ctx, sess.ProjectID, sess.SessionNum)
+	if err != nil {
+		return err
+	}
+	content, meta, err := s.sessionRow(ctx, sess)
+	if err != nil {
+		return err
+	}
+	args := []any{sess.ProjectID, sess.SessionNum, sess.Title, sess.Summary, content, meta}
+	// Without pgvector the embedding columns are left out entirely.
+	var vecCols, vecVals, vecSet string
+	if s.vectorEnabled {
+		args = append(args, vectorArg(embedding), s.modelVersion)
+		vecCols = ", embedding, embedding_model"
+		vecVals = ", $7::vector, NULLIF($8, '')"
+		vecSet = ", embedding=COALESCE($7::vector, embedding)" +
+			", embedding_model=CASE WHEN $7::vector IS NULL THEN embedding_model ELSE NULLIF($8, '') END"
+	}
+	var sqlQuery string
+	if expectedUpdatedAt == nil {
+		sqlQuery = `INSERT INTO sessions (project_id, session_num, title, summary, content, metadata` + vecCols + `)
+			 VALUES ($1, $2, $3, $4, $5, $6` + vecVals + `)
+			 ON CONFLICT (project_id, session_num) DO NOTHING`
+	} else {
+		args = append(args, *expectedUpdatedAt)
+		sqlQuery = `UPDATE sessions
+			 SET title=$3, summary=$4, content=$5, metadata=$6` + vecSet + `, updated_at=now()
+			 WHERE project_id=$1 AND session_num=$2 AND updated_at=$` + strconv.Itoa(len(args))
+	}
+	tag, err := s.exec(ctx, sqlQuery, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if expectedUpdatedAt == nil {
+			return fmt.Errorf("%w: session %d already exists in project %s", ErrSessionConflict, sess.SessionNum, sess.ProjectID)
+		}
+		return fmt.Errorf("%w: session %d in project %s is missing or was updated after %s",
+			ErrSessionConflict, sess.SessionNum, sess.ProjectID, expectedUpdatedAt.Format(time.RFC3339Nano))
+	}
+	s.releaseContent(ctx, oldRef)
+	return nil
+}
+
+func (s *PostgresStore) GetSession(ctx context.Context, projectID string, sessionNum int) (*Session, error) {
+	sess := &Session{}
+	var meta []byte
+	err := s.queryRow(ctx,
+		`SELECT id, project_id, session_num, title, summary, content, metadata, created_at, updated_at
+		 FROM sessions WHERE project_id=$1 AND session_num=$2`,
+		projectID, sessionNum).
+		Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &sess.Content, &meta, &sess.CreatedAt, &sess.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(meta, &sess.Metadata)
+	if err := s.loadContent(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// GetSessionChunk returns length characters of a session's content from
+// offset, sliced in the database so a large transcript is not read whole.
+// Content kept in the content store is loaded and sliced here instead.
+func (s *PostgresStore) GetSessionChunk(ctx context.Context, projectID string, sessionNum, offset, length int) (*SessionChunk, error) {
+	chunk := &SessionChunk{SessionNum: sessionNum, Offset: offset}
+	var meta []byte
+	err := s.queryRow(ctx,
+		`SELECT substring(coalesce(content,'') FROM $3 + 1 FOR $4), char_length(coalesce(content,'')), metadata
+		 FROM sessions WHERE project_id=$1 AND session_num=$2`,
+		projectID, sessionNum, offset, length).
+		Scan(&chunk.Content, &chunk.Total, &meta)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sess := &Session{SessionNum: sessionNum}
+	json.Unmarshal(meta, &sess.Metadata)
+	if _, ok := sess.Metadata[MetaContentRef]; ok && chunk.Total == 0 {
+		if err := s.loadContent(ctx, sess); err != nil {
+			return nil, err
+		}
+		runes := []rune(sess.Content)
+		chunk.Total = len(runes)
+		chunk.Content = string(runes[min(offset, len(runes)):min(offset+length, len(runes))])
+	}
+	chunk.HasMore = offset+length < chunk.Total
+	return chunk, nil
+}
+
+func (s *PostgresStore) ListSessions(ctx context.Context, projectID string) ([]Session, error) {
+	rows, err := s.query(ctx,
+		`SELECT id, project_id, session_num, title, summary, metadata, created_at, updated_at
+		 FROM sessions WHERE project_id=$1 ORDER BY session_num`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		var meta []byte
+		if err := rows.Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &meta, &sess.CreatedAt, &sess.UpdatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(meta, &sess.Metadata)
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// SessionNumsWithTitle returns the numbers of a project's sessions titled
+// title, ignoring case and surrounding whitespace, lowest first.
+func (s *PostgresStore) SessionNumsWithTitle(ctx context.Context, projectID, title string) ([]int, error) {
+	rows, err := s.query(ctx,
+		`SELECT session_num FROM sessions
+		 WHERE project_id=$1 AND lower(btrim(title)) = lower(btrim($2))
+		 ORDER BY session_num`, projectID, title)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// UpdateSessionMeta edits a session's title, summary, and/or number in place.
+// Nil fields are left unchanged. Renumbering onto an existing session_num
+// is rejected rather than overwriting the other session.
+func (s *PostgresStore) UpdateSessionMeta(ctx context.Context, projectID string, num int, title, summary *string, newNum *int, embedding Vector) error {
+	sqlQuery := `UPDATE sessions
+		 SET title=COALESCE($3, title), summary=COALESCE($4, summary),
+		     session_num=COALESCE($5, session_num), embedding=COALESCE($6::vector, embedding),
+		     embedding_model=CASE WHEN $6::vector IS NULL THEN embedding_model ELSE NULLIF($7, '') END, updated_at=now()
+		 WHERE project_id=$1 AND session_num=$2`
+	args := []any{projectID, num, title, summary, newNum, vectorArg(embedding), s.modelVersion}
+	if !s.vectorEnabled {
+		sqlQuery = `UPDATE sessions
+		 SET title=COALESCE($3, title), summary=COALESCE($4, summary),
+		     session_num=COALESCE($5, session_num), updated_at=now()
+		 WHERE project_id=$1 AND session_num=$2`
+		args = args[:5]
+	}
+	tag, err := s.exec(ctx, sqlQuery, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && newNum != nil {
+			return fmt.Errorf("session %d already exists in project %s", *newNum, projectID)
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("session %d not found in project %s", num, projectID)
+	}
+	return nil
+}
+
+// ArchiveSession clears a session's content, keeping title, summary, and
+// embedding, and records archived_at (and archive_path when the content
+// was exported) in its metadata. Content in the content store is deleted.
+func (s *PostgresStore) ArchiveSession(ctx context.Context, projectID string, num int, archivePath string) error {
+	oldRef, err := s.sessionContentRef(ctx, projectID, num)
+	if err != nil {
+		return err
+	}
+	tag, err := s.exec(ctx,
+		`UPDATE sessions
+		 SET content='', updated_at=now(),
+		     metadata = (coalesce(metadata, '{}') - $6::text) || jsonb_strip_nulls(jsonb_build_object(
+		         $3::text, to_jsonb(now()), $4::text, to_jsonb(NULLIF($5, ''))))
+		 WHERE project_id=$1 AND session_num=$2`,
+		projectID, num, MetaArchivedAt, MetaArchivePath, archivePath, MetaContentRef)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("session %d not found in project %s", num, projectID)
+	}
+	s.releaseContent(ctx, oldRef)
+	return nil
+}
+
+// RestoreSession puts content back into an archived session and removes
+// the archive markers from its metadata.
+func (s *PostgresStore) RestoreSession(ctx context.Context, projectID string, num int, content string) error {
+	oldRef, err := s.sessionContentRef(ctx, projectID, num)
+	if err != nil {
+		return err
+	}
+	ref, err := s.putContent(ctx, projectID, num, content)
+	if err != nil {
+		return err
+	}
+	if ref != "" {
+		content = ""
+	}
+	tag, err := s.exec(ctx,
+		`UPDATE sessions
+		 SET content=$3, updated_at=now(),
+		     metadata = (coalesce(metadata, '{}') - $4::text - $5::text - $6::text)
+		         || jsonb_strip_nulls(jsonb_build_object($6::text, to_jsonb(NULLIF($7, ''))))
+		 WHERE project_id=$1 AND session_num=$2`,
+		projectID, num, content, MetaArchivedAt, MetaArchivePath, MetaContentRef, ref)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("session %d not found in project %s", num, projectID)
+	}
+	if oldRef != ref {
+		s.releaseContent(ctx, oldRef)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SearchSessions(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]Session, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var sqlQuery string
+	var args []any
+
+	if embedding != nil && s.vectorEnabled {
+		embStr := vectorToString(embedding)
+		sqlQuery = `SELECT id, project_id, session_num, title, summary, metadata, created_at, updated_at,
+			    1 - (embedding <=> $2::vector) AS score
+			    FROM sessions
+			    WHERE project_id=$1 AND embedding IS NOT NULL AND ` + currentModel(4) + `
+			    ORDER BY embedding <=> $2::vector
+			    LIMIT $3`
+		args = []any{projectID, embStr, limit, s.modelVersion}
+	} else {
+		// ts_rank weight order is {D, C, B, A}; D is unused
+		w := s.sessionWeights
+		args = []any{projectID, query, limit, []float32{0.1, w[2], w[1], w[0]}}
+		tsq := s.keywordQuery(2, &args)
+		// Rank on a weighted vector so title hits outrank long content; the
+		// filter keeps the plain concatenation so idx_sessions_fts is used.
+		sqlQuery = `SELECT id, project_id, session_num, title, summary, metadata, created_at, updated_at,
+			    ts_rank($4::float4[],
+			    setweight(to_tsvector('english', coalesce(title,'')), 'A') ||
+			    setweight(to_tsvector('english', coalesce(summary,'')), 'B') ||
+			    setweight(to_tsvector('english', coalesce(content,'')), 'C'),
+			    ` + tsq + `) AS score
+			    FROM sessions
+			    WHERE project_id=$1
+			    AND to_tsvector('english', coalesce(title,'') || ' ' || coalesce(summary,'') || ' ' || coalesce(content,''))
+			    @@ ` + tsq + `
+			    ORDER BY score DESC
+			    LIMIT $3`
+	}
+
+	rows, err := s.query(ctx, sqlQuery, args...)
+	if err != nil {
+		slog.Error("session search query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		var meta []byte
+		if err := rows.Scan(&sess.ID, &sess.ProjectID, &sess.SessionNum, &sess.Title, &sess.Summary, &meta, &sess.CreatedAt, &sess.UpdatedAt, &sess.Score); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(meta, &sess.Metadata)
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// --- File Index ---
+
+func (s *PostgresStore) IndexFile(ctx context.Context, f *FileEntry, embedding Vector) error {
+	symbols, _ := json.Marshal(f.Symbols)
+	if !s.vectorEnabled {
+		_, err := s.exec(ctx,
+			`INSERT INTO file_index (project_id, file_path, file_type, symbols, summary)
+			 VALUES ($1, $2, $3, $4, $5)
+			 ON CONFLICT (project_id, file_path) DO UPDATE
+			 SET file_type=$3, symbols=$4, summary=$5, last_indexed=now()`,
+			f.ProjectID, f.FilePath, f.FileType, symbols, f.Summary)
+		return err
+	}
+	_, err := s.exec(ctx,
+		`INSERT INTO file_index (project_id, file_path, file_type, symbols, summary, embedding, embedding_model)
+		 VALUES ($1, $2, $3, $4, $5, $6::vector, NULLIF($7, ''))
+		 ON CONFLICT (project_id, file_path) DO UPDATE
+		 SET file_type=$3, symbols=$4, summary=$5, embedding=COALESCE($6::vector, file_index.embedding),
+		     embedding_model=CASE WHEN $6::vector IS NULL THEN file_index.embedding_model ELSE NULLIF($7, '') END,
+		     last_indexed=now()`,
+		f.ProjectID, f.FilePath, f.FileType, symbols, f.Summary, vectorArg(embedding), s.modelVersion)
+	return err
+}
+
+// DeleteFiles removes the given paths from a project's file index and
+// returns how many were indexed.
+func (s *PostgresStore) DeleteFiles(ctx context.Context, projectID string, paths []string) (int64, error) {
+	tag, err := s.exec(ctx,
+		`DELETE FROM file_index WHERE project_id=$1 AND file_path = ANY($2)`, projectID, paths)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *PostgresStore) ListFiles(ctx context.Context, projectID string) ([]FileEntry, error) {
+	rows, err := s.query(ctx,
+		`SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed
+		 FROM file_index WHERE project_id=$1 ORDER BY file_path`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var files []FileEntry
+	for rows.Next() {
+		var f FileEntry
+		var symbols []byte
+		if err := rows.Scan(&f.ID, &f.ProjectID, &f.FilePath, &f.FileType, &symbols, &f.Summary, &f.LastIndexed); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(symbols, &f.Symbols)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// ListFileTypes counts a project's indexed files per file_type, most
+// common first, without loading the files themselves.
+func (s *PostgresStore) ListFileTypes(ctx context.Context, projectID string) ([]TypeCount, error) {
+	rows, err := s.query(ctx,
+		`SELECT file_type, count(*) FROM file_index WHERE project_id=$1
+		 GROUP BY file_type ORDER BY count(*) DESC, file_type`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var types []TypeCount
+	for rows.Next() {
+		var t TypeCount
+		if err := rows.Scan(&t.FileType, &t.Count); err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// ListTopicCounts returns the topics that currently hold memories, in
+// name order. Topics exist only through their memories, so a topic whose
+// last memory was deleted is not listed.
+func (s *PostgresStore) ListTopicCounts(ctx context.Context, projectID string) ([]TopicCount, error) {
+	rows, err := s.query(ctx,
+		`SELECT topic, count(*), count(*) FILTER (WHERE status=$2), max(updated_at)
+		 FROM memories WHERE project_id=$1
+		 GROUP BY topic ORDER BY topic`, projectID, MemoryDraft)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var topics []TopicCount
+	for rows.Next() {
+		var t TopicCount
+		if err := rows.Scan(&t.Topic, &t.Memories, &t.Drafts, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
+
+func (s *PostgresStore) GetFile(ctx context.Context, projectID, filePath string) (*FileEntry, error) {
+	f := &FileEntry{}
+	var symbols []byte
+	err := s.queryRow(ctx,
+		`SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed
+		 FROM file_index WHERE project_id=$1 AND file_path=$2`, projectID, filePath).
+		Scan(&f.ID, &f.ProjectID, &f.FilePath, &f.FileType, &symbols, &f.Summary, &f.LastIndexed)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(symbols, &f.Symbols)
+	return f, nil
+}
+
+// GetFileEmbedding returns the stored vector of an indexed file, or nil
+// when the file is not indexed or has no embedding of the current model
+// version.
+func (s *PostgresStore) GetFileEmbedding(ctx context.Context, projectID, filePath string) (Vector, error) {
+	if !s.vectorEnabled {
+		return nil, nil
+	}
+	var vec *string
+	err := s.queryRow(ctx,
+		`SELECT embedding::text FROM file_index WHERE project_id=$1 AND file_path=$2 AND `+currentModel(3),
+		projectID, filePath, s.modelVersion).Scan(&vec)
+	if err == pgx.ErrNoRows || (err == nil && vec == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseVector(*vec)
+}
+
+func (s *PostgresStore) SearchFiles(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]FileEntry, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var sqlQuery string
+	var args []any
+
+	if embedding != nil && s.vectorEnabled {
+		embStr := vectorToString(embedding)
+		sqlQuery = `SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed,
+			    1 - (embedding <=> $2::vector) AS score
+			    FROM file_index
+			    WHERE project_id=$1 AND embedding IS NOT NULL AND ` + currentModel(4) + `
+			    ORDER BY embedding <=> $2::vector
+			    LIMIT $3`
+		args = []any{projectID, embStr, limit, s.modelVersion}
+	} else {
+		args = []any{projectID, query, limit}
+		tsq := s.keywordQuery(2, &args)
+		// Summary ranks above path/type; the filter matches idx_files_fts
+		sqlQuery = `SELECT id, project_id, file_path, file_type, symbols, summary, last_indexed,
+			    ts_rank(
+			    setweight(to_tsvector('english', coalesce(summary,'')), 'A') ||
+			    setweight(to_tsvector('english', translate(coalesce(file_path,''), '/._-', '    ') || ' ' || coalesce(file_type,'')), 'C'),
+			    ` + tsq + `) AS score
+			    FROM file_index
+			    WHERE project_id=$1
+			    AND to_tsvector('english', coalesce(summary,'') || ' ' || translate(coalesce(file_path,''), '/._-', '    ') || ' ' || coalesce(file_type,''))
+			    @@ ` + tsq + `
+			    ORDER BY score DESC
+			    LIMIT $3`
+	}
+
+	rows, err := s.query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var files []FileEntry
+	for rows.Next() {
+		var f FileEntry
+		var symbols []byte
+		if err := rows.Scan(&f.ID, &f.ProjectID, &f.FilePath, &f.FileType, &symbols, &f.Summary, &f.LastIndexed, &f.Score); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(symbols, &f.Symbols)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// --- Usage & Dashboard ---
+
+func (s *PostgresStore) RecordUsage(ctx context.Context, u *UsageStat) error {
+	if u.QueryEmbedding != nil && s.vectorEnabled {
+		_, err := s.execKeepStats(ctx,
+			`INSERT INTO usage_stats (project_id, tool_name, query_text, results_count, tokens_estimated, query_embedding, embedding_model)
+			 VALUES ($1, $2, $3, $4, $5, $6::vector, NULLIF($7, ''))`,
+			u.ProjectID, u.ToolName, u.QueryText, u.ResultsCount, u.TokensEstimated, vectorArg(u.QueryEmbedding), s.modelVersion)
+		return err
+	}
+	_, err := s.execKeepStats(ctx,
+		`INSERT INTO usage_stats (project_id, tool_name, query_text, results_count, tokens_estimated)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		u.ProjectID, u.ToolName, u.QueryText, u.ResultsCount, u.TokensEstimated)
+	return err
+}
+
+// SearchUsageQueries finds distinct past queries similar to query. With an
+// embedding it ranks by cosine similarity over query embeddings of the
+// current model version; otherwise it falls back to a substring match
+// ranked by frequency. An empty projectID searches all projects.
+func (s *PostgresStore) SearchUsageQueries(ctx context.Context, projectID, query string, embedding Vector, limit int) ([]UsageQuery, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var sqlQuery string
+	var args []any
+	if embedding != nil && s.vectorEnabled {
+		sqlQuery = `SELECT query_text, count(*), max(created_at),
+			    max(1 - (query_embedding <=> $2::vector)) AS score
+			    FROM usage_stats
+			    WHERE ($1 = '' OR project_id = $1) AND query_embedding IS NOT NULL AND ` + currentModel(4) + `
+			    GROUP BY query_text
+			    ORDER BY score DESC
+			    LIMIT $3`
+		args = []any{projectID, vectorToString(embedding), limit, s.modelVersion}
+	} else {
+		sqlQuery = `SELECT query_text, count(*), max(created_at), 0::float8 AS score
+			    FROM usage_stats
+			    WHERE ($1 = '' OR project_id = $1) AND query_text ILIKE '%' || $2 || '%'
+			    GROUP BY query_text
+			    ORDER BY count(*) DESC, max(created_at) DESC
+			    LIMIT $3`
+		args = []any{projectID, query, limit}
+	}
+
+	rows, err := s.query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queries []UsageQuery
+	for rows.Next() {
+		var q UsageQuery
+		if err := rows.Scan(&q.QueryText, &q.Count, &q.LastAsked, &q.Score); err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// ListUsageQueries returns the most frequent distinct queries embedded by
+// the current model version, each with its embedding, for clustering.
+func (s *PostgresStore) ListUsageQueries(ctx context.Context, projectID string, limit int) ([]UsageQuery, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector extension not installed")
+	}
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := s.query(ctx,
+		`SELECT query_text, count(*), max(created_at), avg(query_embedding)::text
+		 FROM usage_stats
+		 WHERE ($1 = '' OR project_id = $1) AND query_embedding IS NOT NULL AND `+currentModel(3)+`
+		 GROUP BY query_text
+		 ORDER BY count(*) DESC, max(created_at) DESC
+		 LIMIT $2`, projectID, limit, s.modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queries []UsageQuery
+	for rows.Next() {
+		var q UsageQuery
+		var vec string
+		if err := rows.Scan(&q.QueryText, &q.Count, &q.LastAsked, &vec); err != nil {
+			return nil, err
+		}
+		if q.Embedding, err = parseVector(vec); err != nil {
+			return nil, fmt.Errorf("query %q embedding: %w", q.QueryText, err)
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// ListSuccessfulQueries returns a project's distinct past search queries
+// that found something, best average result count first. Given an
+// embedding, each also gets its similarity to it (0 for queries stored
+// without a vector of the current model version).
+func (s *PostgresStore) ListSuccessfulQueries(ctx context.Context, projectID string, embedding Vector, limit int) ([]QueryOutcome, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	score := `0::float8`
+	args := []any{projectID, SearchTools, limit}
+	if embedding != nil && s.vectorEnabled {
+		score = `coalesce(max(1 - (query_embedding <=> $4::vector)) FILTER (WHERE ` + currentModel(5) + `), 0)`
+		args = append(args, vectorToString(embedding), s.modelVersion)
+	}
+	rows, err := s.query(ctx,
+		`SELECT query_text, count(*), avg(results_count)::float8, max(created_at), `+score+`
+		 FROM usage_stats
+		 WHERE project_id = $1 AND tool_name = ANY($2) AND query_text <> ''
+		 GROUP BY query_text
+		 HAVING max(results_count) > 0
+		 ORDER BY avg(results_count) DESC, count(*) DESC
+		 LIMIT $3`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queries []QueryOutcome
+	for rows.Next() {
+		var q QueryOutcome
+		if err := rows.Scan(&q.QueryText, &q.Count, &q.AvgResults, &q.LastAsked, &q.Score); err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// GetDashboardStats returns totals and per-project counts for the
+// projects the access scope of ctx allows. Results are cached for the
+// stats TTL across scopes; each caller gets its own filtered copy.
+func (s *PostgresStore) GetDashboardStats(ctx context.Context) (*DashboardStats, error) {
+	if s.stats.ttl <= 0 {
+		ds, err := s.computeDashboardStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ds.scoped(ctx), nil
+	}
+	if ds, _ := s.stats.get(); ds != nil {
+		return ds.scoped(ctx), nil
+	}
+
+	s.stats.fill.Lock()
+	defer s.stats.fill.Unlock()
+	ds, gen := s.stats.get()
+	if ds != nil {
+		return ds.scoped(ctx), nil
+	}
+	ds, err := s.computeDashboardStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.stats.put(ds, gen)
+	return ds.scoped(ctx), nil
+}
+
+// computeDashboardStats runs two queries, whatever the access scope of
+// ctx: one for usage totals and projectStats for every project's
+// counts.
+func (s *PostgresStore) computeDashboardStats(ctx context.Context) (*DashboardStats, error) {
+	ds := &DashboardStats{}
+
+	_ = s.queryRow(ctx,
+		`SELECT count(*), coalesce(sum(tokens_estimated),0),
+		        count(*) FILTER (WHERE created_at > now() - interval '24 hours'),
+		        coalesce(sum(tokens_estimated) FILTER (WHERE created_at > now() - interval '24 hours'),0)
+		 FROM usage_stats`).
+		Scan(&ds.TotalQueries, &ds.TotalTokensSaved, &ds.QueriesLast24h, &ds.TokensLast24h)
+
+	projects, err := s.projectStats(ctx, "")
+	if err != nil {
+		return ds, err
+	}
+	for _, ps := range projects {
+		ds.Projects = append(ds.Projects, ps)
+		ds.ProjectCount++
+		ds.MemoryCount += ps.MemoryCount
+		ds.SessionCount += ps.SessionCount
+		ds.FileCount += ps.FileCount
+	}
+	return ds, nil
+}
+
+// GetAllProjectStats returns the counts of the projects the access scope
+// of ctx allows, in one round trip; see projectStats.
+func (s *PostgresStore) GetAllProjectStats(ctx context.Context) ([]ProjectStats, error) {
+	all, err := s.projectStats(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	scope := access.FromContext(ctx)
+	var stats []ProjectStats
+	for _, ps := range all {
+		if scope.Allows(ps.Project.ID) {
+			stats = append(stats, ps)
+		}
+	}
+	return stats, nil
+}
+
+// projectStats returns the counts of one project, or of every project when
+// projectID is empty: each metric is a GROUP BY project_id subquery,
+// left-joined so projects with no rows still appear with zeros.
+func (s *PostgresStore) projectStats(ctx context.Context, projectID string) ([]ProjectStats, error) {
+	rows, err := s.query(ctx,
+		`SELECT p.id, p.name, p.root_path, p.metadata, p.created_at, p.updated_at,
+		        coalesce(m.n,0), coalesce(se.n,0), coalesce(f.n,0), coalesce(u.n,0), coalesce(u.tokens,0),
+		        coalesce(u.n24,0), coalesce(u.tokens24,0)
+		 FROM projects p
+		 LEFT JOIN (SELECT project_id, count(*) AS n FROM memories
+		            WHERE $1 = '' OR project_id = $1 GROUP BY project_id) m ON m.project_id=p.id
+		 LEFT JOIN (SELECT project_id, count(*) AS n FROM sessions
+		            WHERE $1 = '' OR project_id = $1 GROUP BY project_id) se ON se.project_id=p.id
+		 LEFT JOIN (SELECT project_id, count(*) AS n FROM file_index
+		            WHERE $1 = '' OR project_id = $1 GROUP BY project_id) f ON f.project_id=p.id
+		 LEFT JOIN (SELECT project_id, count(*) AS n, sum(tokens_estimated) AS tokens,
+		                   count(*) FILTER (WHERE created_at > now() - interval '24 hours') AS n24,
+		                   sum(tokens_estimated) FILTER (WHERE created_at > now() - interval '24 hours') AS tokens24
+		            FROM usage_stats WHERE $1 = '' OR project_id = $1 GROUP BY project_id) u ON u.project_id=p.id
+		 WHERE $1 = '' OR p.id = $1
+		 ORDER BY p.name`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []ProjectStats
+	for rows.Next() {
+		var ps ProjectStats
+		var meta []byte
+		p := &ps.Project
+		if err := rows.Scan(&p.ID, &p.Name, &p.RootPath, &meta, &p.CreatedAt, &p.UpdatedAt,
+			&ps.MemoryCount, &ps.SessionCount, &ps.FileCount, &ps.QueryCount, &ps.TokensSaved,
+			&ps.queriesLast24h, &ps.tokensLast24h); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(meta, &p.Metadata)
+		stats = append(stats, ps)
+	}
+	return stats, rows.Err()
+}
+
+// ListEmbeddingCoverage counts rows without an embedding of the current
+// model version per project and entity in one grouped query. Projects
+// with full coverage are absent. Without pgvector nothing is embedded, so
+// it returns nil.
+func (s *PostgresStore) ListEmbeddingCoverage(ctx context.Context) (map[string]EmbeddingCoverage, error) {
+	if !s.vectorEnabled {
+		return nil, nil
+	}
+	var parts []string
+	for _, entity := range embeddingEntities {
+		parts = append(parts, `SELECT project_id, '`+entity+`', count(*) FROM `+entityTables[entity]+`
+			 WHERE embedding IS NULL OR NOT `+currentModel(1)+` GROUP BY project_id`)
+	}
+	rows, err := s.query(ctx, strings.Join(parts, " UNION ALL "), s.modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	coverage := map[string]EmbeddingCoverage{}
+	for rows.Next() {
+		var projectID, entity string
+		var n int
+		if err := rows.Scan(&projectID, &entity, &n); err != nil {
+			return nil, err
+		}
+		c := coverage[projectID]
+		switch entity {
+		case EntityMemory:
+			c.Memories = n
+		case EntitySession:
+			c.Sessions = n
+		case EntityFile:
+			c.Files = n
+		}
+		coverage[projectID] = c
+	}
+	return coverage, rows.Err()
+}
+
+// GetEmbeddingCoverage counts a project's embedded and total memories,
+// sessions, and files. Without pgvector nothing is embedded.
+func (s *PostgresStore) GetEmbeddingCoverage(ctx context.Context, projectID string) (*CoverageStats, error) {
+	counts := map[string][2]int{}
+	var embedded, total int
+	for _, entity := range embeddingEntities {
+		embeddedExpr := `0`
+		args := []any{projectID}
+		if s.vectorEnabled {
+			embeddedExpr = `count(*) FILTER (WHERE embedding IS NOT NULL AND ` + currentModel(2) + `)`
+			args = append(args, s.modelVersion)
+		}
+		var n [2]int
+		if err := s.queryRow(ctx,
+			`SELECT `+embeddedExpr+`, count(*) FROM `+entityTables[entity]+` WHERE project_id=$1`,
+			args...).Scan(&n[0], &n[1]); err != nil {
+			return nil, fmt.Errorf("count %s embeddings: %w", entity, err)
+		}
+		counts[entity] = n
+		embedded += n[0]
+		total += n[1]
+	}
+	return &CoverageStats{
+		ProjectID: projectID,
+		Memories:  newEntityCoverage(counts[EntityMemory][0], counts[EntityMemory][1]),
+		Sessions:  newEntityCoverage(counts[EntitySession][0], counts[EntitySession][1]),
+		Files:     newEntityCoverage(counts[EntityFile][0], counts[EntityFile][1]),
+		Overall:   newEntityCoverage(embedded, total),
+	}, nil
+}
+
+// GetProjectFootprint sums the bytes a project's memories, sessions, files,
+// and their embeddings take, one aggregate query per table.
+func (s *PostgresStore) GetProjectFootprint(ctx context.Context, projectID string) (*Footprint, error) {
+	embeddingBytes := `0::bigint`
+	if s.vectorEnabled {
+		embeddingBytes = `coalesce(sum(pg_column_size(embedding)), 0)::bigint`
+	}
+	f := &Footprint{ProjectID: projectID}
+	for _, q := range []struct {
+		entity, text string
+		dest         *int64
+	}{
+		{EntityMemory, `octet_length(value) + coalesce(octet_length(summary), 0)`, &f.MemoryBytes},
+		{EntitySession, `octet_length(title) + coalesce(octet_length(summary), 0) + coalesce(octet_length(content), 0)`, &f.SessionBytes},
+		{EntityFile, `coalesce(octet_length(summary), 0) + coalesce(octet_length(symbols::text), 0)`, &f.FileBytes},
+	} {
+		var emb int64
+		if err := s.queryRow(ctx,
+			`SELECT coalesce(sum(`+q.text+`), 0)::bigint, `+embeddingBytes+`
+			 FROM `+entityTables[q.entity]+` WHERE project_id=$1`, projectID).Scan(q.dest, &emb); err != nil {
+			return nil, fmt.Errorf("size %s: %w", q.entity, err)
+		}
+		f.EmbeddingBytes += emb
+	}
+	if err := s.queryRow(ctx,
+		`SELECT count(*) FROM sessions WHERE project_id=$1 AND metadata ? $2`,
+		projectID, MetaContentRef).Scan(&f.ExternalSessions); err != nil {
+		return nil, fmt.Errorf("count external sessions: %w", err)
+	}
+	f.TotalBytes = f.MemoryBytes + f.SessionBytes + f.FileBytes + f.EmbeddingBytes
+	return f, nil
+}
+
+// GetProjectStats returns one project's counts with the query
+// GetAllProjectStats uses, or nil when the project does not exist.
+func (s *PostgresStore) GetProjectStats(ctx context.Context, projectID string) (*ProjectStats, error) {
+	if projectID == "" {
+		return nil, nil
+	}
+	stats, err := s.projectStats(ctx, projectID)
+	if err != nil || len(stats) == 0 {
+		return nil, err
+	}
+	return &stats[0], nil
+}
+
+func (s *PostgresStore) SearchAll(ctx context.Context, query string, vectors QueryVectors, limits SearchLimits, weights SearchWeights) (*SearchAllResult, error) {
+	memLimit := limits.resolve(limits.Memories)
+	sessLimit := limits.resolve(limits.Sessions)
+	fileLimit := limits.resolve(limits.Files)
+	// A page can only be ranked across projects if every project returns
+	// all results up to its end; one more tells whether a next page exists.
+	offset := max(limits.Offset, 0)
+
+	// Get all projects to search across
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	result := &SearchAllResult{}
+	if len(projects) == 0 {
+		result.Warnings = append(result.Warnings, WarnNoProjects)
+		return result, nil
+	}
+
+	// Projects are searched by up to searchAllConcurrency workers. Each
+	// writes only its own slot, and the slots are joined in project order
+	// so equal scores rank the same way on every page.
+	type projectResults struct {
+		memories []Memory
+		sessions []Session
+		files    []FileEntry
+		failed   []string // entities whose search returned an error
+		err      error    // the first of those errors
+	}
+	found := make([]projectResults, len(projects))
+	sem := make(chan struct{}, max(s.searchAllConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, p := range projects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			// A project with embeddings disabled has no vectors to match.
+			v := vectors
+			if !p.EmbeddingsEnabled() {
+				v = QueryVectors{}
+			}
+			r := &found[i]
+			fail := func(entity string, err error) {
+				r.failed = append(r.failed, entity)
+				if r.err == nil {
+					r.err = err
+				}
+			}
+			var err error
+			if r.memories, err = s.SearchMemories(ctx, p.ID, query, v.Memory, offset+memLimit+1, false); err != nil {
+				fail(EntityMemory, err)
+			}
+			if r.sessions, err = s.SearchSessions(ctx, p.ID, query, v.Session, offset+sessLimit+1); err != nil {
+				fail(EntitySession, err)
+			}
+			if r.files, err = s.SearchFiles(ctx, p.ID, query, v.File, offset+fileLimit+1); err != nil {
+				fail(EntityFile, err)
+			}
+		}()
+	}
+	wg.Wait()
+	// A project that failed is reported rather than silently missing; if
+	// every search of every project failed there is no result at all.
+	failures := 0
+	for i, r := range found {
+		result.Memories = append(result.Memories, r.memories...)
+		result.Sessions = append(result.Sessions, r.sessions...)
+		result.Files = append(result.Files, r.files...)
+		if r.err != nil {
+			if len(r.failed) == len(embeddingEntities) {
+				failures++
+			}
+			slog.Warn("search_all project failed", "project", projects[i].ID, "entities", r.failed, "error", r.err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("project %s: %s search failed; its results are missing",
+				projects[i].ID, strings.Join(r.failed, ", ")))
+		}
+	}
+	if failures == len(projects) {
+		return nil, fmt.Errorf("search all projects: %w", found[0].err)
+	}
+
+	// Apply per-type weights before anything is sorted or merged
+	for i := range result.Memories {
+		result.Memories[i].Score *= weightOr1(weights.Memory)
+	}
+	for i := range result.Sessions {
+		result.Sessions[i].Score *= weightOr1(weights.Session)
+	}
+	for i := range result.Files {
+		result.Files[i].Score *= weightOr1(weights.File)
+	}
+
+	// Sort each slice by score descending and keep the page
+	// [offset, offset+limit); anything past it means another page.
+	page := func(n, limit int) (int, int) {
+		if n > offset+limit {
+			result.HasMore = true
+		}
+		return min(offset, n), min(offset+limit, n)
+	}
+
+	// Sort memories by score desc
+	for i := 0; i < len(result.Memories); i++ {
+		for j := i + 1; j < len(result.Memories); j++ {
+			if result.Memories[j].Score > result.Memories[i].Score {
+				result.Memories[i], result.Memories[j] = result.Memories[j], result.Memories[i]
+			}
+		}
+	}
+	from, to := page(len(result.Memories), memLimit)
+	result.Memories = result.Memories[from:to]
+
+	// Sort sessions by score desc
+	for i := 0; i < len(result.Sessions); i++ {
+		for j := i + 1; j < len(result.Sessions); j++ {
+			if result.Sessions[j].Score > result.Sessions[i].Score {
+				result.Sessions[i], result.Sessions[j] = result.Sessions[j], result.Sessions[i]
+			}
+		}
+	}
+	from, to = page(len(result.Sessions), sessLimit)
+	result.Sessions = result.Sessions[from:to]
+
+	// Sort files by score desc
+	for i := 0; i < len(result.Files); i++ {
 		for j := i + 1; j < len(result.Files); j++ {
 			if result.Files[j].Score > result.Files[i].Score {
 				result.Files[i], result.Files[j] = result.Files[j], result.Files[i]
 			}
 		}
 	}
-	result.Files = result.Files[:sortAndCap(len(result.Files))]
+	from, to = page(len(result.Files), fileLimit)
+	result.Files = result.Files[from:to]
+
+	// Merge the capped lists into one ranking by weighted score
+	for _, m := range result.Memories {
+		result.Ranked = append(result.Ranked, SearchHit{EntityMemory, m.ProjectID, m.Topic + "/" + m.Key, m.Score})
+	}
+	for _, sess := range result.Sessions {
+		result.Ranked = append(result.Ranked, SearchHit{EntitySession, sess.ProjectID, sess.Title, sess.Score})
+	}
+	for _, f := range result.Files {
+		result.Ranked = append(result.Ranked, SearchHit{EntityFile, f.ProjectID, f.FilePath, f.Score})
+	}
+	sort.SliceStable(result.Ranked, func(i, j int) bool { return result.Ranked[i].Score > result.Ranked[j].Score })
 
 	return result, nil
 }
 
+// --- Embeddings ---
+
+var entityTables = map[string]string{
+	EntityMemory:  "memories",
+	EntitySession: "sessions",
+	EntityFile:    "file_index",
+}
+
+// SetEmbedding replaces the embedding of a single row without touching its
+// content or timestamps. Used by reindexing.
+func (s *PostgresStore) SetEmbedding(ctx context.Context, entity string, id int64, embedding Vector) error {
+	table, ok := entityTables[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %q", entity)
+	}
+	if !s.vectorEnabled {
+		return fmt.Errorf("pgvector extension not installed")
+	}
+	tag, err := s.exec(ctx,
+		`UPDATE `+table+` SET embedding=$2::vector, embedding_model=NULLIF($3, '') WHERE id=$1`,
+		id, vectorArg(embedding), s.modelVersion)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s %d not found", entity, id)
+	}
+	return nil
+}
+
+// embeddingEntities fixes the order entities are audited in.
+var embeddingEntities = []string{EntityMemory, EntitySession, EntityFile}
+
+// EmbeddingDims counts a project's rows by embedding dimension for each
+// entity, so vectors from a different model can be spotted.
+func (s *PostgresStore) EmbeddingDims(ctx context.Context, projectID string) ([]EmbeddingDimCount, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector extension not installed")
+	}
+	var counts []EmbeddingDimCount
+	for _, entity := range embeddingEntities {
+		rows, err := s.query(ctx,
+			`SELECT coalesce(vector_dims(embedding), 0), count(*) FROM `+entityTables[entity]+`
+			 WHERE project_id=$1 GROUP BY 1 ORDER BY 1`, projectID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			c := EmbeddingDimCount{Entity: entity}
+			if err := rows.Scan(&c.Dim, &c.Count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			counts = append(counts, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// ClearMismatchedEmbeddings sets to NULL every embedding in the project
+// whose dimension is not the one dims gives for its entity, along with its
+// model version, so a reindex regenerates them.
+func (s *PostgresStore) ClearMismatchedEmbeddings(ctx context.Context, projectID string, dims map[string]int) (int64, error) {
+	if !s.vectorEnabled {
+		return 0, fmt.Errorf("pgvector extension not installed")
+	}
+	var total int64
+	for _, entity := range embeddingEntities {
+		tag, err := s.exec(ctx,
+			`UPDATE `+entityTables[entity]+` SET embedding=NULL, embedding_model=NULL
+			 WHERE project_id=$1 AND embedding IS NOT NULL AND vector_dims(embedding) <> $2`, projectID, dims[entity])
+		if err != nil {
+			return total, fmt.Errorf("clear %s embeddings: %w", entity, err)
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
+
+// EmbeddingColumnDims returns the declared dimension of each entity's
+// embedding column; 0 means the column takes any dimension.
+func (s *PostgresStore) EmbeddingColumnDims(ctx context.Context) (map[string]int, error) {
+	if !s.vectorEnabled {
+		return nil, fmt.Errorf("pgvector extension not installed")
+	}
+	dims := map[string]int{}
+	for _, entity := range embeddingEntities {
+		var typmod int
+		if err := s.queryRow(ctx,
+			`SELECT atttypmod FROM pg_attribute
+			 WHERE attrelid = $1::regclass AND attname = 'embedding'`,
+			entityTables[entity]).Scan(&typmod); err != nil {
+			return nil, fmt.Errorf("read %s embedding column: %w", entity, err)
+		}
+		dims[entity] = max(typmod, 0)
+	}
+	return dims, nil
+}
+
+// ResizeEmbeddingColumn changes the dimension of an entity's embedding
+// column for a different model. Existing vectors cannot be converted, so
+// they are dropped for a reindex to regenerate; it returns how many.
+func (s *PostgresStore) ResizeEmbeddingColumn(ctx context.Context, entity string, dim int) (int64, error) {
+	table, ok := entityTables[entity]
+	if !ok {
+		return 0, fmt.Errorf("unknown entity %q", entity)
+	}
+	if dim <= 0 {
+		return 0, fmt.Errorf("invalid dimension %d", dim)
+	}
+	if !s.vectorEnabled {
+		return 0, fmt.Errorf("pgvector extension not installed")
+	}
+	var cleared int64
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `SELECT count(embedding) FROM `+table).Scan(&cleared); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			`ALTER TABLE `+table+` ALTER COLUMN embedding TYPE vector(`+strconv.Itoa(dim)+`) USING NULL`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE `+table+` SET embedding_model=NULL WHERE embedding_model IS NOT NULL`)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("resize %s embeddings: %w", entity, err)
+	}
+	s.stats.invalidate()
+	return cleared, nil
+}
+
+// vectorArg converts an optional embedding into a nullable query argument.
+func vectorArg(v Vector) *string {
+	if v == nil {
+		return nil
+	}
+	es := vectorToString(v)
+	return &es
+}
+
+// parseVector parses a pgvector text literal such as "[0.1,0.2,0.3]".
+func parseVector(s string) (Vector, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("malformed vector literal")
+	}
+	body := s[1 : len(s)-1]
+	if body == "" {
+		return Vector{}, nil
+	}
+	parts := strings.Split(body, ",")
+	v := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(p, 32)
+		if err != nil {
+			return nil, fmt.Errorf("vector element %d: %w", i, err)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
 // vectorToString formats a float32 slice as a pgvector literal: "[0.1,0.2,0.3]"
 func vectorToString(v Vector) string {
 	if len(v) == 0 {
@@ -501,3 +2058,110 @@ func vectorToString(v Vector) string {
 	buf = append(buf, ']')
 	return string(buf)
 }
+
+// --- Retention ---
+
+// retentionPolicies selects (id, days) for projects whose metadata key $1
+// holds a positive day count. The CASE keeps malformed values from
+// failing the cast.
+const retentionPolicies = `SELECT id, days FROM (
+	SELECT id, CASE WHEN metadata->>$1 ~ '^[0-9]{1,6}$' THEN (metadata->>$1)::int END AS days
+	FROM projects) rp
+	WHERE days > 0`
+
+// SweepExpired deletes sessions and memories older than their project's
+// retention policy (see MetaSessionRetentionDays). Sessions age from
+// created_at, memories from their last update. Projects without a positive
+// policy are skipped. Swept sessions' content store objects are deleted.
+func (s *PostgresStore) SweepExpired(ctx context.Context) (sessions, memories int64, err error) {
+	const expired = `s.project_id = p.id AND s.created_at < now() - make_interval(days => p.days)`
+	refs, err := s.contentRefs(ctx,
+		`EXISTS (SELECT 1 FROM (`+retentionPolicies+`) p WHERE `+expired+`)`, MetaSessionRetentionDays)
+	if err != nil {
+		return 0, 0, fmt.Errorf("sweep sessions: %w", err)
+	}
+	tag, err := s.exec(ctx,
+		`DELETE FROM sessions s USING (`+retentionPolicies+`) p WHERE `+expired,
+		MetaSessionRetentionDays)
+	if err != nil {
+		return 0, 0, fmt.Errorf("sweep sessions: %w", err)
+	}
+	sessions = tag.RowsAffected()
+	s.releaseContent(ctx, refs...)
+
+	tag, err = s.exec(ctx,
+		`WITH gone AS (
+		     DELETE FROM memories m USING (`+retentionPolicies+`) p
+		     WHERE m.project_id = p.id
+		       AND m.updated_at < now() - make_interval(days => p.days)
+		     RETURNING m.id, m.project_id, m.topic, m.key)
+		 `+recordDeletions,
+		MetaMemoryRetentionDays)
+	if err != nil {
+		return sessions, 0, fmt.Errorf("sweep memories: %w", err)
+	}
+	return sessions, tag.RowsAffected(), nil
+}
+
+// --- Maintenance ---
+
+// orphanQueries select (id, project_id, label) for rows of each entity
+// whose project no longer exists. The foreign keys cascade, so orphans
+// only appear after constraints were dropped or a restore skipped them.
+var orphanQueries = map[string]string{
+	EntityMemory: `SELECT id, project_id, topic || '/' || key FROM memories
+		WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = memories.project_id) ORDER BY id`,
+	EntitySession: `SELECT id, project_id, session_num::text FROM sessions
+		WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = sessions.project_id) ORDER BY id`,
+	EntityFile: `SELECT id, project_id, file_path FROM file_index
+		WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = file_index.project_id) ORDER BY id`,
+}
+
+// FindOrphans scans memories, sessions, and files for rows whose
+// project_id has no matching project.
+func (s *PostgresStore) FindOrphans(ctx context.Context) (*OrphanReport, error) {
+	report := &OrphanReport{}
+	for entity, dest := range map[string]*[]Orphan{
+		EntityMemory:  &report.Memories,
+		EntitySession: &report.Sessions,
+		EntityFile:    &report.Files,
+	} {
+		rows, err := s.query(ctx, orphanQueries[entity])
+		if err != nil {
+			return nil, fmt.Errorf("find orphan %ss: %w", entity, err)
+		}
+		for rows.Next() {
+			var o Orphan
+			if err := rows.Scan(&o.ID, &o.ProjectID, &o.Label); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			*dest = append(*dest, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// DeleteOrphans removes every row FindOrphans would report and returns
+// how many were deleted, along with orphan sessions' content store objects.
+func (s *PostgresStore) DeleteOrphans(ctx context.Context) (int64, error) {
+	refs, err := s.contentRefs(ctx, `NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = s.project_id)`)
+	if err != nil {
+		return 0, fmt.Errorf("delete orphans from sessions: %w", err)
+	}
+	defer s.releaseContent(ctx, refs...)
+	var total int64
+	for _, table := range []string{"memories", "sessions", "file_index"} {
+		tag, err := s.exec(ctx,
+			`DELETE FROM `+table+` t WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = t.project_id)`)
+		if err != nil {
+			return total, fmt.Errorf("delete orphans from %s: %w", table, err)
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
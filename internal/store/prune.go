@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListSearchQueries returns a project's distinct queries to the given
+// tools asked since the given time, most often asked first.
+func (s *PostgresStore) ListSearchQueries(ctx context.Context, projectID string, tools []string, since time.Time, limit int) ([]UsageQuery, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := s.query(ctx,
+		`SELECT query_text, count(*), max(created_at)
+		 FROM usage_stats
+		 WHERE project_id = $1 AND tool_name = ANY($2) AND query_text <> '' AND created_at >= $3
+		 GROUP BY query_text
+		 ORDER BY count(*) DESC, max(created_at) DESC
+		 LIMIT $4`, projectID, tools, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queries []UsageQuery
+	for rows.Next() {
+		var q UsageQuery
+		if err := rows.Scan(&q.QueryText, &q.Count, &q.LastAsked); err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// SavePruneScan replaces a project's prune report with scan and its
+// candidates. Memories flagged by the previous scan too keep their
+// original FlaggedAt; those no longer flagged are dropped.
+func (s *PostgresStore) SavePruneScan(ctx context.Context, scan *PruneScan, candidates []PruneCandidate) error {
+	ids := make([]int64, len(candidates))
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		ids[i], scores[i] = c.ID, c.BestScore
+	}
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO prune_scans (project_id, scanned_at, window_start, queries, min_score)
+			 VALUES ($1, now(), $2, $3, $4)
+			 ON CONFLICT (project_id) DO UPDATE SET scanned_at=now(), window_start=EXCLUDED.window_start,
+			     queries=EXCLUDED.queries, min_score=EXCLUDED.min_score`,
+			scan.ProjectID, scan.WindowStart, scan.Queries, scan.MinScore); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`DELETE FROM prune_candidates WHERE project_id=$1 AND NOT (memory_id = ANY($2))`,
+			scan.ProjectID, ids); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO prune_candidates (memory_id, project_id, best_score)
+			 SELECT id, $1, score FROM unnest($2::bigint[], $3::float8[]) AS c(id, score)
+			 ON CONFLICT (memory_id) DO UPDATE SET best_score=EXCLUDED.best_score,
+			     flagged_at=CASE WHEN prune_candidates.project_id=EXCLUDED.project_id
+			         THEN prune_candidates.flagged_at ELSE now() END,
+			     project_id=EXCLUDED.project_id`,
+			scan.ProjectID, ids, scores)
+		return err
+	})
+}
+
+// GetPruneScan returns a project's last prune scan and its candidates,
+// longest flagged first. The scan is nil if the project was never scanned.
+func (s *PostgresStore) GetPruneScan(ctx context.Context, projectID string) (*PruneScan, []PruneCandidate, error) {
+	scan := &PruneScan{ProjectID: projectID}
+	err := s.queryRow(ctx,
+		`SELECT scanned_at, window_start, queries, min_score FROM prune_scans WHERE project_id=$1`,
+		projectID).Scan(&scan.ScannedAt, &scan.WindowStart, &scan.Queries, &scan.MinScore)
+	if err == pgx.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := s.query(ctx,
+		`SELECT `+memoryColumns+`, best_score, flagged_at
+		 FROM memories JOIN (SELECT memory_id, best_score, flagged_at FROM prune_candidates WHERE project_id=$1) c
+		     ON c.memory_id = memories.id
+		 WHERE memories.project_id=$1
+		 ORDER BY flagged_at, topic, key`, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var candidates []PruneCandidate
+	for rows.Next() {
+		var c PruneCandidate
+		if err := scanMemory(rows, &c.Memory, &c.BestScore, &c.FlaggedAt); err != nil {
+			return nil, nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return scan, candidates, rows.Err()
+}
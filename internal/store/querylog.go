@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// queryLogMaxArg caps how much of a string argument is logged.
+const queryLogMaxArg = 200
+
+// SetQueryLogging turns on debug-level logging of every SQL statement and
+// its (redacted) arguments. Output also requires the debug log level.
+func (s *PostgresStore) SetQueryLogging(on bool) {
+	s.logQueries = on
+}
+
+// logQuery logs sql and its arguments at debug level when enabled.
+func (s *PostgresStore) logQuery(ctx context.Context, sql string, args []any) {
+	if !s.logQueries || !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	redacted := make([]string, len(args))
+	for i, a := range args {
+		redacted[i] = redactArg(a)
+	}
+	slog.DebugContext(ctx, "sql", "query", strings.Join(strings.Fields(sql), " "), "args", redacted)
+}
+
+// redactArg renders a query argument for the log. Embeddings, whether as
+// a Vector or a pgvector text literal, are reduced to their dimension, and
+// long strings are truncated.
+func redactArg(a any) string {
+	switch v := a.(type) {
+	case nil:
+		return "NULL"
+	case Vector:
+		return fmt.Sprintf("<vector dim=%d>", len(v))
+	case *string:
+		if v == nil {
+			return "NULL"
+		}
+		return redactArg(*v)
+	case string:
+		if isVectorLiteral(v) {
+			return fmt.Sprintf("<vector dim=%d>", strings.Count(v, ",")+1)
+		}
+		if len(v) > queryLogMaxArg {
+			return fmt.Sprintf("%q...(%d bytes)", v[:queryLogMaxArg], len(v))
+		}
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(v))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// isVectorLiteral reports whether s looks like vectorToString output.
+func isVectorLiteral(s string) bool {
+	return len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']' && strings.Count(s, ",") >= 8
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/timing"
+	"github.com/jackc/pgx/v5"
+)
+
+// queryTimer is the pool's pgx tracer: it adds each statement's duration,
+// up to the rows being closed, to the timing.Recorder of its context.
+type queryTimer struct{}
+
+type queryStartKey struct{}
+
+func (queryTimer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, time.Now())
+}
+
+func (queryTimer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	if start, ok := ctx.Value(queryStartKey{}).(time.Time); ok {
+		timing.Add(ctx, timing.DB, time.Since(start))
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReassignMemories moves the given memories of project from to project to
+// in one transaction, keeping their ids, values, tags, and vectors. A
+// memory whose topic/key the destination already has stays where it is,
+// unless overwrite replaces the destination's copy. The source records a
+// tombstone for each memory moved so memory_changes mirrors drop it.
+func (s *PostgresStore) ReassignMemories(ctx context.Context, from, to string, ids []int64, overwrite bool) (*ReassignResult, error) {
+	current := `false`
+	args := []any{from, to, ids}
+	if s.vectorEnabled {
+		current = `m.embedding IS NOT NULL AND m.` + currentModel(4)
+		args = append(args, s.modelVersion)
+	}
+
+	result := &ReassignResult{}
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if overwrite {
+			tag, err := tx.Exec(ctx,
+				`WITH gone AS (
+				     DELETE FROM memories d USING memories m
+				     WHERE m.id = ANY($3) AND m.project_id=$1
+				     AND d.project_id=$2 AND d.topic=m.topic AND d.key=m.key
+				     RETURNING d.id, d.project_id, d.topic, d.key)
+				 `+recordDeletions, from, to, ids)
+			if err != nil {
+				return err
+			}
+			result.Overwritten = int(tag.RowsAffected())
+		}
+
+		rows, err := tx.Query(ctx,
+			`WITH moved AS (
+			     UPDATE memories m SET project_id=$2, updated_at=now()
+			     WHERE m.id = ANY($3) AND m.project_id=$1
+			     AND NOT EXISTS (SELECT 1 FROM memories t WHERE t.project_id=$2 AND t.topic=m.topic AND t.key=m.key)
+			     RETURNING m.id, m.topic, m.key, `+current+` AS current),
+			 tombstones AS (
+			     INSERT INTO memory_deletions (memory_id, project_id, topic, key)
+			     SELECT id, $1, topic, key FROM moved)
+			 SELECT id, topic, key, current FROM moved ORDER BY topic, key`, args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			m := Memory{ProjectID: to}
+			var embedded bool
+			if err := rows.Scan(&m.ID, &m.Topic, &m.Key, &embedded); err != nil {
+				rows.Close()
+				return err
+			}
+			result.Moved = append(result.Moved, m)
+			if !embedded {
+				result.Unembedded = append(result.Unembedded, m.ID)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx,
+			`SELECT id, topic, key FROM memories WHERE id = ANY($2) AND project_id=$1 ORDER BY topic, key`,
+			from, ids)
+		if err != nil {
+			return err
+		}
+		result.Conflicts, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Memory, error) {
+			m := Memory{ProjectID: from}
+			return m, row.Scan(&m.ID, &m.Topic, &m.Key)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.stats.invalidate()
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Project metadata keys holding retention policies, in whole days. A
+// missing or zero value retains forever.
+const (
+	MetaSessionRetentionDays = "session_retention_days"
+	MetaMemoryRetentionDays  = "memory_retention_days"
+)
+
+// RunRetentionSweeper deletes expired sessions and memories every interval
+// until ctx is cancelled. It sweeps once at startup so a restarted server
+// does not wait a full interval.
+func RunRetentionSweeper(ctx context.Context, s Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sessions, memories, err := s.SweepExpired(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("retention sweep failed", "error", err)
+		} else if sessions > 0 || memories > 0 {
+			slog.Info("retention sweep", "sessions_deleted", sessions, "memories_deleted", memories)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
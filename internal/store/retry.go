@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// isTransientConnErr reports whether err means the connection went away
+// (server restart, proxy idle timeout) rather than the query being bad.
+// The pool discards broken connections, so a retry gets a fresh one.
+func isTransientConnErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 = connection exception; 57P01-03 = server shutting down / starting
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
+
+// isUnsentErr reports whether err means a statement never reached the
+// server, so running it again cannot apply it twice. Writes retry only on
+// these; a dropped connection may come after the server ran the statement.
+func isUnsentErr(err error) bool {
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var connErr *pgconn.ConnectError
+	return errors.As(err, &connErr)
+}
+
+// IsQueryTimeout reports whether err means a query ran out of time, either
+// its context deadline or the server's statement_timeout (57014).
+func IsQueryTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014"
+}
+
+// exec runs pool.Exec, retrying once if the statement was not sent.
+// Every write goes through exec, so it also invalidates the stats cache.
+func (s *PostgresStore) exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	defer s.stats.invalidate()
+	return s.execKeepStats(ctx, sql, args...)
+}
+
+// execKeepStats is exec for writes that change no project counts, such as
+// usage_stats inserts made on every tool call. It leaves the stats cache
+// alone, so usage totals are up to one stats TTL old.
+func (s *PostgresStore) execKeepStats(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	s.logQuery(ctx, sql, args)
+	tag, err := s.pool.Exec(ctx, sql, args...)
+	if isUnsentErr(err) && ctx.Err() == nil {
+		slog.Warn("database connection lost, retrying", "error", err)
+		tag, err = s.pool.Exec(ctx, sql, args...)
+	}
+	return tag, err
+}
+
+// query runs pool.Query, retrying once on a transient connection error.
+func (s *PostgresStore) query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	s.logQuery(ctx, sql, args)
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if isTransientConnErr(err) && ctx.Err() == nil {
+		slog.Warn("database connection lost, retrying", "error", err)
+		rows, err = s.pool.Query(ctx, sql, args...)
+	}
+	return rows, err
+}
+
+// queryRow defers the query to Scan so the retry covers errors that pgx
+// only reports once the row is read.
+func (s *PostgresStore) queryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return retryRow{s: s, ctx: ctx, sql: sql, args: args}
+}
+
+type retryRow struct {
+	s    *PostgresStore
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r retryRow) Scan(dest ...any) error {
+	r.s.logQuery(r.ctx, r.sql, r.args)
+	err := r.s.pool.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	if isTransientConnErr(err) && r.ctx.Err() == nil {
+		slog.Warn("database connection lost, retrying", "error", err)
+		err = r.s.pool.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ProjectSnapshot is one project's memories, sessions, and files as read
+// from a backup archive.
+type ProjectSnapshot struct {
+	Manifest BackupManifest
+	Memories []Memory
+	Sessions []Session
+	Files    []FileEntry
+}
+
+// OpenArchive opens a backup archive, decompressing it when the name ends
+// in .gz.
+func OpenArchive(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, f}, nil
+}
+
+// ReadProjectSnapshot reads the rows of projectID from a stream written by
+// Backup, without touching the database. Session content is inlined by
+// Backup, so sessions come back complete. A stream without its closing
+// manifest is rejected as truncated, and a project missing from the
+// archive is an error rather than an empty snapshot.
+func ReadProjectSnapshot(r io.Reader, projectID string) (*ProjectSnapshot, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var first backupRecord
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if first.Manifest == nil {
+		return nil, fmt.Errorf("not a devmemory backup: missing manifest")
+	}
+	if first.Manifest.Format > BackupFormat {
+		return nil, fmt.Errorf("backup format %d is newer than this build supports (%d)", first.Manifest.Format, BackupFormat)
+	}
+	snap := &ProjectSnapshot{Manifest: *first.Manifest}
+
+	found := false
+	for {
+		var rec backupRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			return nil, fmt.Errorf("backup is truncated: no closing manifest")
+		} else if err != nil {
+			return nil, fmt.Errorf("read backup: %w", err)
+		}
+		if rec.End != nil {
+			snap.Manifest.Counts = rec.End.Counts
+			break
+		}
+
+		var owner struct {
+			ID        any    `json:"id"`
+			ProjectID string `json:"project_id"`
+		}
+		if err := json.Unmarshal(rec.Row, &owner); err != nil {
+			return nil, fmt.Errorf("read %s row: %w", rec.Table, err)
+		}
+		var err error
+		switch rec.Table {
+		case "projects":
+			found = found || owner.ID == projectID
+		case "memories":
+			if owner.ProjectID == projectID {
+				var m Memory
+				err = json.Unmarshal(rec.Row, &m)
+				snap.Memories = append(snap.Memories, m)
+			}
+		case "sessions":
+			if owner.ProjectID == projectID {
+				var sess Session
+				err = json.Unmarshal(rec.Row, &sess)
+				snap.Sessions = append(snap.Sessions, sess)
+			}
+		case "file_index":
+			if owner.ProjectID == projectID {
+				var f FileEntry
+				err = json.Unmarshal(rec.Row, &f)
+				snap.Files = append(snap.Files, f)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s row: %w", rec.Table, err)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("project '%s' is not in the backup", projectID)
+	}
+	return snap, nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+)
+
+// statsCache holds the last DashboardStats of all projects, whatever the
+// caller's access scope, for a short TTL so that every
+// dashboard client refreshing on the same SSE event shares one computation.
+// Any write through exec invalidates it; usage inserts do not, so usage
+// totals are refreshed by the TTL alone.
+type statsCache struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	stats *DashboardStats
+	at    time.Time
+	gen   uint64 // bumped on invalidate; a fill started under an older gen is discarded
+
+	// fill serialises recomputation so concurrent misses wait for the
+	// first one instead of all querying.
+	fill sync.Mutex
+}
+
+func (c *statsCache) get() (*DashboardStats, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats != nil && time.Since(c.at) < c.ttl {
+		return c.stats, c.gen
+	}
+	return nil, c.gen
+}
+
+func (c *statsCache) put(ds *DashboardStats, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen == c.gen {
+		c.stats, c.at = ds, time.Now()
+	}
+}
+
+// scoped copies ds for the access scope of ctx, so a caller can set
+// fields such as EmbeddingStatus without touching the cached value other
+// requests are rendering. A restricted scope sees only its projects, and
+// totals summed over them; usage not tied to a project is left out.
+func (ds *DashboardStats) scoped(ctx context.Context) *DashboardStats {
+	scope := access.FromContext(ctx)
+	if scope.Unrestricted() {
+		c := *ds
+		c.Projects = append([]ProjectStats(nil), ds.Projects...)
+		return &c
+	}
+	c := &DashboardStats{EmbeddingStatus: ds.EmbeddingStatus}
+	for _, ps := range ds.Projects {
+		if !scope.Allows(ps.Project.ID) {
+			continue
+		}
+		c.Projects = append(c.Projects, ps)
+		c.ProjectCount++
+		c.MemoryCount += ps.MemoryCount
+		c.SessionCount += ps.SessionCount
+		c.FileCount += ps.FileCount
+		c.TotalQueries += ps.QueryCount
+		c.TotalTokensSaved += ps.TokensSaved
+		c.QueriesLast24h += ps.queriesLast24h
+		c.TokensLast24h += ps.tokensLast24h
+	}
+	return c
+}
+
+func (c *statsCache) invalidate() {
+	c.mu.Lock()
+	c.stats = nil
+	c.gen++
+	c.mu.Unlock()
+}
+
+// SetStatsCacheTTL sets how long GetDashboardStats results are reused.
+// Zero disables caching.
+func (s *PostgresStore) SetStatsCacheTTL(ttl time.Duration) {
+	s.stats.mu.Lock()
+	s.stats.ttl = ttl
+	s.stats.stats = nil
+	s.stats.mu.Unlock()
+}
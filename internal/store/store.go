@@ -2,6 +2,10 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -18,6 +22,49 @@ type Project struct {
 	UpdatedAt time.Time         `json:"updated_at"`
 }
 
+// MetaEmbeddingsEnabled is the project metadata flag that, when false,
+// keeps a project keyword-only even with an embedding service configured.
+const MetaEmbeddingsEnabled = "embeddings_enabled"
+
+// EmbeddingsEnabled reports whether the project's rows should be embedded.
+// Projects embed unless MetaEmbeddingsEnabled is explicitly false.
+func (p *Project) EmbeddingsEnabled() bool {
+	on, ok := p.Metadata[MetaEmbeddingsEnabled].(bool)
+	return !ok || on
+}
+
+// MetaPrimingTopics is the project metadata key listing the topics an
+// agent should read first at session start, in order.
+const MetaPrimingTopics = "priming_topics"
+
+// PrimingTopics returns the project's MetaPrimingTopics, or nil.
+func (p *Project) PrimingTopics() []string {
+	list, _ := p.Metadata[MetaPrimingTopics].([]any)
+	var topics []string
+	for _, t := range list {
+		if s, ok := t.(string); ok {
+			topics = append(topics, s)
+		}
+	}
+	return topics
+}
+
+// MetaTopicSchemas is the project metadata key mapping topics to the JSON
+// Schema their memory values must satisfy.
+const MetaTopicSchemas = "topic_schemas"
+
+// TopicSchema returns the JSON Schema of topic from MetaTopicSchemas, or
+// nil if the topic is unconstrained.
+func (p *Project) TopicSchema(topic string) map[string]any {
+	schemas, _ := p.Metadata[MetaTopicSchemas].(map[string]any)
+	schema, _ := schemas[topic].(map[string]any)
+	return schema
+}
+
+// MetaMinScore is the project metadata key holding the tuned similarity
+// threshold that memory_search applies when no min_score is given.
+const MetaMinScore = "min_score"
+
 // Memory represents a key-value memory entry with optional embedding.
 type Memory struct {
 	ID        int64     `json:"id"`
@@ -25,12 +72,63 @@ type Memory struct {
 	Topic     string    `json:"topic"`
 	Key       string    `json:"key"`
 	Value     string    `json:"value"`
+	Summary   string    `json:"summary,omitempty"` // set for long values; see SetMemorySummaries
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	CreatedBy string    `json:"created_by,omitempty"`
-	Score     float64   `json:"score,omitempty"` // similarity score for search results
+	Status    string    `json:"status,omitempty"` // MemoryDraft or MemoryPublished
+	Tags      []string  `json:"tags,omitempty"`
+	Score     float64   `json:"score,omitempty"`  // similarity score for search results
+}
+
+// EmbedText is the text embedded for a memory: the value first, then the
+// topic and the key split into words, so a concept named only in the key
+// still matches while the value dominates.
+func (m *Memory) EmbedText(includeKey bool) string {
+	if !includeKey {
+		return m.Value
+	}
+	words := strings.NewReplacer("/", " ", ".", " ", "_", " ", "-", " ").Replace(m.Key)
+	return m.Value + "\nTopic: " + m.Topic + "\nKey: " + words
 }
 
+// TopicCentroid is the mean embedding of a topic's memories.
+type TopicCentroid struct {
+	Topic    string `json:"topic"`
+	Count    int    `json:"count"`
+	Centroid Vector `json:"-"`
+}
+
+// MemoryVector is a memory's identity and stored embedding.
+type MemoryVector struct {
+	Topic     string
+	Key       string
+	Embedding Vector
+}
+
+// ReassignResult reports a ReassignMemories call. Memories name only id,
+// topic, and key.
+type ReassignResult struct {
+	Moved       []Memory `json:"moved"`
+	Conflicts   []Memory `json:"conflicts"`   // left in the source: the destination has the key
+	Overwritten int      `json:"overwritten"` // destination memories replaced
+	Unembedded  []int64  `json:"-"`           // moved without a current-model vector
+}
+
+// MemoryDeletion is the tombstone of a deleted memory.
+type MemoryDeletion struct {
+	MemoryID  int64     `json:"memory_id"`
+	Topic     string    `json:"topic"`
+	Key       string    `json:"key"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Memory statuses. Drafts are hidden from search until published.
+const (
+	MemoryDraft     = "draft"
+	MemoryPublished = "published"
+)
+
 // Session represents a session transcript.
 type Session struct {
 	ID         int64          `json:"id"`
@@ -41,21 +139,71 @@ type Session struct {
 	Content    string         `json:"content,omitempty"`
 	Metadata   map[string]any `json:"metadata,omitempty"`
 	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 	Score      float64        `json:"score,omitempty"`
 }
 
+// SessionChunk is a slice of a session's content. Offset and Total count
+// characters, not bytes.
+type SessionChunk struct {
+	SessionNum int    `json:"session_num"`
+	Offset     int    `json:"offset"`
+	Content    string `json:"content"`
+	Total      int    `json:"total"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Symbol describes a function, type, or other declaration within a file.
+type Symbol struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"` // e.g. "func", "type", "method", "const"
+	Doc  string `json:"doc,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// UnmarshalJSON also accepts a bare string, which older clients sent as
+// the symbol name.
+func (s *Symbol) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = Symbol{Name: name}
+		return nil
+	}
+	type plain Symbol
+	return json.Unmarshal(data, (*plain)(s))
+}
+
 // FileEntry represents an indexed file.
 type FileEntry struct {
 	ID          int64     `json:"id"`
 	ProjectID   string    `json:"project_id"`
 	FilePath    string    `json:"file_path"`
 	FileType    string    `json:"file_type,omitempty"`
-	Symbols     []any     `json:"symbols,omitempty"`
+	Symbols     []Symbol  `json:"symbols,omitempty"`
 	Summary     string    `json:"summary,omitempty"`
 	LastIndexed time.Time `json:"last_indexed"`
 	Score       float64   `json:"score,omitempty"`
 }
 
+// EmbedText is the text embedded for a file: the summary first, followed
+// by the path split into words and the file type, so path-based queries
+// still match while the summary dominates.
+func (f *FileEntry) EmbedText(includePath bool) string {
+	if !includePath {
+		return f.Summary
+	}
+	path := strings.NewReplacer("/", " ", ".", " ", "_", " ", "-", " ").Replace(f.FilePath)
+	parts := []string{}
+	if f.Summary != "" {
+		parts = append(parts, f.Summary)
+	}
+	parts = append(parts, "File: "+path)
+	if f.FileType != "" {
+		parts = append(parts, "Type: "+f.FileType)
+	}
+	return strings.Join(parts, "\n")
+}
+
 // UsageStat records a single tool invocation for analytics.
 type UsageStat struct {
 	ID              int64     `json:"id"`
@@ -65,8 +213,58 @@ type UsageStat struct {
 	ResultsCount    int       `json:"results_count"`
 	TokensEstimated int       `json:"tokens_estimated"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	// QueryEmbedding is stored only when query embedding is enabled.
+	QueryEmbedding Vector `json:"-"`
+}
+
+// UsageQuery is a distinct past query with how often it was asked.
+type UsageQuery struct {
+	QueryText string    `json:"query_text"`
+	Count     int       `json:"count"`
+	LastAsked time.Time `json:"last_asked"`
+	Score     float64   `json:"score,omitempty"`
+	Embedding Vector    `json:"-"`
+}
+
+// QueryOutcome is a distinct past search query and how many results it
+// found on average.
+type QueryOutcome struct {
+	QueryText  string    `json:"query_text"`
+	Count      int       `json:"count"`
+	AvgResults float64   `json:"avg_results"`
+	LastAsked  time.Time `json:"last_asked"`
+	Score      float64   `json:"-"` // similarity to the given query vector
+}
+
+// PruneScan describes the last prune scan of a project: the memory
+// searches asked since WindowStart were replayed, and memories none of
+// them returned at MinScore or above were flagged as prune candidates.
+type PruneScan struct {
+	ProjectID   string    `json:"project_id"`
+	ScannedAt   time.Time `json:"scanned_at"`
+	WindowStart time.Time `json:"window_start"`
+	Queries     int       `json:"queries"`
+	MinScore    float64   `json:"min_score"`
 }
 
+// PruneCandidate is a memory flagged by a prune scan. BestScore is the
+// highest score a replayed search gave it, 0 if none returned it;
+// FlaggedAt is when it was first flagged by consecutive scans.
+type PruneCandidate struct {
+	Memory
+	BestScore float64   `json:"best_score"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// SearchTools are the tools whose usage_stats query_text is a search
+// query rather than, say, a memory key.
+var SearchTools = []string{"memory_search", "session_search", "file_search", "search_all"}
+
+// MemorySearchTools are the tools whose searches return memories; prune
+// scans replay their queries.
+var MemorySearchTools = []string{"memory_search", "search_all"}
+
 // DashboardStats aggregates counts across all projects.
 type DashboardStats struct {
 	ProjectCount     int
@@ -83,50 +281,281 @@ type DashboardStats struct {
 
 // ProjectStats aggregates counts for a single project.
 type ProjectStats struct {
-	Project      Project
-	MemoryCount  int
-	SessionCount int
-	FileCount    int
-	QueryCount   int
-	TokensSaved  int
+	Project      Project `json:"project"`
+	MemoryCount  int     `json:"memory_count"`
+	SessionCount int     `json:"session_count"`
+	FileCount    int     `json:"file_count"`
+	QueryCount   int     `json:"query_count"`
+	TokensSaved  int     `json:"tokens_saved"`
+
+	// Last-24h usage, for the totals of a scoped DashboardStats.
+	queriesLast24h int
+	tokensLast24h  int
 }
 
-// SearchAllResult holds cross-entity search results.
+// EmbeddingCoverage counts a project's rows that have no embedding.
+type EmbeddingCoverage struct {
+	Memories int `json:"memories_unembedded"`
+	Sessions int `json:"sessions_unembedded"`
+	Files    int `json:"files_unembedded"`
+}
+
+// CoverageStats is how much of a project is semantically searchable:
+// rows with an embedding of the current model version out of all rows.
+type CoverageStats struct {
+	ProjectID string         `json:"project_id"`
+	Memories  EntityCoverage `json:"memories"`
+	Sessions  EntityCoverage `json:"sessions"`
+	Files     EntityCoverage `json:"files"`
+	Overall   EntityCoverage `json:"overall"`
+}
+
+// Footprint is the space a project's data takes, in bytes. Text is counted
+// uncompressed; embeddings as stored. Session content kept in a content
+// store is not in Postgres and only counted in ExternalSessions.
+type Footprint struct {
+	ProjectID        string `json:"project_id"`
+	MemoryBytes      int64  `json:"memory_bytes"`  // values and summaries
+	SessionBytes     int64  `json:"session_bytes"` // titles, summaries, and content
+	FileBytes        int64  `json:"file_bytes"`    // summaries and symbols
+	EmbeddingBytes   int64  `json:"embedding_bytes"`
+	TotalBytes       int64  `json:"total_bytes"`
+	ExternalSessions int    `json:"external_sessions"`
+}
+
+// EntityCoverage counts embedded rows of one kind. Percent is 100 when
+// there are no rows, since nothing is missing.
+type EntityCoverage struct {
+	Embedded int     `json:"embedded"`
+	Total    int     `json:"total"`
+	Percent  float64 `json:"percent"`
+}
+
+func newEntityCoverage(embedded, total int) EntityCoverage {
+	c := EntityCoverage{Embedded: embedded, Total: total, Percent: 100}
+	if total > 0 {
+		c.Percent = math.Round(float64(embedded)*1000/float64(total)) / 10
+	}
+	return c
+}
+
+// MetaAutoVersionKeys is the project metadata flag that makes memory_set
+// write key@2, key@3, ... instead of overwriting an existing key.
+const MetaAutoVersionKeys = "auto_version_keys"
+
+// Session metadata keys set by ArchiveSession.
+const (
+	MetaArchivedAt  = "archived_at"
+	MetaArchivePath = "archive_path" // where content was exported, if anywhere
+)
+
+// Entity names accepted by SetEmbedding.
+const (
+	EntityMemory  = "memory"
+	EntitySession = "session"
+	EntityFile    = "file"
+)
+
+// EmbeddingDimCount counts one entity's rows with a given embedding
+// dimension. Dim 0 counts rows without an embedding.
+type EmbeddingDimCount struct {
+	Entity string `json:"entity"`
+	Dim    int    `json:"dim"`
+	Count  int    `json:"count"`
+}
+
+// TypeCount is the number of indexed files of one file type.
+type TypeCount struct {
+	FileType string `json:"file_type"`
+	Count    int    `json:"count"`
+}
+
+// TopicCount is the number of memories in one topic of a project.
+type TopicCount struct {
+	Topic     string    `json:"topic"`
+	Memories  int       `json:"memories"`
+	Drafts    int       `json:"drafts"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SearchLimits caps SearchAll results per entity type. A zero per-type
+// limit falls back to Default, and a zero Default to 10. Offset skips that
+// many of the best results of each type, for paging.
+type SearchLimits struct {
+	Default  int
+	Memories int
+	Sessions int
+	Files    int
+	Offset   int
+}
+
+func (l SearchLimits) resolve(n int) int {
+	if n > 0 {
+		return n
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return 10
+}
+
+// QueryVectors are the SearchAll query embeddings per entity type. They
+// differ only when entity types use different embedding models; nil
+// searches that type by keyword.
+type QueryVectors struct {
+	Memory  Vector
+	Session Vector
+	File    Vector
+}
+
+// SearchWeights multiply SearchAll scores per entity type so one type can
+// be biased above another. A zero weight means 1.0.
+type SearchWeights struct {
+	Memory  float64
+	Session float64
+	File    float64
+}
+
+func weightOr1(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// SearchHit is one entry of SearchAllResult.Ranked.
+type SearchHit struct {
+	Entity    string  `json:"entity"`
+	ProjectID string  `json:"project_id"`
+	Label     string  `json:"label"` // topic/key, session title, or file path
+	Score     float64 `json:"score"`
+}
+
+// SearchAllResult holds cross-entity search results. Ranked merges all
+// three lists by weighted score. HasMore reports that some type has
+// results beyond this page. Warnings explain an empty or incomplete
+// result, such as WarnNoProjects or a project whose search failed.
 type SearchAllResult struct {
 	Memories []Memory
 	Sessions []Session
 	Files    []FileEntry
+	Ranked   []SearchHit
+	HasMore  bool
+	Warnings []string
+}
+
+// WarnNoProjects is the SearchAllResult warning when there is nothing to
+// search because no project is registered.
+const WarnNoProjects = "no projects registered"
+
+// Orphan is a row whose project_id has no matching project.
+type Orphan struct {
+	ID        int64  `json:"id"`
+	ProjectID string `json:"project_id"`
+	Label     string `json:"label"` // topic/key, session number, or file path
+}
+
+// OrphanReport lists orphaned rows by entity type.
+type OrphanReport struct {
+	Memories []Orphan `json:"memories"`
+	Sessions []Orphan `json:"sessions"`
+	Files    []Orphan `json:"files"`
+}
+
+// Total returns the number of orphaned rows.
+func (r *OrphanReport) Total() int {
+	return len(r.Memories) + len(r.Sessions) + len(r.Files)
 }
 
 // Store defines the persistence interface.
 type Store interface {
 	// Projects
 	CreateProject(ctx context.Context, p *Project) error
+	EnsureProject(ctx context.Context, id string) (bool, error)
 	GetProject(ctx context.Context, id string) (*Project, error)
 	ListProjects(ctx context.Context) ([]Project, error)
 
 	// Memories
 	SetMemory(ctx context.Context, m *Memory, embedding Vector) error
+	// CreateMemory inserts m unless its key already exists, leaving an
+	// existing memory untouched; it reports whether m was inserted.
+	CreateMemory(ctx context.Context, m *Memory, embedding Vector) (bool, error)
 	GetMemory(ctx context.Context, projectID, topic, key string) (*Memory, error)
+	GetMemoryEmbedding(ctx context.Context, projectID, topic, key string) (Vector, error)
 	ListMemories(ctx context.Context, projectID, topic string) ([]Memory, error)
 	DeleteMemory(ctx context.Context, projectID, topic, key string) error
-	SearchMemories(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]Memory, error)
+	SearchMemories(ctx context.Context, projectID string, query string, embedding Vector, limit int, includeDrafts bool) ([]Memory, error)
+	SearchMemoriesTSQuery(ctx context.Context, projectID, tsquery string, limit int, includeDrafts bool) ([]Memory, error)
+	PublishMemory(ctx context.Context, projectID, topic, key string) error
+	TopicCentroids(ctx context.Context, projectID string) ([]TopicCentroid, error)
+	ListTopicCounts(ctx context.Context, projectID string) ([]TopicCount, error)
+	SampleMemoryEmbeddings(ctx context.Context, projectID string, limit int) ([]MemoryVector, int, error)
+	RenameTopic(ctx context.Context, projectID, from, to string) (moved, conflicts int, err error)
+	MergeMemoryKeys(ctx context.Context, projectID, topic, source, target, value string, embedding Vector) (bool, error)
+	ListDraftMemories(ctx context.Context) ([]Memory, error)
+	ListMemoriesChangedSince(ctx context.Context, projectID string, since time.Time) ([]Memory, error)
+	ListMemoryDeletionsSince(ctx context.Context, projectID string, since time.Time) ([]MemoryDeletion, error)
+	ListIdleMemories(ctx context.Context, projectID string, since time.Time) ([]Memory, error)
+	ListMemoryVersions(ctx context.Context, projectID, topic, key string) ([]Memory, error)
+	TagMemories(ctx context.Context, projectID string, ids []int64, tag string) (int, error)
+	ReassignMemories(ctx context.Context, from, to string, ids []int64, overwrite bool) (*ReassignResult, error)
 
 	// Sessions
 	CreateSession(ctx context.Context, s *Session, embedding Vector) error
+	CreateSessionExpect(ctx context.Context, s *Session, embedding Vector, expectedUpdatedAt *time.Time) error
 	GetSession(ctx context.Context, projectID string, sessionNum int) (*Session, error)
+	SessionNumsWithTitle(ctx context.Context, projectID, title string) ([]int, error)
+	GetSessionChunk(ctx context.Context, projectID string, sessionNum, offset, length int) (*SessionChunk, error)
 	ListSessions(ctx context.Context, projectID string) ([]Session, error)
+	UpdateSessionMeta(ctx context.Context, projectID string, num int, title, summary *string, newNum *int, embedding Vector) error
+	ArchiveSession(ctx context.Context, projectID string, num int, archivePath string) error
+	RestoreSession(ctx context.Context, projectID string, num int, content string) error
 	SearchSessions(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]Session, error)
 
 	// File Index
 	IndexFile(ctx context.Context, f *FileEntry, embedding Vector) error
+	ListFiles(ctx context.Context, projectID string) ([]FileEntry, error)
+	DeleteFiles(ctx context.Context, projectID string, paths []string) (int64, error)
+	ListFileTypes(ctx context.Context, projectID string) ([]TypeCount, error)
+	GetFile(ctx context.Context, projectID, filePath string) (*FileEntry, error)
+	GetFileEmbedding(ctx context.Context, projectID, filePath string) (Vector, error)
 	SearchFiles(ctx context.Context, projectID string, query string, embedding Vector, limit int) ([]FileEntry, error)
 
 	// Usage & Dashboard
 	RecordUsage(ctx context.Context, u *UsageStat) error
+	SearchUsageQueries(ctx context.Context, projectID, query string, embedding Vector, limit int) ([]UsageQuery, error)
+	ListUsageQueries(ctx context.Context, projectID string, limit int) ([]UsageQuery, error)
+	ListSuccessfulQueries(ctx context.Context, projectID string, embedding Vector, limit int) ([]QueryOutcome, error)
+	ListSearchQueries(ctx context.Context, projectID string, tools []string, since time.Time, limit int) ([]UsageQuery, error)
+	SavePruneScan(ctx context.Context, scan *PruneScan, candidates []PruneCandidate) error
+	GetPruneScan(ctx context.Context, projectID string) (*PruneScan, []PruneCandidate, error)
 	GetDashboardStats(ctx context.Context) (*DashboardStats, error)
 	GetProjectStats(ctx context.Context, projectID string) (*ProjectStats, error)
-	SearchAll(ctx context.Context, query string, embedding Vector, limit int) (*SearchAllResult, error)
+	GetAllProjectStats(ctx context.Context) ([]ProjectStats, error)
+	ListEmbeddingCoverage(ctx context.Context) (map[string]EmbeddingCoverage, error)
+	GetEmbeddingCoverage(ctx context.Context, projectID string) (*CoverageStats, error)
+	GetProjectFootprint(ctx context.Context, projectID string) (*Footprint, error)
+	ProjectCentroids(ctx context.Context) (map[string]Vector, error)
+	WarmCaches(ctx context.Context) error
+	SearchAll(ctx context.Context, query string, vectors QueryVectors, limits SearchLimits, weights SearchWeights) (*SearchAllResult, error)
+
+	// Embeddings
+	SetEmbedding(ctx context.Context, entity string, id int64, embedding Vector) error
+
+	// Retention
+	SweepExpired(ctx context.Context) (sessions, memories int64, err error)
+
+	// Maintenance
+	EmbeddingDims(ctx context.Context, projectID string) ([]EmbeddingDimCount, error)
+	ClearMismatchedEmbeddings(ctx context.Context, projectID string, dims map[string]int) (int64, error)
+	EmbeddingColumnDims(ctx context.Context) (map[string]int, error)
+	ResizeEmbeddingColumn(ctx context.Context, entity string, dim int) (int64, error)
+	FindOrphans(ctx context.Context) (*OrphanReport, error)
+	DeleteOrphans(ctx context.Context) (int64, error)
+	Backup(ctx context.Context, w io.Writer, embeddings bool) (*BackupManifest, error)
+	Restore(ctx context.Context, r io.Reader) (*RestoreResult, error)
+	ExportEmbeddings(ctx context.Context, projectID string, w io.Writer) (*EmbeddingManifest, error)
+	ImportEmbeddings(ctx context.Context, projectID string, r io.Reader) (*EmbeddingImportResult, error)
 
 	// Lifecycle
 	Close()
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Synonyms maps a lower-cased term to the terms keyword search also
+// matches for it. A term may be several words, e.g. "ci" → "continuous
+// integration".
+type Synonyms map[string][]string
+
+// LoadSynonyms reads a synonym file; see ParseSynonyms for the format.
+func LoadSynonyms(path string) (Synonyms, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSynonyms(f)
+}
+
+// ParseSynonyms reads one group of equivalent terms per line, separated
+// by commas:
+//
+//	auth, authentication, authn
+//	k8s, kubernetes
+//
+// Every term in a group expands to all the others. Blank lines and lines
+// starting with # are ignored. A term in several groups expands to the
+// union of them.
+func ParseSynonyms(r io.Reader) (Synonyms, error) {
+	syn := Synonyms{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var group []string
+		for _, t := range strings.Split(line, ",") {
+			if t = strings.Join(strings.Fields(strings.ToLower(t)), " "); t != "" {
+				group = append(group, t)
+			}
+		}
+		if len(group) < 2 {
+			return nil, fmt.Errorf("synonyms line %d: need at least two comma-separated terms", n)
+		}
+		for _, t := range group {
+			for _, alt := range group {
+				if alt != t && !slices.Contains(syn[t], alt) {
+					syn[t] = append(syn[t], alt)
+				}
+			}
+		}
+	}
+	return syn, sc.Err()
+}
+
+// expansions returns the terms of query that have synonyms, longest
+// first so a phrase is rewritten before the words inside it.
+func (syn Synonyms) expansions(query string) []string {
+	words := " " + strings.Join(strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '"' || r == ',' || r == '-'
+	}), " ") + " "
+	var found []string
+	for term := range syn {
+		if strings.Contains(words, " "+term+" ") {
+			found = append(found, term)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if len(found[i]) != len(found[j]) {
+			return len(found[i]) > len(found[j])
+		}
+		return found[i] < found[j]
+	})
+	return found
+}
+
+// SetSynonyms enables synonym expansion for keyword search. Each term of
+// a search query found in syn is rewritten, inside the parsed tsquery, to
+// match the term or any of its synonyms. Semantic search is unaffected.
+func (s *PostgresStore) SetSynonyms(syn Synonyms) {
+	s.synonyms = syn
+}
+
+// keywordQuery returns the SQL tsquery for the search text held in
+// parameter $param of args, wrapped in ts_rewrite calls for each term
+// with synonyms. The arguments those calls need are appended to args.
+func (s *PostgresStore) keywordQuery(param int, args *[]any) string {
+	expr := `websearch_to_tsquery('english', $` + strconv.Itoa(param) + `)`
+	query, _ := (*args)[param-1].(string)
+	for _, term := range s.synonyms.expansions(query) {
+		alts := []string{quoteTerm(term)}
+		for _, alt := range s.synonyms[term] {
+			alts = append(alts, quoteTerm(alt))
+		}
+		*args = append(*args, term, strings.Join(alts, " or "))
+		n := len(*args)
+		expr = `ts_rewrite(` + expr + `, plainto_tsquery('english', $` + strconv.Itoa(n-1) +
+			`), websearch_to_tsquery('english', $` + strconv.Itoa(n) + `))`
+	}
+	return expr
+}
+
+// quoteTerm quotes a term for websearch_to_tsquery so a multi-word
+// synonym matches as a phrase.
+func quoteTerm(t string) string {
+	return `"` + strings.ReplaceAll(t, `"`, "") + `"`
+}
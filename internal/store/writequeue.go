@@ -0,0 +1,186 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultWriteQueueInterval is how often queued writes are retried.
+const DefaultWriteQueueInterval = 30 * time.Second
+
+// WriteQueue is a file-backed queue of memory writes that failed because
+// the database was unreachable. Each write is one JSON line, synced to
+// disk before Enqueue returns, so a crash during the outage loses nothing.
+type WriteQueue struct {
+	mu   sync.Mutex
+	path string
+}
+
+// queuedWrite is one buffered SetMemory call.
+type queuedWrite struct {
+	Memory    Memory    `json:"memory"`
+	Embedding Vector    `json:"embedding,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+// OpenWriteQueue uses the file at path as a queue, creating its directory.
+// Writes left over from a previous run are kept for replay.
+func OpenWriteQueue(path string) (*WriteQueue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create write queue dir: %w", err)
+	}
+	return &WriteQueue{path: path}, nil
+}
+
+// IsConnError reports whether err means the database could not be reached
+// and the write was not sent, i.e. it may be queued and replayed later
+// without applying twice.
+func IsConnError(err error) bool {
+	return isUnsentErr(err)
+}
+
+// Enqueue appends a memory write to the queue.
+func (q *WriteQueue) Enqueue(m *Memory, embedding Vector) error {
+	line, err := json.Marshal(queuedWrite{Memory: *m, Embedding: embedding, QueuedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	f, err := os.OpenFile(q.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open write queue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append to write queue: %w", err)
+	}
+	return f.Sync()
+}
+
+// Len returns the number of queued writes.
+func (q *WriteQueue) Len() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	writes, err := q.read()
+	return len(writes), err
+}
+
+// Replay applies queued writes in order and removes the ones that
+// succeeded. It stops at the first failure so later writes never land
+// before earlier ones; a write rejected for a reason other than the
+// connection is dropped and logged, since retrying cannot fix it.
+func (q *WriteQueue) Replay(ctx context.Context, s Store) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	writes, err := q.read()
+	if err != nil || len(writes) == 0 {
+		return 0, err
+	}
+
+	done := 0
+	for _, w := range writes {
+		if err = s.SetMemory(ctx, &w.Memory, w.Embedding); err != nil {
+			// Keep the write for the next replay rather than drop it
+			// whenever the connection is lost, sent or not.
+			if isTransientConnErr(err) || ctx.Err() != nil {
+				break
+			}
+			slog.Error("dropping queued memory write", "project", w.Memory.ProjectID,
+				"topic", w.Memory.Topic, "key", w.Memory.Key, "queued_at", w.QueuedAt, "error", err)
+			err = nil
+		}
+		done++
+	}
+	if werr := q.write(writes[done:]); werr != nil {
+		return done, werr
+	}
+	return done, err
+}
+
+// read loads every queued write; a missing file is an empty queue.
+func (q *WriteQueue) read() ([]queuedWrite, error) {
+	f, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open write queue: %w", err)
+	}
+	defer f.Close()
+
+	var writes []queuedWrite
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var w queuedWrite
+		if err := json.Unmarshal(sc.Bytes(), &w); err != nil {
+			// A torn last line from a crash mid-append; skip it.
+			slog.Warn("skipping unreadable write queue entry", "error", err)
+			continue
+		}
+		writes = append(writes, w)
+	}
+	return writes, sc.Err()
+}
+
+// write replaces the queue with writes, atomically via a temp file.
+func (q *WriteQueue) write(writes []queuedWrite) error {
+	if len(writes) == 0 {
+		if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("clear write queue: %w", err)
+		}
+		return nil
+	}
+	tmp := q.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("rewrite write queue: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, w := range writes {
+		if err := enc.Encode(w); err != nil {
+			f.Close()
+			return fmt.Errorf("rewrite write queue: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("rewrite write queue: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("rewrite write queue: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// RunWriteQueue replays queued writes every interval until ctx is
+// cancelled, starting at once so writes left by a previous run land early.
+func RunWriteQueue(ctx context.Context, q *WriteQueue, s Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := q.Replay(ctx, s)
+		if n > 0 {
+			slog.Info("replayed queued memory writes", "count", n)
+		}
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("write queue replay incomplete", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package summary condenses Markdown prose without a language model.
+package summary
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Extractive picks sentences from content within maxChars: the first
+// sentence of every paragraph, then the second, and so on, so a small
+// budget covers the whole transcript before going deep into any part of it.
+// The chosen sentences are returned in document order.
+func Extractive(content string, maxChars int) string {
+	paras := summaryParagraphs(content)
+	sentences := make([][]string, len(paras))
+	for i, p := range paras {
+		sentences[i] = splitSentences(p)
+	}
+
+	chosen := make([][]bool, len(paras))
+	for i := range chosen {
+		chosen[i] = make([]bool, len(sentences[i]))
+	}
+	used := 0
+	for depth, added := 0, true; added; depth++ {
+		added = false
+		for i := range sentences {
+			if depth >= len(sentences[i]) || (depth > 0 && !chosen[i][depth-1]) {
+				continue
+			}
+			n := utf8.RuneCountInString(sentences[i][depth]) + 1
+			if used+n > maxChars {
+				continue
+			}
+			chosen[i][depth] = true
+			used += n
+			added = true
+		}
+	}
+
+	var parts []string
+	for i := range sentences {
+		for j, ok := range chosen[i] {
+			if ok {
+				parts = append(parts, sentences[i][j])
+			}
+		}
+	}
+	if len(parts) == 0 && len(paras) > 0 {
+		// Even the first sentence is over budget.
+		return truncate(sentences[0][0], maxChars)
+	}
+	return strings.Join(parts, " ")
+}
+
+// summaryParagraphs splits Markdown into prose paragraphs, dropping
+// headings, fenced code, and tables. Each list item is its own paragraph.
+func summaryParagraphs(content string) []string {
+	var paras []string
+	var cur []string
+	flush := func() {
+		if len(cur) > 0 {
+			paras = append(paras, strings.Join(cur, " "))
+			cur = nil
+		}
+	}
+
+	var fence string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			continue
+		case trimmed == "" || strings.HasPrefix(trimmed, "|"):
+			flush()
+			continue
+		}
+		if level, _ := Heading(line); level > 0 {
+			flush()
+			continue
+		}
+
+		trimmed = strings.TrimSpace(strings.TrimLeft(trimmed, ">"))
+		if item, ok := listItem(trimmed); ok {
+			flush()
+			trimmed = item
+		}
+		if trimmed != "" {
+			cur = append(cur, trimmed)
+		}
+	}
+	flush()
+	return paras
+}
+
+// listItem strips a bullet ("- ", "* ", "+ ") or ordered ("1. ") marker.
+func listItem(line string) (string, bool) {
+	for _, m := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, m) {
+			return strings.TrimSpace(line[len(m):]), true
+		}
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' ' {
+		return strings.TrimSpace(line[i+2:]), true
+	}
+	return line, false
+}
+
+// splitSentences breaks a paragraph after '.', '!', or '?' followed by
+// whitespace.
+func splitSentences(p string) []string {
+	var out []string
+	start := 0
+	runes := []rune(p)
+	for i, r := range runes {
+		if (r == '.' || r == '!' || r == '?') && i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+			if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+				out = append(out, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// Heading returns the level and text of an ATX heading, or 0 if line is
+// not one. Up to three leading spaces are allowed, as in CommonMark.
+func Heading(line string) (int, string) {
+	line = strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, ""
+	}
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+	title := strings.TrimSpace(rest)
+	title = strings.TrimSpace(strings.TrimRight(title, "#"))
+	if title == "" {
+		return 0, ""
+	}
+	return level, title
+}
+
+// truncate cuts s to n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,86 @@
+// Package timing adds up how long one request spends in embedding calls
+// and database queries, for MCP_VERBOSE_TIMING. Code that does the work
+// records spans against the request context; without a Recorder in the
+// context recording is a no-op.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Span names.
+const (
+	Embedding = "embedding"
+	DB        = "db"
+)
+
+// Recorder accumulates span durations. It is safe for concurrent use, so
+// spans running in parallel are summed and may exceed the total.
+type Recorder struct {
+	start time.Time
+	mu    sync.Mutex
+	spent map[string]time.Duration
+	calls map[string]int
+}
+
+// Span is the time spent in one kind of work and how many calls it took.
+type Span struct {
+	Ms    float64 `json:"ms"`
+	Calls int     `json:"calls"`
+}
+
+// Report is a Recorder's result.
+type Report struct {
+	TotalMs   float64 `json:"total_ms"`
+	Embedding Span    `json:"embedding"`
+	DB        Span    `json:"db"`
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns ctx carrying a new Recorder started now.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{start: time.Now(), spent: map[string]time.Duration{}, calls: map[string]int{}}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// Add records d spent in span against the Recorder of ctx, if any.
+func Add(ctx context.Context, span string, d time.Duration) {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.spent[span] += d
+	r.calls[span]++
+	r.mu.Unlock()
+}
+
+// Start begins a span and returns the function that ends it:
+//
+//	defer timing.Start(ctx, timing.Embedding)()
+func Start(ctx context.Context, span string) func() {
+	if ctx.Value(recorderKey{}) == nil {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() { Add(ctx, span, time.Since(begin)) }
+}
+
+// Report returns the spans so far and the time since the Recorder started.
+func (r *Recorder) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Report{
+		TotalMs:   ms(time.Since(r.start)),
+		Embedding: Span{Ms: ms(r.spent[Embedding]), Calls: r.calls[Embedding]},
+		DB:        Span{Ms: ms(r.spent[DB]), Calls: r.calls[DB]},
+	}
+}
+
+// ms is d in milliseconds, to a microsecond.
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,184 @@
+// Package transcript converts agent session logs into Markdown sessions.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxToolOutput caps how much of a tool result is kept in the Markdown.
+const maxToolOutput = 2000
+
+// Transcript is a parsed session ready to store.
+type Transcript struct {
+	SessionID string
+	Title     string
+	Summary   string
+	Markdown  string
+	StartedAt time.Time
+	Cwd       string
+	GitBranch string
+	Turns     int // user and assistant messages kept
+}
+
+// claudeLine is one record of a Claude Code .jsonl transcript. Only the
+// fields used here are decoded; unknown record types are skipped.
+type claudeLine struct {
+	Type      string         `json:"type"`
+	Summary   string         `json:"summary"`
+	SessionID string         `json:"sessionId"`
+	Timestamp time.Time      `json:"timestamp"`
+	Cwd       string         `json:"cwd"`
+	GitBranch string         `json:"gitBranch"`
+	IsMeta    bool           `json:"isMeta"`
+	Message   *claudeMessage `json:"message"`
+}
+
+type claudeMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// claudeBlock is one content block: text, tool_use, tool_result, or
+// thinking (dropped).
+type claudeBlock struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Content json.RawMessage `json:"content"`
+	IsError bool            `json:"is_error"`
+}
+
+// ParseClaude reads a Claude Code JSONL transcript and renders the
+// conversation as Markdown. The title comes from a summary record when
+// present, otherwise from the first user prompt; the summary is the first
+// summary record or the opening of the first assistant reply.
+func ParseClaude(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	var md strings.Builder
+	var firstPrompt, firstReply string
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var l claudeLine
+		if err := json.Unmarshal([]byte(raw), &l); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if l.Type == "summary" {
+			if t.Title == "" {
+				t.Title = l.Summary
+			}
+			continue
+		}
+		if (l.Type != "user" && l.Type != "assistant") || l.Message == nil || l.IsMeta {
+			continue
+		}
+		if t.SessionID == "" {
+			t.SessionID, t.Cwd, t.GitBranch = l.SessionID, l.Cwd, l.GitBranch
+		}
+		if t.StartedAt.IsZero() {
+			t.StartedAt = l.Timestamp
+		}
+
+		text, tools := renderContent(l.Message.Content)
+		if text == "" && tools == "" {
+			continue
+		}
+		t.Turns++
+		if l.Type == "user" && text != "" {
+			if firstPrompt == "" {
+				firstPrompt = text
+			}
+			fmt.Fprintf(&md, "## User\n\n%s\n\n", text)
+		} else if l.Type == "assistant" && text != "" {
+			if firstReply == "" {
+				firstReply = text
+			}
+			fmt.Fprintf(&md, "## Assistant\n\n%s\n\n", text)
+		}
+		if tools != "" {
+			md.WriteString(tools)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if t.Title == "" {
+		t.Title = firstLine(firstPrompt, 80)
+	}
+	if t.Title == "" {
+		t.Title = "Claude Code session " + t.SessionID
+	}
+	t.Summary = firstLine(firstReply, 500)
+	t.Markdown = strings.TrimSpace(md.String())
+	return t, nil
+}
+
+// renderContent splits message content into prose and a Markdown rendering
+// of tool calls and results. Content is either a string or block array.
+func renderContent(raw json.RawMessage) (text, tools string) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s), ""
+	}
+	var blocks []claudeBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return "", ""
+	}
+	var texts []string
+	var tb strings.Builder
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if t := strings.TrimSpace(b.Text); t != "" {
+				texts = append(texts, t)
+			}
+		case "tool_use":
+			fmt.Fprintf(&tb, "### Tool: %s\n\n```json\n%s\n```\n\n", b.Name, clip(string(b.Input), maxToolOutput))
+		case "tool_result":
+			label := "Result"
+			if b.IsError {
+				label = "Error"
+			}
+			out, _ := renderContent(b.Content)
+			if out != "" {
+				fmt.Fprintf(&tb, "### %s\n\n```\n%s\n```\n\n", label, clip(out, maxToolOutput))
+			}
+		}
+	}
+	return strings.Join(texts, "\n\n"), tb.String()
+}
+
+// firstLine returns the first non-empty line of s, cut to n bytes.
+func firstLine(s string, n int) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "# ")); line != "" {
+			return clip(line, n)
+		}
+	}
+	return ""
+}
+
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
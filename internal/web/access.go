@@ -0,0 +1,62 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+)
+
+// adminPaths act on every project; only an unrestricted token may use them.
+var adminPaths = map[string]bool{
+	"/api/reindex":             true,
+	"/api/reindex/logs":        true,
+	"/api/maintenance/orphans": true,
+	"/api/debug/events":        true,
+}
+
+// requireAccess applies Options.Access to the dashboard. A browser signs
+// in by opening any page with ?token=..., which is moved into a cookie.
+// Requests naming a project outside the token's scope get 403; project
+// lists are filtered by the store.
+func (ws *WebServer) requireAccess(next http.Handler) http.Handler {
+	policy := ws.opts.Access
+	if policy == nil {
+		return next
+	}
+	check := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := access.FromContext(r.Context())
+		if !scope.Unrestricted() {
+			if adminPaths[r.URL.Path] {
+				http.Error(w, "this token may not run admin operations", http.StatusForbidden)
+				return
+			}
+			for _, name := range []string{"project", "project_id"} {
+				if id := r.FormValue(name); id != "" && !scope.Allows(id) {
+					http.Error(w, "this token may not access project "+id, http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+	authed := policy.Middleware(check)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if token := q.Get("token"); token != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     access.CookieName,
+				Value:    token,
+				Path:     ws.opts.BasePath + "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			q.Del("token")
+			u := *r.URL
+			u.Path = ws.opts.BasePath + r.URL.Path
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusSeeOther)
+			return
+		}
+		authed.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Platform-LSS/devmemory/internal/access"
+)
+
+func TestRequireAccess(t *testing.T) {
+	policy, err := access.Parse(strings.NewReader("admin: *\nteam: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := &WebServer{opts: Options{Access: policy, BasePath: "/dm"}}
+	h := ws.requireAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		target   string
+		form     string // POST body
+		status   int
+		location string
+	}{
+		{name: "no token", target: "/", status: http.StatusUnauthorized},
+		{name: "unknown token", token: "nope", target: "/", status: http.StatusUnauthorized},
+		{name: "page", token: "team", target: "/", status: http.StatusOK},
+		{name: "own project", token: "team", target: "/api/memories?project=a", status: http.StatusOK},
+		{name: "other project", token: "team", target: "/api/memories?project=b", status: http.StatusForbidden},
+		{name: "other project_id", token: "team", target: "/api/sessions?project_id=b", status: http.StatusForbidden},
+		{name: "other project in form", token: "team", method: http.MethodPost, target: "/api/memories", form: "project=b", status: http.StatusForbidden},
+		{name: "admin path", token: "team", method: http.MethodPost, target: "/api/reindex", status: http.StatusForbidden},
+		{name: "admin path as admin", token: "admin", method: http.MethodPost, target: "/api/reindex", status: http.StatusOK},
+		{name: "admin any project", token: "admin", target: "/api/memories?project=b", status: http.StatusOK},
+		{name: "sign in", target: "/projects?token=team&x=1", status: http.StatusSeeOther, location: "/dm/projects?x=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, tt.target, strings.NewReader(tt.form))
+			if tt.form != "" {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.location != "" {
+				if got := w.Header().Get("Location"); got != tt.location {
+					t.Errorf("Location = %q, want %q", got, tt.location)
+				}
+				cookies := w.Result().Cookies()
+				if len(cookies) != 1 || cookies[0].Name != access.CookieName || cookies[0].Value != "team" || !cookies[0].HttpOnly {
+					t.Errorf("cookies = %v, want an HttpOnly %s cookie holding the token", cookies, access.CookieName)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireAccessWithoutPolicy(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	ws := &WebServer{}
+	w := httptest.NewRecorder()
+	ws.requireAccess(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/reindex", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d without a policy", w.Code, http.StatusOK)
+	}
+}
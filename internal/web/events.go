@@ -1,13 +1,20 @@
 package web
 
-import "sync"
+import (
+	"errors"
+	"sync"
+)
 
 // EventBus is an in-memory pub/sub for SSE events.
 type EventBus struct {
 	mu      sync.RWMutex
 	clients map[chan string]struct{}
+	max     int // 0 = unlimited; see SetMaxClients
 }
 
+// ErrTooManyClients is returned by Subscribe when the bus is full.
+var ErrTooManyClients = errors.New("too many event subscribers")
+
 // NewEventBus creates a new event bus.
 func NewEventBus() *EventBus {
 	return &EventBus{
@@ -15,22 +22,42 @@ func NewEventBus() *EventBus {
 	}
 }
 
-// Subscribe returns a channel that receives events and an unsubscribe function.
-func (eb *EventBus) Subscribe() (chan string, func()) {
+// SetMaxClients caps the number of concurrent subscribers; 0 removes the
+// cap. Existing subscribers are kept.
+func (eb *EventBus) SetMaxClients(n int) {
+	eb.mu.Lock()
+	eb.max = n
+	eb.mu.Unlock()
+}
+
+// Clients returns the number of current subscribers and the cap.
+func (eb *EventBus) Clients() (n, max int) {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	return len(eb.clients), eb.max
+}
+
+// Subscribe returns a channel that receives events and an unsubscribe
+// function, or ErrTooManyClients if the bus already has its maximum.
+func (eb *EventBus) Subscribe() (chan string, func(), error) {
 	ch := make(chan string, 16)
 	eb.mu.Lock()
+	if eb.max > 0 && len(eb.clients) >= eb.max {
+		eb.mu.Unlock()
+		return nil, nil, ErrTooManyClients
+	}
 	eb.clients[ch] = struct{}{}
 	eb.mu.Unlock()
 
 	unsub := func() {
+		// Publish holds the read lock while sending, so once the channel
+		// is removed under the write lock nothing can send on it.
 		eb.mu.Lock()
 		delete(eb.clients, ch)
 		eb.mu.Unlock()
-		// Drain remaining messages
-		for range ch {
-		}
+		close(ch)
 	}
-	return ch, unsub
+	return ch, unsub, nil
 }
 
 // Publish sends an event name to all subscribed clients.
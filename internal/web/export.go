@@ -0,0 +1,101 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// handleAPIHistoryExport downloads session content as Markdown: one session
+// with num, or every session of the project without it. http.ServeContent
+// handles Range/If-Range and sets Accept-Ranges and Content-Length, so an
+// interrupted download of a large export can resume.
+func (ws *WebServer) handleAPIHistoryExport(w http.ResponseWriter, r *http.Request) {
+	projectID := queryParam(r, "project", "")
+	num := queryInt(r, "num", 0)
+	if projectID == "" {
+		http.Error(w, "Missing params", 400)
+		return
+	}
+
+	var parts []string
+	var modified time.Time
+	name := projectID + "-sessions.md"
+	if num > 0 {
+		sess, err := ws.store.GetSession(r.Context(), projectID, num)
+		if err != nil {
+			slog.Error("export session", "error", err)
+			http.Error(w, "Error", 500)
+			return
+		}
+		if sess == nil {
+			http.NotFound(w, r)
+			return
+		}
+		parts = []string{sess.Content}
+		modified = sess.UpdatedAt
+		name = fmt.Sprintf("%s-session-%d.md", projectID, num)
+	} else {
+		sessions, err := ws.store.ListSessions(r.Context(), projectID)
+		if err != nil {
+			slog.Error("export sessions", "error", err)
+			http.Error(w, "Error", 500)
+			return
+		}
+		for _, s := range sessions {
+			sess, err := ws.store.GetSession(r.Context(), projectID, s.SessionNum)
+			if err != nil || sess == nil {
+				slog.Error("export session", "session", s.SessionNum, "error", err)
+				http.Error(w, "Error", 500)
+				return
+			}
+			parts = append(parts, fmt.Sprintf("# Session %d: %s\n\n", sess.SessionNum, sess.Title), sess.Content, "\n\n")
+			if sess.UpdatedAt.After(modified) {
+				modified = sess.UpdatedAt
+			}
+		}
+	}
+
+	content := newPartsReader(parts)
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	// A strong validator lets If-Range reject a resume after the content changed.
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, modified.UnixNano(), content.Size()))
+	http.ServeContent(w, r, name, modified, content)
+}
+
+// newPartsReader serves several strings as one seekable stream without
+// concatenating them, so a project export isn't copied into one buffer.
+func newPartsReader(parts []string) *io.SectionReader {
+	pr := partsReader{parts: parts}
+	var size int64
+	for _, p := range parts {
+		size += int64(len(p))
+	}
+	return io.NewSectionReader(pr, 0, size)
+}
+
+type partsReader struct {
+	parts []string
+}
+
+func (pr partsReader) ReadAt(b []byte, off int64) (int, error) {
+	n := 0
+	for _, p := range pr.parts {
+		if n == len(b) {
+			break
+		}
+		if off >= int64(len(p)) {
+			off -= int64(len(p))
+			continue
+		}
+		n += copy(b[n:], p[off:])
+		off = 0
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -1,10 +1,12 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/Platform-LSS/devmemory/internal/store"
@@ -20,10 +22,29 @@ func (ws *WebServer) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	stats.EmbeddingStatus = ws.embedding.Status()
-	ws.renderFragment(w, "_stats.html", map[string]any{
+	data := map[string]any{
 		"Stats":  stats,
 		"Period": period,
-	})
+	}
+	if notModified(w, r, "_stats.html", data) {
+		return
+	}
+	ws.renderFragment(w, "_stats.html", data)
+}
+
+// handleAPIProjectStats returns every project's counts as JSON.
+func (ws *WebServer) handleAPIProjectStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := ws.store.GetAllProjectStats(r.Context())
+	if err != nil {
+		slog.Error("project stats", "error", err)
+		http.Error(w, "Error loading stats", 500)
+		return
+	}
+	if stats == nil {
+		stats = []store.ProjectStats{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
 }
 
 // --- Cost Fragment ---
@@ -35,23 +56,55 @@ func (ws *WebServer) handleAPICost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	stats.EmbeddingStatus = ws.embedding.Status()
-	ws.renderFragment(w, "_cost.html", map[string]any{
+	data := map[string]any{
 		"Stats":  stats,
 		"Period": queryParam(r, "period", "24h"),
-	})
+	}
+	if notModified(w, r, "_cost.html", data) {
+		return
+	}
+	ws.renderFragment(w, "_cost.html", data)
 }
 
 // --- Projects Fragment ---
 
+// projectCard is a project's dashboard stats plus its embedding coverage
+// gauge and storage footprint; Coverage is nil when the project is not
+// embedded, Footprint when it could not be measured.
+type projectCard struct {
+	store.ProjectStats
+	Coverage  *store.CoverageStats
+	Footprint *store.Footprint
+}
+
 func (ws *WebServer) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
 	stats, err := ws.store.GetDashboardStats(r.Context())
 	if err != nil {
 		http.Error(w, "Error loading stats", 500)
 		return
 	}
+	cards := make([]projectCard, len(stats.Projects))
+	for i, p := range stats.Projects {
+		cards[i].ProjectStats = p
+		if ws.embedding.Enabled() && p.Project.EmbeddingsEnabled() {
+			if c, err := ws.store.GetEmbeddingCoverage(r.Context(), p.Project.ID); err == nil {
+				cards[i].Coverage = c
+			} else {
+				slog.Warn("embedding coverage", "project", p.Project.ID, "error", err)
+			}
+		}
+		if f, err := ws.store.GetProjectFootprint(r.Context(), p.Project.ID); err == nil {
+			cards[i].Footprint = f
+		} else {
+			slog.Warn("project footprint", "project", p.Project.ID, "error", err)
+		}
+	}
+	if notModified(w, r, "_project_card.html", cards) {
+		return
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	for _, p := range stats.Projects {
-		ws.tmpl.renderFragment("_project_card.html").ExecuteTemplate(w, "_project_card.html", p)
+	for _, c := range cards {
+		ws.tmpl.renderFragment("_project_card.html").ExecuteTemplate(w, "_project_card.html", c)
 	}
 	if len(stats.Projects) == 0 {
 		w.Write([]byte(`<p class="text-zinc-500 col-span-3">No projects registered yet.</p>`))
@@ -104,11 +157,26 @@ func (ws *WebServer) handleAPISearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emb := ws.embedding.Embed(r.Context(), query)
-	results, err := ws.store.SearchAll(r.Context(), query, emb, 10)
+	page := max(queryInt(r, "page", 1), 1)
+	const perPage = 10
+
+	// Embed once per distinct model; entities without an override share it.
+	memEmb := ws.embedder(store.EntityMemory)
+	emb := memEmb.EmbedQuery(r.Context(), query)
+	vectors := store.QueryVectors{Memory: emb, Session: emb, File: emb}
+	if e := ws.embedder(store.EntitySession); e != memEmb {
+		vectors.Session = e.EmbedQuery(r.Context(), query)
+	}
+	if e := ws.embedder(store.EntityFile); e != memEmb {
+		vectors.File = e.EmbedQuery(r.Context(), query)
+	}
+	limits := store.SearchLimits{Default: perPage, Offset: (page - 1) * perPage}
+	results, err := ws.store.SearchAll(r.Context(), query, vectors, limits, store.SearchWeights{})
 	if err != nil {
 		slog.Error("search all", "error", err)
-		http.Error(w, "Search error", 500)
+		// A 200 so HTMX swaps the message in; an error status would leave
+		// the old results looking current.
+		w.Write([]byte(`<p class="text-red-400 p-4">Search failed. Check the server logs and try again.</p>`))
 		return
 	}
 
@@ -116,13 +184,50 @@ func (ws *WebServer) handleAPISearch(w http.ResponseWriter, r *http.Request) {
 	if emb != nil {
 		searchType = "semantic"
 	}
+	// The service is configured but returned nothing: it is failing.
+	degraded := emb == nil && ws.embedding.Enabled()
 
 	ws.renderFragment(w, "_search_results.html", map[string]any{
 		"Query":      query,
 		"SearchType": searchType,
+		"Degraded":   degraded,
 		"Memories":   results.Memories,
 		"Sessions":   results.Sessions,
 		"Files":      results.Files,
+		"Page":       page,
+		"NextPage":   page + 1,
+		"HasMore":    results.HasMore,
+		"NoProjects": slices.Contains(results.Warnings, store.WarnNoProjects),
+		// Any other warning names a project whose search failed.
+		"Failed": slices.DeleteFunc(slices.Clone(results.Warnings), func(w string) bool { return w == store.WarnNoProjects }),
+	})
+}
+
+// --- File Fragments ---
+
+func (ws *WebServer) handleAPIFiles(w http.ResponseWriter, r *http.Request) {
+	projectID := queryParam(r, "project", "")
+	fileType := queryParam(r, "type", "")
+	if projectID == "" {
+		w.Write([]byte(`<p class="text-zinc-500 p-4">Select a file type</p>`))
+		return
+	}
+	files, err := ws.store.ListFiles(r.Context(), projectID)
+	if err != nil {
+		slog.Error("list files", "error", err)
+		http.Error(w, "Error", 500)
+		return
+	}
+	var matching []store.FileEntry
+	for _, f := range files {
+		if f.FileType == fileType {
+			matching = append(matching, f)
+		}
+	}
+	ws.renderFragment(w, "_file_list.html", map[string]any{
+		"Files":     matching,
+		"ProjectID": projectID,
+		"FileType":  fileType,
 	})
 }
 
@@ -191,26 +296,47 @@ func (ws *WebServer) handleAPIMemoryUpdate(w http.ResponseWriter, r *http.Reques
 		value = mem.Value
 	}
 
-	emb := ws.embedding.Embed(r.Context(), value)
-	err := ws.store.SetMemory(r.Context(), &store.Memory{
+	updated := &store.Memory{
 		ProjectID: mem.ProjectID,
 		Topic:     mem.Topic,
 		Key:       mem.Key,
 		Value:     value,
-	}, emb)
+		Status:    store.MemoryPublished,
+	}
+	emb, err := ws.embedValue(r.Context(), updated)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	err = ws.store.SetMemory(r.Context(), updated, emb)
 	if err != nil {
 		slog.Error("update memory", "error", err)
 		http.Error(w, "Error", 500)
 		return
 	}
+	ws.events.Publish("dashboard-stats")
 
-	// Return updated memory card
+	// Return updated memory card; a human edit counts as review
 	mem.Value = value
+	mem.Status = store.MemoryPublished
 	ws.renderFragment(w, "_memory_card", map[string]any{
 		"Memory": mem,
 	})
 }
 
+// embedValue embeds a memory unless its project has embeddings disabled.
+func (ws *WebServer) embedValue(ctx context.Context, m *store.Memory) ([]float32, error) {
+	p, err := ws.store.GetProject(ctx, m.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil && !p.EmbeddingsEnabled() {
+		return nil, nil
+	}
+	emb, _, err := ws.embedder(store.EntityMemory).EmbedValue(ctx, m.EmbedText(ws.opts.MemoryEmbedKey))
+	return emb, err
+}
+
 func (ws *WebServer) handleAPIMemoryDelete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
@@ -227,9 +353,15 @@ func (ws *WebServer) handleAPIMemoryDelete(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Error", 500)
 		return
 	}
+	ws.events.Publish("dashboard-stats")
 
-	// Return empty (HTMX will remove the element)
+	// Return empty (HTMX will remove the element). If that was the
+	// topic's last memory, also drop the topic from the sidebar.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(200)
+	if remaining, err := ws.store.ListMemories(r.Context(), mem.ProjectID, mem.Topic); err == nil && len(remaining) == 0 {
+		w.Write([]byte(`<div id="` + topicID(mem.ProjectID, mem.Topic) + `" hx-swap-oob="delete"></div>`))
+	}
 }
 
 func (ws *WebServer) handleAPIMemoryCreate(w http.ResponseWriter, r *http.Request) {
@@ -244,18 +376,25 @@ func (ws *WebServer) handleAPIMemoryCreate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	emb := ws.embedding.Embed(r.Context(), value)
-	err := ws.store.SetMemory(r.Context(), &store.Memory{
+	mem := &store.Memory{
 		ProjectID: projectID,
 		Topic:     topic,
 		Key:       key,
 		Value:     value,
-	}, emb)
+		Status:    store.MemoryPublished,
+	}
+	emb, err := ws.embedValue(r.Context(), mem)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	err = ws.store.SetMemory(r.Context(), mem, emb)
 	if err != nil {
 		slog.Error("create memory", "error", err)
 		http.Error(w, "Error", 500)
 		return
 	}
+	ws.events.Publish("dashboard-stats")
 
 	// Return the new memory list for the topic
 	memories, _ := ws.store.ListMemories(r.Context(), projectID, topic)
@@ -266,6 +405,40 @@ func (ws *WebServer) handleAPIMemoryCreate(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// --- Review Queue ---
+
+func (ws *WebServer) handleAPIMemoryDrafts(w http.ResponseWriter, r *http.Request) {
+	drafts, err := ws.store.ListDraftMemories(r.Context())
+	if err != nil {
+		slog.Error("list drafts", "error", err)
+		http.Error(w, "Error", 500)
+		return
+	}
+	ws.renderFragment(w, "_memory_list.html", map[string]any{
+		"Memories": drafts,
+	})
+}
+
+func (ws *WebServer) handleAPIMemoryPublish(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, _ := strconv.ParseInt(idStr, 10, 64)
+
+	mem := ws.findMemoryByID(r, id)
+	if mem == nil {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	if err := ws.store.PublishMemory(r.Context(), mem.ProjectID, mem.Topic, mem.Key); err != nil {
+		slog.Error("publish memory", "error", err)
+		http.Error(w, "Error", 500)
+		return
+	}
+	mem.Status = store.MemoryPublished
+	ws.renderFragment(w, "_memory_card", map[string]any{
+		"Memory": mem,
+	})
+}
+
 // findMemoryByID searches across all projects for a memory with the given ID.
 func (ws *WebServer) findMemoryByID(r *http.Request, id int64) *store.Memory {
 	projects, _ := ws.store.ListProjects(r.Context())
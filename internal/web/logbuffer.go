@@ -0,0 +1,67 @@
+package web
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LogBuffer is a fixed-size ring of log lines with live subscribers.
+// New subscribers receive the retained backlog before new lines.
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	start   int
+	count   int
+	clients map[chan string]struct{}
+}
+
+// NewLogBuffer creates a buffer that retains the last size lines.
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{
+		lines:   make([]string, size),
+		clients: make(map[chan string]struct{}),
+	}
+}
+
+// Logf formats a timestamped line, stores it, and fans it out to subscribers.
+func (lb *LogBuffer) Logf(format string, args ...any) {
+	line := time.Now().Format("15:04:05") + " " + fmt.Sprintf(format, args...)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	idx := (lb.start + lb.count) % len(lb.lines)
+	lb.lines[idx] = line
+	if lb.count < len(lb.lines) {
+		lb.count++
+	} else {
+		lb.start = (lb.start + 1) % len(lb.lines)
+	}
+	for ch := range lb.clients {
+		select {
+		case ch <- line:
+		default:
+			// Client too slow, skip
+		}
+	}
+}
+
+// Subscribe returns the current backlog, a channel of subsequent lines,
+// and an unsubscribe function.
+func (lb *LogBuffer) Subscribe() ([]string, chan string, func()) {
+	ch := make(chan string, 64)
+	lb.mu.Lock()
+	backlog := make([]string, 0, lb.count)
+	for i := 0; i < lb.count; i++ {
+		backlog = append(backlog, lb.lines[(lb.start+i)%len(lb.lines)])
+	}
+	lb.clients[ch] = struct{}{}
+	lb.mu.Unlock()
+
+	unsub := func() {
+		lb.mu.Lock()
+		delete(lb.clients, ch)
+		lb.mu.Unlock()
+	}
+	return backlog, ch, unsub
+}
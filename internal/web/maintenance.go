@@ -0,0 +1,35 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// handleAPIOrphans reports rows whose project no longer exists, as JSON for
+// scripts and health checks.
+func (ws *WebServer) handleAPIOrphans(w http.ResponseWriter, r *http.Request) {
+	report, err := ws.store.FindOrphans(r.Context())
+	if err != nil {
+		slog.Error("find orphans", "error", err)
+		http.Error(w, "Error", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"total":   report.Total(),
+		"orphans": report,
+	})
+}
+
+func (ws *WebServer) handleAPIOrphansCleanup(w http.ResponseWriter, r *http.Request) {
+	deleted, err := ws.store.DeleteOrphans(r.Context())
+	if err != nil {
+		slog.Error("delete orphans", "error", err)
+		http.Error(w, "Error", 500)
+		return
+	}
+	slog.Info("orphans deleted", "count", deleted)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"deleted": deleted})
+}
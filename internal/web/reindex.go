@@ -0,0 +1,154 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+
+	"github.com/Platform-LSS/devmemory/internal/store"
+)
+
+// reindexLogSize is how many reindex log lines are kept for late viewers.
+const reindexLogSize = 500
+
+// handleAPIReindex starts a background re-embedding of every memory,
+// session, and file. Only one reindex runs at a time.
+func (ws *WebServer) handleAPIReindex(w http.ResponseWriter, r *http.Request) {
+	if !ws.embedder(store.EntityMemory).Enabled() && !ws.embedder(store.EntitySession).Enabled() && !ws.embedder(store.EntityFile).Enabled() {
+		w.Write([]byte(`<span class="text-red-400">Embedding is disabled; nothing to reindex.</span>`))
+		return
+	}
+	if !ws.reindexing.CompareAndSwap(false, true) {
+		w.Write([]byte(`<span class="text-yellow-400">Reindex already running.</span>`))
+		return
+	}
+	go func() {
+		defer ws.reindexing.Store(false)
+		// Detached from the request: the reindex outlives the POST.
+		ws.runReindex(context.Background())
+	}()
+	w.Write([]byte(`<span class="text-emerald-400">Reindex started.</span>`))
+}
+
+// handleAPIReindexLogs streams reindex log lines as SSE "reindex-log" events.
+func (ws *WebServer) handleAPIReindexLogs(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	backlog, ch, unsub := ws.reindexLog.Subscribe()
+	defer unsub()
+	ping, stop := ws.heartbeat()
+	defer stop()
+
+	for _, line := range backlog {
+		writeLogEvent(w, line)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-ch:
+			writeLogEvent(w, line)
+			flusher.Flush()
+		case <-ping:
+			writePing(w)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogEvent(w http.ResponseWriter, line string) {
+	fmt.Fprintf(w, "event: reindex-log\ndata: <div>%s</div>\n\n", html.EscapeString(line))
+}
+
+// runReindex re-embeds all entities project by project, logging each failure.
+func (ws *WebServer) runReindex(ctx context.Context) {
+	log := ws.reindexLog
+	projects, err := ws.store.ListProjects(ctx)
+	if err != nil {
+		log.Logf("ERROR list projects: %v", err)
+		return
+	}
+	log.Logf("reindex started: %d projects", len(projects))
+
+	var ok, failed int
+	embed := func(entity string, id int64, label, text string) {
+		e := ws.embedder(entity)
+		if !e.Enabled() {
+			return
+		}
+		vec, err := e.EmbedChecked(ctx, text)
+		if err != nil {
+			failed++
+			log.Logf("FAILED %s %s: %v", entity, label, err)
+			return
+		}
+		if vec == nil {
+			failed++
+			log.Logf("FAILED %s %s: no embedding returned", entity, label)
+			return
+		}
+		if err := ws.store.SetEmbedding(ctx, entity, id, vec); err != nil {
+			failed++
+			log.Logf("FAILED %s %s: %v", entity, label, err)
+			return
+		}
+		ok++
+	}
+
+	for _, p := range projects {
+		if !p.EmbeddingsEnabled() {
+			log.Logf("project %s skipped: embeddings disabled", p.ID)
+			continue
+		}
+		log.Logf("project %s", p.ID)
+
+		memories, err := ws.store.ListMemories(ctx, p.ID, "")
+		if err != nil {
+			log.Logf("ERROR list memories for %s: %v", p.ID, err)
+		}
+		for _, m := range memories {
+			text := m.EmbedText(ws.opts.MemoryEmbedKey)
+			if ws.embedder(store.EntityMemory).TooShort(text) {
+				log.Logf("skipped %s %s/%s: too short to embed", store.EntityMemory, m.Topic, m.Key)
+				continue
+			}
+			embed(store.EntityMemory, m.ID, m.Topic+"/"+m.Key, text)
+		}
+
+		sessions, err := ws.store.ListSessions(ctx, p.ID)
+		if err != nil {
+			log.Logf("ERROR list sessions for %s: %v", p.ID, err)
+		}
+		for _, sess := range sessions {
+			text := sess.Summary
+			if text == "" {
+				text = sess.Title
+			}
+			embed(store.EntitySession, sess.ID, fmt.Sprintf("#%d", sess.SessionNum), text)
+		}
+
+		files, err := ws.store.ListFiles(ctx, p.ID)
+		if err != nil {
+			log.Logf("ERROR list files for %s: %v", p.ID, err)
+		}
+		for _, f := range files {
+			embed(store.EntityFile, f.ID, f.FilePath, f.EmbedText(ws.opts.FileEmbedPath))
+		}
+
+		log.Logf("project %s done (%d memories, %d sessions, %d files)", p.ID, len(memories), len(sessions), len(files))
+	}
+
+	log.Logf("reindex complete: %d embedded, %d failed", ok, failed)
+	slog.Info("reindex complete", "embedded", ok, "failed", failed)
+}
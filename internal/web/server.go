@@ -2,37 +2,99 @@ package web
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/Platform-LSS/devmemory/internal/access"
 	"github.com/Platform-LSS/devmemory/internal/embedding"
 	"github.com/Platform-LSS/devmemory/internal/store"
 )
 
+// Options tunes dashboard behaviour.
+type Options struct {
+	// FileEmbedPath mirrors the MCP option so reindexed files embed the
+	// same text as freshly indexed ones.
+	FileEmbedPath bool
+
+	// MemoryEmbedKey mirrors the MCP option for memories edited or
+	// reindexed from the dashboard.
+	MemoryEmbedKey bool
+
+	// SSEHeartbeat is how often SSE streams send a keepalive comment.
+	// Zero disables heartbeats.
+	SSEHeartbeat time.Duration
+
+	// SSEMaxClients caps concurrent /api/events streams; further clients
+	// get 503. Zero is unlimited.
+	SSEMaxClients int
+
+	// BasePath mounts the dashboard under a subpath such as "/devmemory",
+	// for serving behind a reverse proxy. Empty serves it at the root.
+	BasePath string
+
+	// Access, when set, requires a token on every request and limits it
+	// to the token's projects. Nil leaves the dashboard open.
+	Access access.Policy
+}
+
 // WebServer serves the GOTH-stack dashboard.
 type WebServer struct {
 	store     store.Store
 	embedding *embedding.Service
 	events    *EventBus
 	tmpl      *pageTemplates
+	opts      Options
+
+	reindexing atomic.Bool // a reindex is in progress
+	reindexLog *LogBuffer  // reindex progress for the dashboard console
+
+	// entityEmbedding overrides embedding for one entity type.
+	entityEmbedding map[string]*embedding.Service
 }
 
 // New creates a WebServer with parsed templates.
-func New(s store.Store, emb *embedding.Service) (*WebServer, error) {
-	tmpl, err := loadTemplates()
+func New(s store.Store, emb *embedding.Service, opts Options) (*WebServer, error) {
+	tmpl, err := loadTemplates(opts.BasePath)
 	if err != nil {
 		return nil, fmt.Errorf("parse templates: %w", err)
 	}
+	events := NewEventBus()
+	events.SetMaxClients(opts.SSEMaxClients)
 	return &WebServer{
-		store:     s,
-		embedding: emb,
-		events:    NewEventBus(),
-		tmpl:      tmpl,
+		store:      s,
+		embedding:  emb,
+		events:     events,
+		tmpl:       tmpl,
+		opts:       opts,
+		reindexLog: NewLogBuffer(reindexLogSize),
 	}, nil
 }
 
+// SetEntityEmbedding makes entity embed with emb instead of the shared
+// service, matching the MCP server's setting.
+func (ws *WebServer) SetEntityEmbedding(entity string, emb *embedding.Service) {
+	if ws.entityEmbedding == nil {
+		ws.entityEmbedding = map[string]*embedding.Service{}
+	}
+	ws.entityEmbedding[entity] = emb
+}
+
+// embedder returns the embedding service for entity.
+func (ws *WebServer) embedder(entity string) *embedding.Service {
+	if e, ok := ws.entityEmbedding[entity]; ok {
+		return e
+	}
+	return ws.embedding
+}
+
 // Events returns the event bus for use by MCP tool handlers.
 func (ws *WebServer) Events() *EventBus {
 	return ws.events
@@ -47,21 +109,41 @@ func (ws *WebServer) Routes() http.Handler {
 	mux.HandleFunc("GET /history", ws.handleHistory)
 	mux.HandleFunc("GET /search", ws.handleSearch)
 	mux.HandleFunc("GET /memories", ws.handleMemories)
+	mux.HandleFunc("GET /files", ws.handleFiles)
 
 	// HTMX partials
+	mux.HandleFunc("GET /api/events", ws.handleAPIEvents)
+	mux.HandleFunc("GET /api/debug/events", ws.handleAPIDebugEvents)
 	mux.HandleFunc("GET /api/stats", ws.handleAPIStats)
+	mux.HandleFunc("GET /api/stats/projects", ws.handleAPIProjectStats)
 	mux.HandleFunc("GET /api/cost", ws.handleAPICost)
 	mux.HandleFunc("GET /api/projects", ws.handleAPIProjects)
 	mux.HandleFunc("GET /api/history/sessions", ws.handleAPISessions)
 	mux.HandleFunc("GET /api/history/detail", ws.handleAPISessionDetail)
+	mux.HandleFunc("GET /api/history/export", ws.handleAPIHistoryExport)
 	mux.HandleFunc("GET /api/search", ws.handleAPISearch)
 	mux.HandleFunc("GET /api/memories", ws.handleAPIMemories)
+	mux.HandleFunc("GET /api/files", ws.handleAPIFiles)
 	mux.HandleFunc("GET /api/memories/edit/{id}", ws.handleAPIMemoryEdit)
 	mux.HandleFunc("PUT /api/memories/{id}", ws.handleAPIMemoryUpdate)
 	mux.HandleFunc("DELETE /api/memories/{id}", ws.handleAPIMemoryDelete)
 	mux.HandleFunc("POST /api/memories", ws.handleAPIMemoryCreate)
+	mux.HandleFunc("GET /api/memories/drafts", ws.handleAPIMemoryDrafts)
+	mux.HandleFunc("POST /api/memories/{id}/publish", ws.handleAPIMemoryPublish)
+	mux.HandleFunc("POST /api/reindex", ws.handleAPIReindex)
+	mux.HandleFunc("GET /api/reindex/logs", ws.handleAPIReindexLogs)
+	mux.HandleFunc("GET /api/maintenance/orphans", ws.handleAPIOrphans)
+	mux.HandleFunc("DELETE /api/maintenance/orphans", ws.handleAPIOrphansCleanup)
 
-	return requestLogger(mux)
+	h := ws.requireAccess(mux)
+	if ws.opts.BasePath == "" {
+		return requestLogger(h)
+	}
+	// Serve everything under the base path; the mux redirects the bare
+	// base path to its trailing-slash form.
+	root := http.NewServeMux()
+	root.Handle(ws.opts.BasePath+"/", http.StripPrefix(ws.opts.BasePath, h))
+	return requestLogger(root)
 }
 
 // --- Full Page Handlers ---
@@ -106,19 +188,11 @@ func (ws *WebServer) handleMemories(w http.ResponseWriter, r *http.Request) {
 
 	type topicGroup struct {
 		Project store.Project
-		Topics  []string
+		Topics  []store.TopicCount
 	}
 	var groups []topicGroup
 	for _, p := range projects {
-		mems, _ := ws.store.ListMemories(r.Context(), p.ID, "")
-		seen := map[string]bool{}
-		var topics []string
-		for _, m := range mems {
-			if !seen[m.Topic] {
-				seen[m.Topic] = true
-				topics = append(topics, m.Topic)
-			}
-		}
+		topics, _ := ws.store.ListTopicCounts(r.Context(), p.ID)
 		groups = append(groups, topicGroup{Project: p, Topics: topics})
 	}
 
@@ -128,6 +202,25 @@ func (ws *WebServer) handleMemories(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (ws *WebServer) handleFiles(w http.ResponseWriter, r *http.Request) {
+	projects, _ := ws.store.ListProjects(r.Context())
+
+	type typeGroup struct {
+		Project store.Project
+		Types   []store.TypeCount
+	}
+	var groups []typeGroup
+	for _, p := range projects {
+		types, _ := ws.store.ListFileTypes(r.Context(), p.ID)
+		groups = append(groups, typeGroup{Project: p, Types: types})
+	}
+
+	ws.renderPage(w, "files.html", map[string]any{
+		"Groups": groups,
+		"Active": "files",
+	})
+}
+
 // --- Helpers ---
 
 func (ws *WebServer) renderPage(w http.ResponseWriter, name string, data any) {
@@ -159,6 +252,27 @@ func (ws *WebServer) renderFragment(w http.ResponseWriter, name string, data any
 	w.Write(buf.Bytes())
 }
 
+// notModified sets an ETag derived from a fragment's data and answers 304
+// when the client already has it, so polled fragments skip rendering
+// while nothing changes. no-cache makes the browser revalidate each poll.
+func notModified(w http.ResponseWriter, r *http.Request, name string, data any) bool {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(append([]byte(name+"\x00"), b...))
+	etag := `"` + hex.EncodeToString(sum[:12]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if tag = strings.TrimSpace(tag); tag == etag || tag == "W/"+etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
 func queryParam(r *http.Request, name, fallback string) string {
 	v := r.URL.Query().Get(name)
 	if v == "" {
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// heartbeat returns a channel that ticks every SSE heartbeat interval and
+// a stop function. With heartbeats disabled the channel never fires.
+func (ws *WebServer) heartbeat() (<-chan time.Time, func()) {
+	if ws.opts.SSEHeartbeat <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTicker(ws.opts.SSEHeartbeat)
+	return t.C, t.Stop
+}
+
+// handleAPIDebugEvents reports the number of SSE subscribers and the
+// SSE_MAX_CLIENTS cap, for spotting leaked or abusive connections.
+func (ws *WebServer) handleAPIDebugEvents(w http.ResponseWriter, r *http.Request) {
+	n, max := ws.events.Clients()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"subscribers": n, "max_clients": max})
+}
+
+// writePing writes an SSE comment line, which clients ignore but which
+// keeps proxies from closing an idle connection.
+func writePing(w http.ResponseWriter) {
+	fmt.Fprint(w, ": ping\n\n")
+}
+
+// handleAPIEvents streams EventBus events (e.g. "dashboard-stats") so the
+// dashboard can refresh when data changes instead of only on a timer.
+func (ws *WebServer) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", 500)
+		return
+	}
+	ch, unsub, err := ws.events.Subscribe()
+	if err != nil {
+		// The dashboard still works without live updates; htmx's SSE
+		// extension retries with backoff.
+		slog.Warn("rejecting SSE client", "error", err)
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unsub()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ping, stop := ws.heartbeat()
+	defer stop()
+
+	writePing(w)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, event)
+			flusher.Flush()
+		case <-ping:
+			writePing(w)
+			flusher.Flush()
+		}
+	}
+}
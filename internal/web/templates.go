@@ -2,6 +2,7 @@ package web
 
 import (
 	"embed"
+	"encoding/hex"
 	"fmt"
 	"html/template"
 	"math"
@@ -18,10 +19,14 @@ type pageTemplates struct {
 	pages map[string]*template.Template
 }
 
-func loadTemplates() (*pageTemplates, error) {
+// loadTemplates parses the templates; basePath prefixes the URLs built
+// with the url func.
+func loadTemplates(basePath string) (*pageTemplates, error) {
 	funcMap := template.FuncMap{
+		"url":        func(path string) string { return basePath + path },
 		"comma":      commaFormat,
 		"cost":       costFormat,
+		"bytes":      bytesFormat,
 		"truncate":   truncate,
 		"timeAgo":    timeAgo,
 		"scoreColor": scoreColor,
@@ -32,6 +37,7 @@ func loadTemplates() (*pageTemplates, error) {
 		"mul":        func(a, b int) int { return a * b },
 		"list":       func(items ...string) []string { return items },
 		"div":        func(a, b int) int { if b == 0 { return 0 }; return a / b },
+		"topicID":    topicID,
 	}
 
 	// Parse layout + all fragment templates into a base
@@ -50,6 +56,7 @@ func loadTemplates() (*pageTemplates, error) {
 		"templates/search.html",
 		"templates/history.html",
 		"templates/memories.html",
+		"templates/files.html",
 	}
 	for _, pf := range pageFiles {
 		clone, err := base.Clone()
@@ -106,6 +113,27 @@ func costFormat(tokens int, pricePerMTok float64) string {
 	return fmt.Sprintf("$%.2f", cost)
 }
 
+// bytesFormat renders a byte count in binary units, e.g. "3.2 MiB".
+func bytesFormat(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	f := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		f /= 1024
+		if f < 1024 || unit == "GiB" {
+			return fmt.Sprintf("%.1f %s", f, unit)
+		}
+	}
+	return ""
+}
+
+// topicID is the element id of a topic's sidebar link on the memories
+// page, so a response can remove the link once the topic is empty.
+func topicID(projectID, topic string) string {
+	return "topic-" + hex.EncodeToString([]byte(projectID+"\x00"+topic))
+}
+
 func truncate(s string, n int) string {
 	if len(s) <= n {
 		return s
@@ -0,0 +1,83 @@
+// Package webhook posts search summaries to an external endpoint for
+// logging or analytics, off the request path.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// queueSize is how many events may wait for delivery; more are dropped.
+const queueSize = 256
+
+// Hit is one search result: its entity type, row id and score.
+type Hit struct {
+	Entity string  `json:"entity"`
+	ID     int64   `json:"id"`
+	Score  float64 `json:"score"`
+}
+
+// SearchEvent summarizes one search tool call.
+type SearchEvent struct {
+	Tool       string    `json:"tool"`
+	ProjectID  string    `json:"project_id,omitempty"`
+	Query      string    `json:"query"`
+	SearchType string    `json:"search_type"`
+	Results    []Hit     `json:"results"`
+	Time       time.Time `json:"time"`
+}
+
+// Notifier delivers events to one URL from a background goroutine, so a
+// slow or failing endpoint never delays a tool response. A nil *Notifier
+// drops everything.
+type Notifier struct {
+	url    string
+	client *http.Client
+	queue  chan []byte
+}
+
+// New starts a notifier posting to url.
+func New(url string) *Notifier {
+	n := &Notifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan []byte, queueSize),
+	}
+	go n.run()
+	return n
+}
+
+// Send queues ev for delivery. It never blocks; when the queue is full
+// the event is dropped and logged.
+func (n *Notifier) Send(ev SearchEvent) {
+	if n == nil {
+		return
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		slog.Warn("webhook: encode event", "error", err)
+		return
+	}
+	select {
+	case n.queue <- body:
+	default:
+		slog.Warn("webhook: queue full, dropping event", "tool", ev.Tool)
+	}
+}
+
+func (n *Notifier) run() {
+	for body := range n.queue {
+		resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			slog.Warn("webhook: post failed", "error", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			slog.Warn("webhook: unexpected status", "status", resp.Status)
+		}
+	}
+}
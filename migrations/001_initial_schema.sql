@@ -1,7 +1,5 @@
 -- DevMemory: AI Development Memory System
--- PostgreSQL 16 + pgvector
-
-CREATE EXTENSION IF NOT EXISTS vector;
+-- PostgreSQL 16; the pgvector columns and indexes are in 001_vector.sql
 
 -- Projects table: multi-project support
 CREATE TABLE projects (
@@ -13,21 +11,20 @@ CREATE TABLE projects (
     updated_at  TIMESTAMPTZ DEFAULT now()
 );
 
--- Memories: key-value entries with semantic embeddings
+-- Memories: key-value entries
 CREATE TABLE memories (
     id          BIGSERIAL PRIMARY KEY,
     project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
     topic       TEXT NOT NULL,
     key         TEXT NOT NULL,
     value       TEXT NOT NULL,
-    embedding   vector(384),
     created_at  TIMESTAMPTZ DEFAULT now(),
     updated_at  TIMESTAMPTZ DEFAULT now(),
     created_by  TEXT DEFAULT '',
     UNIQUE(project_id, topic, key)
 );
 
--- Sessions: transcripts with semantic embeddings
+-- Sessions: numbered transcripts
 CREATE TABLE sessions (
     id          BIGSERIAL PRIMARY KEY,
     project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
@@ -35,13 +32,12 @@ CREATE TABLE sessions (
     title       TEXT NOT NULL,
     summary     TEXT DEFAULT '',
     content     TEXT DEFAULT '',
-    embedding   vector(384),
     metadata    JSONB DEFAULT '{}',
     created_at  TIMESTAMPTZ DEFAULT now(),
     UNIQUE(project_id, session_num)
 );
 
--- File index: project files with metadata and embeddings
+-- File index: project files with metadata
 CREATE TABLE file_index (
     id           BIGSERIAL PRIMARY KEY,
     project_id   TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
@@ -49,19 +45,10 @@ CREATE TABLE file_index (
     file_type    TEXT DEFAULT '',
     symbols      JSONB DEFAULT '[]',
     summary      TEXT DEFAULT '',
-    embedding    vector(384),
     last_indexed TIMESTAMPTZ DEFAULT now(),
     UNIQUE(project_id, file_path)
 );
 
--- HNSW indexes for semantic search
-CREATE INDEX idx_memories_embedding ON memories
-    USING hnsw (embedding vector_cosine_ops);
-CREATE INDEX idx_sessions_embedding ON sessions
-    USING hnsw (embedding vector_cosine_ops);
-CREATE INDEX idx_files_embedding ON file_index
-    USING hnsw (embedding vector_cosine_ops);
-
 -- B-tree indexes for keyword lookups
 CREATE INDEX idx_memories_project_topic ON memories(project_id, topic);
 CREATE INDEX idx_memories_project_key ON memories(project_id, key);
@@ -0,0 +1,16 @@
+-- Semantic search: pgvector embeddings and their HNSW indexes. Skipped on
+-- a server without pgvector (keyword-only mode) and applied by a later
+-- run once it is installed, so every statement is idempotent.
+-- devmemory:requires-vector
+CREATE EXTENSION IF NOT EXISTS vector;
+
+ALTER TABLE memories ADD COLUMN IF NOT EXISTS embedding vector(384);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS embedding vector(384);
+ALTER TABLE file_index ADD COLUMN IF NOT EXISTS embedding vector(384);
+
+CREATE INDEX IF NOT EXISTS idx_memories_embedding ON memories
+    USING hnsw (embedding vector_cosine_ops);
+CREATE INDEX IF NOT EXISTS idx_sessions_embedding ON sessions
+    USING hnsw (embedding vector_cosine_ops);
+CREATE INDEX IF NOT EXISTS idx_files_embedding ON file_index
+    USING hnsw (embedding vector_cosine_ops);
@@ -0,0 +1,7 @@
+-- Memory approval workflow: agent-written memories start as drafts and
+-- are hidden from search until published. Existing rows stay published.
+ALTER TABLE memories
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published'));
+
+CREATE INDEX IF NOT EXISTS idx_memories_drafts ON memories(project_id) WHERE status = 'draft';
@@ -0,0 +1,6 @@
+-- Include file path and type in file full-text search so queries for a
+-- path component ("migrations") match. Path separators become spaces so
+-- each component is its own lexeme.
+DROP INDEX IF EXISTS idx_files_fts;
+CREATE INDEX idx_files_fts ON file_index
+    USING GIN (to_tsvector('english', coalesce(summary, '') || ' ' || translate(coalesce(file_path, ''), '/._-', '    ') || ' ' || coalesce(file_type, '')));
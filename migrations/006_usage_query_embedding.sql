@@ -0,0 +1,6 @@
+-- Optional query embeddings for usage_search (USAGE_EMBED_QUERIES=true).
+-- devmemory:requires-vector
+ALTER TABLE usage_stats ADD COLUMN IF NOT EXISTS query_embedding vector(384);
+
+CREATE INDEX IF NOT EXISTS idx_usage_stats_query_embedding ON usage_stats
+    USING hnsw (query_embedding vector_cosine_ops);
@@ -0,0 +1,6 @@
+-- Track when a session was last written so session_create can detect
+-- concurrent overwrites (optimistic concurrency via expected_updated_at).
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE sessions SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE sessions ALTER COLUMN updated_at SET DEFAULT now();
+ALTER TABLE sessions ALTER COLUMN updated_at SET NOT NULL;
@@ -0,0 +1,6 @@
+-- Free-form labels on memories, e.g. "security", applied with
+-- memory_bulk_tag. Kept as an array; a memory has only a handful.
+ALTER TABLE memories
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_memories_tags ON memories USING GIN (tags);
@@ -0,0 +1,6 @@
+-- The embedding model version (EMBEDDING_MODEL_VERSION) each vector came
+-- from. Searches only compare vectors of the current version; NULL marks
+-- vectors stored before versions were tracked.
+ALTER TABLE memories ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+ALTER TABLE file_index ADD COLUMN IF NOT EXISTS embedding_model TEXT;
@@ -0,0 +1,15 @@
+-- Incremental sync (memory_changes): find memories written since a cursor
+-- without scanning the project, and remember deleted ones as tombstones
+-- so mirrors can drop them too.
+CREATE INDEX IF NOT EXISTS idx_memories_updated ON memories (project_id, updated_at);
+
+CREATE TABLE IF NOT EXISTS memory_deletions (
+    id          BIGSERIAL PRIMARY KEY,
+    memory_id   BIGINT NOT NULL,
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    topic       TEXT NOT NULL,
+    key         TEXT NOT NULL,
+    deleted_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_memory_deletions_project ON memory_deletions (project_id, deleted_at);
@@ -0,0 +1,4 @@
+-- Short extractive summary of long memory values for list views, filled
+-- in on write when MEMORY_SUMMARY_THRESHOLD is set. Empty means none.
+ALTER TABLE memories
+    ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
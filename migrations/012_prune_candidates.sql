@@ -0,0 +1,19 @@
+-- Prune suggestions (prune_suggestions): the last scan of each project,
+-- which replayed its past memory searches, and the memories no replayed
+-- search returned above the score floor. Flags only; nothing is deleted.
+CREATE TABLE IF NOT EXISTS prune_scans (
+    project_id    TEXT PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    scanned_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    window_start  TIMESTAMPTZ NOT NULL,
+    queries       INTEGER NOT NULL DEFAULT 0,
+    min_score     REAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS prune_candidates (
+    memory_id   BIGINT PRIMARY KEY REFERENCES memories(id) ON DELETE CASCADE,
+    project_id  TEXT NOT NULL REFERENCES prune_scans(project_id) ON DELETE CASCADE,
+    best_score  REAL NOT NULL DEFAULT 0,
+    flagged_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_prune_candidates_project ON prune_candidates (project_id);
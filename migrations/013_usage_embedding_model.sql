@@ -0,0 +1,4 @@
+-- The embedding model version (EMBEDDING_MODEL_VERSION) of each stored
+-- query embedding, so query suggestions and clustering only compare
+-- vectors of the current version, as 009 does for memories and sessions.
+ALTER TABLE usage_stats ADD COLUMN IF NOT EXISTS embedding_model TEXT;